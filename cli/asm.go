@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+)
+
+func Asm(args []string) int {
+	flagSet := flag.NewFlagSet("asm", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: sicsimgo asm <file.asm> [-o file.obj] [-l file.lst] [-g] [-dialect name] [-auto-extend] [-sic]")
+		flagSet.PrintDefaults()
+	}
+	objFileName := flagSet.String("o", "", "object file `path` (default: source name with .obj)")
+	lstFileName := flagSet.String("l", "", "listing file `path` (default: no listing)")
+	debugInfo := flagSet.Bool("g", false, "write debug file next to the object file")
+	assemblerFlags := addAssemblerFlags(flagSet)
+
+	fileNames, err := parseArgs(flagSet, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(fileNames) != 1 {
+		flagSet.Usage()
+		return ExitUsage
+	}
+	sourceFileName := fileNames[0]
+	if *objFileName == "" {
+		*objFileName = strings.TrimSuffix(sourceFileName, filepath.Ext(sourceFileName)) + ".obj"
+	}
+
+	if err := assemblerFlags.apply(); err != nil {
+		return errorf("%v", err)
+	}
+
+	_, _, loadedProgramType, err := loader.LoadProgramFile(sourceFileName)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if loadedProgramType != loader.Assembly {
+		return errorf("%s: not an assembly file", sourceFileName)
+	}
+
+	// Listing is written even when assembly fails, as it shows the errors
+	if *lstFileName != "" {
+		if err := writeFile(*lstFileName, loader.WriteLstFile); err != nil {
+			return errorf("%v", err)
+		}
+	}
+
+	assemblyErrors := assembly.GetErrors(loader.SyntaxNodes)
+	printAssemblyDiagnostics(sourceFileName, assemblyErrors, assembly.GetWarnings(loader.SyntaxNodes))
+	if len(assemblyErrors) > 0 {
+		return ExitFailure
+	}
+
+	if err := writeFile(*objFileName, loader.WriteObjFile); err != nil {
+		return errorf("%v", err)
+	}
+	if *debugInfo {
+		if err := writeFile(loader.GetDbgFileName(*objFileName), loader.WriteDbgFile); err != nil {
+			return errorf("%v", err)
+		}
+	}
+
+	return ExitSuccess
+}
+
+// Prints errors in file:line:col format understood by editors
+func printAssemblyErrors(sourceFileName string, assemblyErrors []assembly.AssemblyError) {
+	printAssemblyDiagnostics(sourceFileName, assemblyErrors, nil)
+}
+
+// Errors and warnings are printed together in source order
+func printAssemblyDiagnostics(sourceFileName string, assemblyErrors []assembly.AssemblyError, assemblyWarnings []assembly.AssemblyError) {
+	type diagnostic struct {
+		assembly.AssemblyError
+		prefix string
+	}
+	var diagnostics []diagnostic
+	for _, assemblyError := range assemblyErrors {
+		diagnostics = append(diagnostics, diagnostic{AssemblyError: assemblyError})
+	}
+	for _, assemblyWarning := range assemblyWarnings {
+		diagnostics = append(diagnostics, diagnostic{AssemblyError: assemblyWarning, prefix: "warning: "})
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].LineNumber != diagnostics[j].LineNumber {
+			return diagnostics[i].LineNumber < diagnostics[j].LineNumber
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s%s\n", sourceFileName, diagnostic.LineNumber, diagnostic.Column, diagnostic.prefix, diagnostic.Message)
+	}
+}
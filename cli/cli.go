@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+)
+
+/*
+DEFINITIONS
+*/
+// Assembler options shared by commands which read assembly sources
+type assemblerFlags struct {
+	dialect    string
+	autoExtend bool
+	sic        bool
+}
+
+// Keeps the first write error, as writers of output files don't return it
+type errorWriter struct {
+	writer io.Writer
+	err    error
+}
+
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string) int
+}
+
+const (
+	ExitSuccess int = 0
+	ExitFailure int = 1
+	ExitUsage   int = 2
+)
+
+/*
+IMPLEMENTATION
+*/
+var commands []command
+
+func init() {
+	commands = []command{
+		{Name: "asm", Description: "assemble a program into object and listing files", Run: Asm},
+		{Name: "dap", Description: "serve the Debug Adapter Protocol for debugging in editors", Run: Dap},
+		{Name: "disasm", Description: "write an object program as assembly source", Run: Disasm},
+		{Name: "run", Description: "run a program without the window and print performance counters", Run: Execute},
+		{Name: "xref", Description: "print symbol cross-reference of an assembly program", Run: Xref},
+	}
+}
+
+/*
+OPERATIONS
+*/
+func Run(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+
+	for _, command := range commands {
+		if command.Name == args[0] {
+			return command.Run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "sicsimgo: unknown command %q\n", args[0])
+	printUsage(os.Stderr)
+	return ExitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: sicsimgo [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the graphical simulator is started.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, command := range commands {
+		fmt.Fprintf(w, "    %-10s %s\n", command.Name, command.Description)
+	}
+}
+
+// Parses flags which may appear before, between or after positional arguments
+func parseArgs(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flagSet.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func addAssemblerFlags(flagSet *flag.FlagSet) *assemblerFlags {
+	flags := &assemblerFlags{}
+	flagSet.StringVar(&flags.dialect, "dialect", assembly.DialectSicSimGo.String(), "source `dialect`: sicsimgo or sictools")
+	flagSet.BoolVar(&flags.autoExtend, "auto-extend", false, "promote instructions with out of range operands to format 4 or SIC format")
+	flagSet.BoolVar(&flags.sic, "sic", false, "assemble for plain SIC, same as the SIC directive")
+	return flags
+}
+
+// Sets loader options from parsed flags
+func (flags *assemblerFlags) apply() error {
+	dialect, err := assembly.ParseDialect(flags.dialect)
+	if err != nil {
+		return err
+	}
+	loader.AssemblerOptions.Dialect = dialect
+	loader.AssemblerOptions.AutoExtend = flags.autoExtend
+	loader.AssemblerOptions.SIC = flags.sic
+	return nil
+}
+
+func writeFile(fileName string, write func(w io.Writer)) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	writer := &errorWriter{writer: file}
+	write(writer)
+
+	closeErr := file.Close()
+	if writer.err != nil {
+		return writer.err
+	}
+	return closeErr
+}
+
+func (writer *errorWriter) Write(p []byte) (int, error) {
+	if writer.err != nil {
+		return 0, writer.err
+	}
+	n, err := writer.writer.Write(p)
+	writer.err = err
+	return n, err
+}
+
+// Servers for debuggers listen on address such as :1234, only on loopback addresses
+func listenLoopback(address string) (net.Listener, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, ErrNotLoopback(host)
+	}
+	return net.Listen("tcp", net.JoinHostPort(host, port))
+}
+
+func errorf(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "sicsimgo: "+format+"\n", args...)
+	return ExitFailure
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Runs a command, returning its exit code and what it wrote to standard error
+func runCommand(t *testing.T, args []string) (int, string) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	exitCode := Run(args)
+	writer.Close()
+	return exitCode, <-output
+}
+
+func TestRun(t *testing.T) {
+	directory := t.TempDir()
+	validFileName := filepath.Join(directory, "valid.asm")
+	invalidFileName := filepath.Join(directory, "invalid.asm")
+	warningFileName := filepath.Join(directory, "warning.asm")
+	sources := map[string]string{
+		validFileName: `prog  START 0
+      LDA   #5
+halt  J     halt
+      END   prog
+`,
+		invalidFileName: `prog  START 0
+      LDA   #5
+      JSUB  nosuch
+      END   prog
+`,
+		warningFileName: `prog  START 0
+      LDA   far
+      JSUB  nosuch
+      RESB  4096
+far   WORD  1
+      END   prog
+`,
+	}
+	for fileName, source := range sources {
+		if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		args             []string
+		expectedExitCode int
+		expectedStderr   string
+		expectedFile     string
+	}{
+		{
+			name:             "No command",
+			args:             []string{},
+			expectedExitCode: ExitUsage,
+			expectedStderr:   "usage: sicsimgo [command] [arguments]",
+		},
+		{
+			name:             "Unknown command",
+			args:             []string{"nosuch"},
+			expectedExitCode: ExitUsage,
+			expectedStderr:   `sicsimgo: unknown command "nosuch"`,
+		},
+		{
+			name:             "Missing source",
+			args:             []string{"asm"},
+			expectedExitCode: ExitUsage,
+			expectedStderr:   "usage: sicsimgo asm",
+		},
+		{
+			name:             "Unknown flag",
+			args:             []string{"asm", validFileName, "-nosuch"},
+			expectedExitCode: ExitUsage,
+			expectedStderr:   "flag provided but not defined: -nosuch",
+		},
+		{
+			name:             "Assembled",
+			args:             []string{"asm", validFileName, "-o", filepath.Join(directory, "valid.obj")},
+			expectedExitCode: ExitSuccess,
+			expectedFile:     filepath.Join(directory, "valid.obj"),
+		},
+		{
+			name:             "Assembly errors",
+			args:             []string{"asm", invalidFileName},
+			expectedExitCode: ExitFailure,
+			expectedStderr:   invalidFileName + ":3:13: Undefined symbol: nosuch\n",
+		},
+		{
+			name:             "Warnings and errors in source order",
+			args:             []string{"asm", warningFileName, "-auto-extend"},
+			expectedExitCode: ExitFailure,
+			expectedStderr:   warningFileName + ":2:7: warning: LDA encoded in SIC format, address out of range for format 3\n" + warningFileName + ":3:13: Undefined symbol: nosuch\n",
+		},
+		{
+			name:             "Missing file",
+			args:             []string{"xref", filepath.Join(directory, "nosuch.asm")},
+			expectedExitCode: ExitFailure,
+			expectedStderr:   "sicsimgo: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode, stderr := runCommand(t, tt.args)
+			if exitCode != tt.expectedExitCode {
+				t.Errorf("Run() = %d, want %d, stderr:\n%s", exitCode, tt.expectedExitCode, stderr)
+			}
+			if !strings.Contains(stderr, tt.expectedStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.expectedStderr)
+			}
+			if tt.expectedFile != "" {
+				if _, err := os.Stat(tt.expectedFile); err != nil {
+					t.Errorf("file not written: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFileError(t *testing.T) {
+	// Writes to /dev/full fail with no space left on the device
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full")
+	}
+	err := writeFile("/dev/full", func(w io.Writer) {
+		io.WriteString(w, "listing")
+	})
+	if err == nil {
+		t.Error("writeFile() = nil, want the write error")
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sicsimgo/dap"
+)
+
+func Dap(args []string) int {
+	flagSet := flag.NewFlagSet("dap", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: sicsimgo dap [-listen address]")
+		flagSet.PrintDefaults()
+	}
+	listenAddress := flagSet.String("listen", "", "wait for an editor on loopback `address` such as :4711 instead of using standard input and output")
+
+	positional, err := parseArgs(flagSet, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		flagSet.Usage()
+		return ExitUsage
+	}
+
+	// Program is chosen by the editor's launch request
+	if *listenAddress == "" {
+		if err := dap.Serve(os.Stdin, os.Stdout); err != nil {
+			return errorf("%v", err)
+		}
+		return ExitSuccess
+	}
+
+	listener, err := listenLoopback(*listenAddress)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "sicsimgo: waiting for an editor on %s\n", listener.Addr())
+	conn, err := listener.Accept()
+	listener.Close()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	defer conn.Close()
+	if err := dap.Serve(conn, conn); err != nil {
+		return errorf("%v", err)
+	}
+	return ExitSuccess
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sicsimgo/core/loader"
+)
+
+func Disasm(args []string) int {
+	flagSet := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: sicsimgo disasm <file.obj> [-o file.asm]")
+		flagSet.PrintDefaults()
+	}
+	asmFileName := flagSet.String("o", "", "assembly file `path` (default: standard output)")
+
+	fileNames, err := parseArgs(flagSet, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(fileNames) != 1 {
+		flagSet.Usage()
+		return ExitUsage
+	}
+	objFileName := fileNames[0]
+
+	_, _, loadedProgramType, err := loader.LoadProgramFile(objFileName)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if loadedProgramType != loader.Bytecode {
+		return errorf("%s: not an object file", objFileName)
+	}
+
+	// Sources are not overwritten unless asked for
+	if *asmFileName == "" {
+		loader.WriteAsmFile(os.Stdout)
+		return ExitSuccess
+	}
+	if err := writeFile(*asmFileName, loader.WriteAsmFile); err != nil {
+		return errorf("%v", err)
+	}
+
+	return ExitSuccess
+}
@@ -0,0 +1,9 @@
+package cli
+
+import (
+	"fmt"
+)
+
+func ErrNotLoopback(host string) error {
+	return fmt.Errorf("Debugger servers only listen on loopback addresses, not %s", host)
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sicsimgo/core"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/proc"
+	"sicsimgo/gdb"
+)
+
+func Execute(args []string) int {
+	flagSet := flag.NewFlagSet("run", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: sicsimgo run <file.asm|file.obj|file.snap> [-max n] [-snapshot file.snap] [-gdb address] [-cycles file.json] [-hotspots n] [-sort order] [-lcov file] [-annotate file] [-dialect name] [-auto-extend] [-sic]")
+		flagSet.PrintDefaults()
+	}
+	maxInstructions := flagSet.Uint64("max", 0, "stop after `n` instructions (default: run until the program halts)")
+	snapshotFileName := flagSet.String("snapshot", "", "save the machine to snapshot `file` when the run stops")
+	gdbAddress := flagSet.String("gdb", "", "wait for a GDB client on loopback `address` such as :1234 and let it control the run")
+	cyclesFileName := flagSet.String("cycles", "", "JSON `file` with cycle costs, replacing the defaults it lists")
+	hotspots := flagSet.Int("hotspots", -1, "write `n` hottest instructions and all subroutines, 0 writes all instructions")
+	hotspotOrder := flagSet.String("sort", core.HotspotsByCycles.String(), "hotspot `order`: cycles, executions or address")
+	lcovFileName := flagSet.String("lcov", "", "write lcov coverage of the assembly source to `file`")
+	annotateFileName := flagSet.String("annotate", "", "write the assembly source annotated with coverage to `file`")
+	assemblerFlags := addAssemblerFlags(flagSet)
+
+	fileNames, err := parseArgs(flagSet, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(fileNames) != 1 {
+		flagSet.Usage()
+		return ExitUsage
+	}
+	fileName := fileNames[0]
+
+	if err := assemblerFlags.apply(); err != nil {
+		return errorf("%v", err)
+	}
+	order, err := core.ParseHotspotOrder(*hotspotOrder)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if *cyclesFileName != "" {
+		file, err := os.Open(*cyclesFileName)
+		if err != nil {
+			return errorf("%v", err)
+		}
+		cycleCosts, err := proc.LoadCycleCosts(file)
+		file.Close()
+		if err != nil {
+			return errorf("%s: %v", *cyclesFileName, err)
+		}
+		core.SetCycleCosts(cycleCosts)
+	}
+
+	// Bookkeeping which isn't written slows the run down
+	core.CoverageEnabled = *lcovFileName != "" || *annotateFileName != ""
+	core.ProfilerEnabled = *hotspots >= 0 || core.CoverageEnabled
+
+	if _, err := core.LoadProgram(fileName); err != nil {
+		return errorf("%v", err)
+	}
+	if core.LoadedProgramTypeState == loader.Assembly {
+		assemblyErrors := assembly.GetErrors(loader.SyntaxNodes)
+		printAssemblyErrors(fileName, assemblyErrors)
+		if len(assemblyErrors) > 0 {
+			return ExitFailure
+		}
+	} else if *lcovFileName != "" || *annotateFileName != "" {
+		return errorf("%s: coverage needs an assembly source", fileName)
+	}
+
+	// Program output goes to standard output, counters to standard error
+	if *gdbAddress != "" {
+		if err := serveGdb(*gdbAddress); err != nil {
+			return errorf("%v", err)
+		}
+	} else if halted := core.RunProgram(*maxInstructions); !halted {
+		fmt.Fprintf(os.Stderr, "sicsimgo: stopped after %d instructions\n", *maxInstructions)
+	}
+	if core.SimFault != nil {
+		fmt.Fprintf(os.Stderr, "sicsimgo: %v\n", core.SimFault)
+	}
+	for _, warning := range core.GetCallStackWarnings() {
+		fmt.Fprintf(os.Stderr, "sicsimgo: warning: %s\n", warning.Message)
+	}
+	core.WriteCounters(os.Stderr)
+	if *snapshotFileName != "" {
+		if err := core.SaveSnapshotFile(*snapshotFileName); err != nil {
+			return errorf("%v", err)
+		}
+	}
+	if *hotspots >= 0 {
+		fmt.Fprintln(os.Stderr)
+		core.WriteHotspots(os.Stderr, order, *hotspots)
+	}
+	if *lcovFileName != "" {
+		if err := writeFile(*lcovFileName, core.WriteLcovFile); err != nil {
+			return errorf("%v", err)
+		}
+	}
+	if *annotateFileName != "" {
+		if err := writeFile(*annotateFileName, core.WriteCoverageListing); err != nil {
+			return errorf("%v", err)
+		}
+	}
+
+	if core.SimFault != nil {
+		return ExitFailure
+	}
+	return ExitSuccess
+}
+
+func serveGdb(address string) error {
+	listener, err := listenLoopback(address)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "sicsimgo: waiting for GDB on %s\n", listener.Addr())
+	return gdb.Serve(listener)
+}
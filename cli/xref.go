@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+)
+
+func Xref(args []string) int {
+	flagSet := flag.NewFlagSet("xref", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: sicsimgo xref <file.asm> [-dialect name] [-auto-extend] [-sic]")
+		flagSet.PrintDefaults()
+	}
+	assemblerFlags := addAssemblerFlags(flagSet)
+
+	fileNames, err := parseArgs(flagSet, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(fileNames) != 1 {
+		flagSet.Usage()
+		return ExitUsage
+	}
+	sourceFileName := fileNames[0]
+
+	if err := assemblerFlags.apply(); err != nil {
+		return errorf("%v", err)
+	}
+
+	_, _, loadedProgramType, err := loader.LoadProgramFile(sourceFileName)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if loadedProgramType != loader.Assembly {
+		return errorf("%s: not an assembly file", sourceFileName)
+	}
+
+	// Report is still useful for programs with errors
+	loader.WriteSymbolTable(os.Stdout)
+
+	assemblyErrors := assembly.GetErrors(loader.SyntaxNodes)
+	printAssemblyErrors(sourceFileName, assemblyErrors)
+	if len(assemblyErrors) > 0 {
+		return ExitFailure
+	}
+
+	return ExitSuccess
+}
@@ -9,3 +9,7 @@ func ErrInvalidRegister(registerId RegisterId) error {
 	log.Fatalf("Invalid register id: %d", registerId)
 	return fmt.Errorf("Invalid register id: %d", registerId)
 }
+
+func ErrInvalidMemorySize(dataSize int, keysSize int) error {
+	return fmt.Errorf("Invalid memory size: %d bytes, %d storage keys", dataSize, keysSize)
+}
@@ -3,6 +3,7 @@ package base
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -11,6 +12,30 @@ DEFINITIONS
 */
 type Device byte
 
+// Bytes read from a device file and cycles the device stays busy for, restored with snapshots
+type DeviceState struct {
+	Cursor int64
+	Busy   int
+}
+
+/*
+IMPLEMENTATION
+*/
+var deviceReaders map[Device]*bufio.Reader = make(map[Device]*bufio.Reader)
+var deviceFiles []*os.File
+
+// Device files are read from the cursor on when they are opened
+var deviceCursors map[Device]int64 = make(map[Device]int64)
+
+// Standard devices, replaced when the standard streams are used otherwise
+var DeviceInput io.Reader = os.Stdin
+var DeviceOutput io.Writer = os.Stdout
+var DeviceErrorOutput io.Writer = os.Stderr
+
+// Cycles a device stays busy after a read or write
+var DeviceBusyCycles int
+var deviceBusy map[Device]int = make(map[Device]int)
+
 /*
 DEBUG
 */
@@ -20,53 +45,81 @@ const debugWrite bool = false
 /*
 OPERATIONS
 */
-func Test() bool {
-	return true
+// Device is ready once the cycles it was busy for have passed
+func Test(device Device) bool {
+	return deviceBusy[device] <= 0
 }
 
-func Read(device Device) (byte, error) {
-	switch device {
-	case Device(0x00):
-		// Stdin
-		if debugRead {
-			fmt.Println("Reading from stdin")
-		}
-		reader := bufio.NewReader(os.Stdin)
-		return readByte(reader)
-	case Device(0x01):
-		// Stdout
-		if debugRead {
-			fmt.Println("Reading from stdout")
-		}
-		reader := bufio.NewReader(os.Stdout)
-		return readByte(reader)
-	case Device(0x02):
-		// Stderr
-		if debugRead {
-			fmt.Println("Reading from stderr")
-		}
-		reader := bufio.NewReader(os.Stderr)
-		return readByte(reader)
-	default:
-		// XX.dev file
-		filename := fmt.Sprintf("%02X.dev", device)
-		if debugRead {
-			fmt.Println("Reading from file ", filename)
-		}
-		file, err := os.Open(filename)
-		if err != nil {
-			return 0x00, err
+func IsAnyDeviceBusy() bool {
+	return len(deviceBusy) > 0
+}
+
+func TickDevices(cycles int) {
+	if len(deviceBusy) == 0 {
+		return
+	}
+	for device := range deviceBusy {
+		deviceBusy[device] -= cycles
+		if deviceBusy[device] <= 0 {
+			delete(deviceBusy, device)
 		}
-		defer file.Close()
+	}
+}
 
-		reader := bufio.NewReader(file)
-		return readByte(reader)
+// Devices keep their reader between reads, so each read continues where the last one stopped
+func Read(device Device) (byte, error) {
+	if DeviceBusyCycles > 0 {
+		deviceBusy[device] = DeviceBusyCycles
+	}
+	reader, exists := deviceReaders[device]
+	if !exists {
+		switch device {
+		case Device(0x00):
+			// Stdin
+			if debugRead {
+				fmt.Println("Reading from stdin")
+			}
+			reader = bufio.NewReader(DeviceInput)
+		case Device(0x01):
+			// Stdout
+			if debugRead {
+				fmt.Println("Reading from stdout")
+			}
+			reader = bufio.NewReader(os.Stdout)
+		case Device(0x02):
+			// Stderr
+			if debugRead {
+				fmt.Println("Reading from stderr")
+			}
+			reader = bufio.NewReader(os.Stderr)
+		default:
+			// XX.dev file
+			filename := fmt.Sprintf("%02X.dev", device)
+			if debugRead {
+				fmt.Println("Reading from file ", filename)
+			}
+			file, err := os.Open(filename)
+			if err != nil {
+				return 0x00, err
+			}
+			deviceFiles = append(deviceFiles, file)
+			reader = bufio.NewReader(file)
+			if _, err := reader.Discard(int(deviceCursors[device])); err != nil {
+				return 0x00, err
+			}
+		}
+		deviceReaders[device] = reader
 	}
+	data, err := readByte(reader)
+	if err == nil && device > Device(0x02) {
+		deviceCursors[device]++
+	}
+	return data, err
 }
 func readByte(reader *bufio.Reader) (byte, error) {
 	readByte, err := reader.ReadByte()
 	if err != nil {
-		panic("error reading byte")
+		return 0x00, err
 	}
 	if debugRead {
 		fmt.Println("  Read byte:", readByte)
@@ -75,6 +128,9 @@ func readByte(reader *bufio.Reader) (byte, error) {
 }
 
 func Write(device Device, data byte) error {
+	if DeviceBusyCycles > 0 {
+		deviceBusy[device] = DeviceBusyCycles
+	}
 
 	if debugWrite {
 		fmt.Println("Writing to device", device, "data:", data)
@@ -83,15 +139,15 @@ func Write(device Device, data byte) error {
 	switch device {
 	case Device(0x0):
 		// Stdout
-		_, err := os.Stdout.Write([]byte{data})
+		_, err := DeviceOutput.Write([]byte{data})
 		return err
 	case Device(0x1):
 		// Stdout
-		_, err := os.Stdout.Write([]byte{data})
+		_, err := DeviceOutput.Write([]byte{data})
 		return err
 	case Device(0x2):
 		// Stderr
-		_, err := os.Stderr.Write([]byte{data})
+		_, err := DeviceErrorOutput.Write([]byte{data})
 		return err
 	}
 	// XX.dev file
@@ -109,3 +165,39 @@ func Write(device Device, data byte) error {
 
 	return nil
 }
+
+// Closes device files, the next read starts from the beginning
+func ResetDevices() {
+	for _, file := range deviceFiles {
+		file.Close()
+	}
+	deviceFiles = nil
+	deviceReaders = make(map[Device]*bufio.Reader)
+	deviceCursors = make(map[Device]int64)
+	deviceBusy = make(map[Device]int)
+}
+
+// Devices which were read from or are busy
+func GetDeviceStates() map[Device]DeviceState {
+	states := make(map[Device]DeviceState)
+	for device, cursor := range deviceCursors {
+		states[device] = DeviceState{Cursor: cursor, Busy: deviceBusy[device]}
+	}
+	for device, busy := range deviceBusy {
+		states[device] = DeviceState{Cursor: deviceCursors[device], Busy: busy}
+	}
+	return states
+}
+
+// Resets devices, files are opened again at their cursors by the next read
+func RestoreDevices(states map[Device]DeviceState) {
+	ResetDevices()
+	for device, state := range states {
+		if state.Cursor > 0 {
+			deviceCursors[device] = state.Cursor
+		}
+		if state.Busy > 0 {
+			deviceBusy[device] = state.Busy
+		}
+	}
+}
@@ -10,11 +10,14 @@ DEFINITIONS
 */
 type Memory struct {
 	Data []byte
+	Keys []uint8 // Storage key of each block
 }
 
 const (
 	MEMORY_SIZE uint32 = 0x100000
 	MAX_ADDRESS uint32 = 0xFFFFF
+
+	STORAGE_BLOCK_SIZE uint32 = 0x800
 )
 
 /*
@@ -22,6 +25,7 @@ IMPLEMENTATION
 */
 var memory Memory = Memory{
 	Data: make([]byte, MEMORY_SIZE),
+	Keys: make([]uint8, MEMORY_SIZE/STORAGE_BLOCK_SIZE),
 }
 
 /*
@@ -33,11 +37,7 @@ func ToAddress(val uint32) units.Int24 {
 		panic("Address out of range")
 	}
 
-	return units.Int24{
-		byte((val >> 16) & 0xFF),
-		byte((val >> 8) & 0xFF),
-		byte(val & 0xFF),
-	}
+	return units.Uint32ToInt24(val)
 }
 
 func toAddress(val units.Int24) uint32 {
@@ -48,6 +48,9 @@ func toAddress(val units.Int24) uint32 {
 OPERATIONS
 */
 func GetByte(addressBytes units.Int24) byte {
+	return memory.GetByte(addressBytes)
+}
+func (memory Memory) GetByte(addressBytes units.Int24) byte {
 	address := toAddress(addressBytes)
 
 	return memory.Data[address]
@@ -58,22 +61,25 @@ func SetByte(addressBytes units.Int24, value byte) {
 }
 
 func GetWord(addressBytes units.Int24) units.Int24 {
+	return memory.GetWord(addressBytes)
+}
+func (memory Memory) GetWord(addressBytes units.Int24) units.Int24 {
 	address := toAddress(addressBytes)
 
-	return units.Int24{
-		memory.Data[address],
-		memory.Data[address+1],
-		memory.Data[address+2],
-	}
+	return units.BytesToInt24(memory.Data[address], memory.Data[address+1], memory.Data[address+2])
 }
 func SetWord(addressBytes units.Int24, value units.Int24) {
 	address := toAddress(addressBytes)
-	memory.Data[address] = value[0]
-	memory.Data[address+1] = value[1]
-	memory.Data[address+2] = value[2]
+	bytes := value.Bytes()
+	memory.Data[address] = bytes[0]
+	memory.Data[address+1] = bytes[1]
+	memory.Data[address+2] = bytes[2]
 }
 
 func GetFloat(addressBytes units.Int24) units.Float48 {
+	return memory.GetFloat(addressBytes)
+}
+func (memory Memory) GetFloat(addressBytes units.Int24) units.Float48 {
 	address := toAddress(addressBytes)
 
 	float := units.Float48{}
@@ -107,6 +113,9 @@ func GetSlice(startAddress units.Int24, endAddress units.Int24) []byte {
 }
 
 func GetSlice16(startAddress units.Int24) []byte {
+	return memory.GetSlice16(startAddress)
+}
+func (memory Memory) GetSlice16(startAddress units.Int24) []byte {
 	start := toAddress(startAddress)
 
 	return []byte{
@@ -129,8 +138,60 @@ func GetSlice16(startAddress units.Int24) []byte {
 	}
 }
 
+// Storage key of the block containing address
+func GetStorageKey(addressBytes units.Int24) uint8 {
+	return memory.GetStorageKey(addressBytes)
+}
+func (memory Memory) GetStorageKey(addressBytes units.Int24) uint8 {
+	address := toAddress(addressBytes)
+	return memory.Keys[address/STORAGE_BLOCK_SIZE]
+}
+func SetStorageKey(addressBytes units.Int24, key uint8) {
+	address := toAddress(addressBytes)
+	memory.Keys[address/STORAGE_BLOCK_SIZE] = key & 0x0F
+}
+
+// User mode may only write to blocks whose key matches the process ID in SW
+func IsWriteAllowed(addressBytes units.Int24, length int) bool {
+	if IsSupervisorMode() {
+		return true
+	}
+	address := toAddress(addressBytes)
+	for i := uint32(0); i < uint32(length) && address+i <= MAX_ADDRESS; i++ {
+		if memory.Keys[(address+i)/STORAGE_BLOCK_SIZE] != GetProcessId() {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy of memory and storage keys, not changed by later execution
+func CopyMemory() Memory {
+	return Memory{
+		Data: append([]byte{}, memory.Data...),
+		Keys: append([]uint8{}, memory.Keys...),
+	}
+}
+
+// Memory restored from elsewhere must have the size of memory and its storage keys
+func (memory Memory) Validate() error {
+	if len(memory.Data) != int(MEMORY_SIZE) || len(memory.Keys) != int(MEMORY_SIZE/STORAGE_BLOCK_SIZE) {
+		return ErrInvalidMemorySize(len(memory.Data), len(memory.Keys))
+	}
+	return nil
+}
+
+// Replaces memory and storage keys with a copy of validated memory
+func RestoreMemory(restored Memory) {
+	memory = Memory{
+		Data: append([]byte{}, restored.Data...),
+		Keys: append([]uint8{}, restored.Keys...),
+	}
+}
+
 func ResetMemory() {
 	memory.Data = make([]byte, MEMORY_SIZE)
+	memory.Keys = make([]uint8, MEMORY_SIZE/STORAGE_BLOCK_SIZE)
 }
 
 /*
@@ -17,7 +17,8 @@ type Registers struct {
 	T  units.Int24
 	F  units.Float48
 	PC units.Int24
-	SW units.Int24 // 0x0, 0x40 in 0x80 represent "less than", "equal to" and "greater than" respectively
+	SW units.Int24 // Status word, fields are accessed in status.go
+	I  units.Int24 // Interval timer
 }
 
 const (
@@ -45,11 +46,19 @@ var registers Registers = Registers{
 	F:  units.Float48{},
 	PC: units.Int24{},
 	SW: units.Int24{},
+	I:  units.Int24{},
 }
 
 /*
 OPERATIONS
 */
+func GetRegisters() Registers {
+	return registers
+}
+func SetRegisters(values Registers) {
+	registers = values
+}
+
 func GetRegisterA() units.Int24 {
 	return registers.A
 }
@@ -113,6 +122,13 @@ func SetRegisterSW(value units.Int24) {
 	registers.SW = value
 }
 
+func GetRegisterI() units.Int24 {
+	return registers.I
+}
+func SetRegisterI(value units.Int24) {
+	registers.I = value
+}
+
 func (registerId RegisterId) GetRegister() (units.Int24, error) {
 	switch registerId {
 	case RegisterAId:
@@ -128,7 +144,7 @@ func (registerId RegisterId) GetRegister() (units.Int24, error) {
 	case RegisterTId:
 		return registers.T, nil
 	case RegisterFId:
-		return units.Int24{registers.F[0], registers.F[1], registers.F[2]}, nil
+		return units.BytesToInt24(registers.F[0], registers.F[1], registers.F[2]), nil
 	case RegisterPCId:
 		return registers.PC, nil
 	case RegisterSWId:
@@ -152,7 +168,8 @@ func (registerId RegisterId) SetRegister(value units.Int24) error {
 	case RegisterTId:
 		registers.T = value
 	case RegisterFId:
-		registers.F = units.Float48{value[0], value[1], value[2], 0x00, 0x00, 0x00}
+		bytes := value.Bytes()
+		registers.F = units.Float48{bytes[0], bytes[1], bytes[2], 0x00, 0x00, 0x00}
 	case RegisterPCId:
 		registers.PC = value
 	case RegisterSWId:
@@ -173,7 +190,10 @@ func ResetRegisters() {
 	registers.T = resetValue
 	registers.F = units.Float48{}
 	registers.PC = resetValue
-	registers.SW = resetValue
+	// Programs start in supervisor mode, with interrupts masked
+	registers.SW = units.Int24{}
+	SetSupervisorMode(true)
+	registers.I = resetValue
 }
 
 /*
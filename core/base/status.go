@@ -0,0 +1,114 @@
+package base
+
+import (
+	"fmt"
+	"math/bits"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type ConditionCode uint8
+
+// Status word fields, bit 0 being the most significant:
+// MODE (0), IDLE (1), ID (2-5), CC (6-7), MASK (8-11), ICODE (16-23)
+const (
+	SW_MODE  uint32 = 0x800000
+	SW_IDLE  uint32 = 0x400000
+	SW_ID    uint32 = 0x3C0000
+	SW_CC    uint32 = 0x030000
+	SW_MASK  uint32 = 0x00F000
+	SW_ICODE uint32 = 0x0000FF
+)
+
+const (
+	ConditionLess    ConditionCode = 0b00
+	ConditionEqual   ConditionCode = 0b01
+	ConditionGreater ConditionCode = 0b10
+)
+
+/*
+OPERATIONS
+*/
+func getStatusField(field uint32) uint32 {
+	return statusField(registers.SW, field)
+}
+func statusField(sw units.Int24, field uint32) uint32 {
+	return sw.ToUint32() & field >> bits.TrailingZeros32(field)
+}
+func setStatusField(field uint32, value uint32) {
+	value <<= bits.TrailingZeros32(field)
+	registers.SW = units.Uint32ToInt24(registers.SW.ToUint32()&^field | value&field)
+}
+
+// Supervisor mode, user mode otherwise
+func IsSupervisorMode() bool {
+	return getStatusField(SW_MODE) == 1
+}
+func SetSupervisorMode(supervisor bool) {
+	if supervisor {
+		setStatusField(SW_MODE, 1)
+	} else {
+		setStatusField(SW_MODE, 0)
+	}
+}
+
+// Idle processor doesn't execute instructions until an interrupt is taken
+func IsIdle() bool {
+	return getStatusField(SW_IDLE) == 1
+}
+
+func GetProcessId() uint8 {
+	return uint8(getStatusField(SW_ID))
+}
+
+func GetConditionCode() ConditionCode {
+	return ConditionCode(getStatusField(SW_CC))
+}
+func SetConditionCode(conditionCode ConditionCode) {
+	setStatusField(SW_CC, uint32(conditionCode))
+}
+
+// Bit 3 of the mask is set when interrupts of class I are allowed, bit 0 for class IV
+func GetInterruptMask() uint8 {
+	return uint8(getStatusField(SW_MASK))
+}
+
+func GetInterruptCode() byte {
+	return byte(getStatusField(SW_ICODE))
+}
+func SetInterruptCode(interruptCode byte) {
+	setStatusField(SW_ICODE, uint32(interruptCode))
+}
+
+/*
+STRINGS
+*/
+func (conditionCode ConditionCode) String() string {
+	switch conditionCode {
+	case ConditionLess:
+		return "<"
+	case ConditionEqual:
+		return "="
+	case ConditionGreater:
+		return ">"
+	}
+	return "Not implemented"
+}
+
+// Status word fields as shown to the user
+func StatusString() string {
+	return StringStatus(registers.SW)
+}
+func StringStatus(sw units.Int24) string {
+	mode := "U"
+	if statusField(sw, SW_MODE) == 1 {
+		mode = "S"
+	}
+	state := "R"
+	if statusField(sw, SW_IDLE) == 1 {
+		state = "I"
+	}
+	return fmt.Sprintf("%s %s ID=%X CC%s MASK=%04b ICODE=%02X", mode, state, statusField(sw, SW_ID), ConditionCode(statusField(sw, SW_CC)).String(), statusField(sw, SW_MASK), statusField(sw, SW_ICODE))
+}
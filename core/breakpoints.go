@@ -0,0 +1,56 @@
+package core
+
+import (
+	"sort"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+IMPLEMENTATION
+*/
+// Runs stop before executing an instruction at a breakpoint
+var breakpoints map[units.Int24]bool = make(map[units.Int24]bool)
+
+/*
+OPERATIONS
+*/
+func SetBreakpoint(address units.Int24) {
+	breakpoints[address] = true
+}
+
+func ClearBreakpoint(address units.Int24) {
+	delete(breakpoints, address)
+}
+
+func ToggleBreakpoint(address units.Int24) {
+	if IsBreakpoint(address) {
+		ClearBreakpoint(address)
+	} else {
+		SetBreakpoint(address)
+	}
+}
+
+func IsBreakpoint(address units.Int24) bool {
+	return len(breakpoints) > 0 && breakpoints[address]
+}
+
+// Breakpoints sorted by address
+func GetBreakpoints() []units.Int24 {
+	addresses := make(units.Int24Slice, 0, len(breakpoints))
+	for address := range breakpoints {
+		addresses = append(addresses, address)
+	}
+	sort.Sort(addresses)
+	return addresses
+}
+
+// Checked after each executed instruction of a run
+func isAtBreakpoint() bool {
+	return IsBreakpoint(base.GetRegisterPC())
+}
+
+func ResetBreakpoints() {
+	breakpoints = make(map[units.Int24]bool)
+}
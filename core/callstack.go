@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Subroutine call made by JSUB, L holds the return address until the subroutine saves it
+type StackFrame struct {
+	Subroutine     units.Int24
+	SubroutineName string
+	CallAddress    units.Int24
+	CallName       string
+	ReturnAddress  units.Int24
+	LinkSaved      bool
+}
+
+type CallStackWarningType int
+
+const (
+	LinkOverwritten CallStackWarningType = iota
+	ReturnMismatch
+)
+
+type CallStackWarning struct {
+	Type    CallStackWarningType
+	Address units.Int24
+	Message string
+}
+
+/*
+IMPLEMENTATION
+*/
+// Shadow call stack of the running program
+var CallStackEnabled bool = true
+var callStack []StackFrame
+var callStackWarnings []CallStackWarning
+
+/*
+OPERATIONS
+*/
+// Called after the instruction was executed, JSUB has already set PC and L
+func trackCallStack(instruction *proc.Instruction) {
+	switch {
+	case instruction.Opcode == proc.JSUB:
+		if len(callStack) > 0 && !callStack[len(callStack)-1].LinkSaved {
+			caller := callStack[len(callStack)-1]
+			addCallStackWarning(LinkOverwritten, instruction.InstructionAddress, fmt.Sprintf("JSUB at %s overwrites L of %s without saving it",
+				base.StringAddress(instruction.InstructionAddress), caller.SubroutineName))
+		}
+		callStack = append(callStack, StackFrame{
+			Subroutine:     base.GetRegisterPC(),
+			SubroutineName: loader.StringAddressName(base.GetRegisterPC()),
+			CallAddress:    instruction.InstructionAddress,
+			CallName:       loader.StringAddressName(instruction.InstructionAddress),
+			ReturnAddress:  base.GetRegisterL(),
+		})
+
+	case instruction.Opcode == proc.RSUB:
+		// Returning from the main program isn't tracked
+		if len(callStack) == 0 {
+			return
+		}
+		returnAddress := base.GetRegisterPC()
+		frame := callStack[len(callStack)-1]
+		callStack = callStack[:len(callStack)-1]
+		if returnAddress.Compare(frame.ReturnAddress) == 0 {
+			return
+		}
+		addCallStackWarning(ReturnMismatch, instruction.InstructionAddress, fmt.Sprintf("RSUB at %s of %s returns to %s instead of %s",
+			base.StringAddress(instruction.InstructionAddress), frame.SubroutineName, base.StringAddress(returnAddress), base.StringAddress(frame.ReturnAddress)))
+		// Returned past the frame, to one of its callers
+		for index := len(callStack) - 1; index >= 0; index-- {
+			if callStack[index].ReturnAddress.Compare(returnAddress) == 0 {
+				callStack = callStack[:index]
+				break
+			}
+		}
+
+	case instruction.Opcode == proc.STL, instruction.Opcode == proc.RMO && instruction.R1 == base.RegisterLId:
+		if len(callStack) > 0 {
+			callStack[len(callStack)-1].LinkSaved = true
+		}
+	}
+}
+
+// Warnings are reported once per instruction
+func addCallStackWarning(warningType CallStackWarningType, address units.Int24, message string) {
+	for _, warning := range callStackWarnings {
+		if warning.Type == warningType && warning.Address.Compare(address) == 0 {
+			return
+		}
+	}
+	callStackWarnings = append(callStackWarnings, CallStackWarning{Type: warningType, Address: address, Message: message})
+}
+
+// Frames from the outermost call to the innermost one
+func GetCallStack() []StackFrame {
+	return append([]StackFrame{}, callStack...)
+}
+
+func GetCallStackWarnings() []CallStackWarning {
+	return append([]CallStackWarning{}, callStackWarnings...)
+}
+
+// Subroutine the processor is in, the program start outside of subroutines
+func getCurrentSubroutine() units.Int24 {
+	if len(callStack) == 0 {
+		return loader.StartPC
+	}
+	return callStack[len(callStack)-1].Subroutine
+}
+
+func ResetCallStack() {
+	callStack = nil
+	callStackWarnings = nil
+}
+
+/*
+STRINGS
+*/
+func (warningType CallStackWarningType) String() string {
+	switch warningType {
+	case LinkOverwritten:
+		return "L overwritten"
+	case ReturnMismatch:
+		return "Return mismatch"
+	}
+	return "Not implemented"
+}
+
+func (frame StackFrame) String() string {
+	return fmt.Sprintf("%s called from %s", frame.SubroutineName, frame.CallName)
+}
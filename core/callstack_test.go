@@ -0,0 +1,73 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestTrackCallStack(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		warnings []CallStackWarningType
+		depth    int
+	}{
+		{
+			name: "Nested call with saved L",
+			source: `prog  START 0
+      JSUB  outer
+halt  J     halt
+outer STL   link
+      JSUB  inner
+      LDL   link
+      RSUB
+inner RSUB
+link  RESW  1
+      END   prog
+`,
+		},
+		{
+			name: "Nested call without saved L",
+			source: `prog  START 0
+      JSUB  outer
+halt  J     halt
+outer JSUB  inner
+      RSUB
+inner RSUB
+      END   prog
+`,
+			warnings: []CallStackWarningType{LinkOverwritten, ReturnMismatch},
+		},
+		{
+			name: "Stopped in a subroutine",
+			source: `prog  START 0
+      JSUB  outer
+      J     prog
+outer JSUB  inner
+inner J     inner
+      END   prog
+`,
+			warnings: []CallStackWarningType{LinkOverwritten},
+			depth:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestProgram(t, tt.source)
+			RunProgram(100)
+
+			warnings := GetCallStackWarnings()
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("GetCallStackWarnings() = %v, want %v", warnings, tt.warnings)
+			}
+			for i, warning := range warnings {
+				if warning.Type != tt.warnings[i] {
+					t.Errorf("warning %d = %s, want %s", i, warning.Type, tt.warnings[i])
+				}
+			}
+			if depth := len(GetCallStack()); depth != tt.depth {
+				t.Errorf("len(GetCallStack()) = %d, want %d", depth, tt.depth)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type CommandType int
+
+const (
+	CommandStep CommandType = iota
+	CommandStepOver
+	CommandStepOut
+	CommandRunToAddress
+	CommandRun
+	CommandStop
+	CommandReset
+	CommandLoad
+	CommandCall
+	CommandQuit
+)
+
+// Address is used by run to address, file name, options and loaded by load.
+// Call runs the function on the controller, between two instructions.
+// Stopped is called on the controller when the step or run started by the command ends,
+// commands starting a run while running are ignored and never stop.
+type Command struct {
+	Type     CommandType
+	Address  units.Int24
+	FileName string
+	Options  assembly.Options
+	Loaded   func(programName string, err error)
+	Stopped  func()
+	Function func()
+}
+
+// Consistent copy of the machine, published to the UI. Views are never changed once published.
+type View struct {
+	ExecuteState      ExecuteState
+	LoadedProgramType loader.LoadedProgramType
+	ProcState         ProcState
+	Registers         base.Registers
+	Memory            base.Memory
+	Counters          Counters
+
+	// The loader replaces these instead of changing them, so they are shared between views
+	InstructionList []proc.Instruction
+	Labels          map[units.Int24]string
+	SymbolTableList []assembly.Symbol
+	CrossReferences []assembly.CrossReference
+
+	SymbolicOperands         map[units.Int24]string
+	ModifiedCode             map[units.Int24]units.Int24
+	Breakpoints              map[units.Int24]bool
+	InstructionProfiles      map[units.Int24]Profile
+	MaxInstructionExecutions uint64
+	InstructionHotspots      []Hotspot
+	SubroutineHotspots       []Hotspot
+	CallStack                []StackFrame
+	CallStackWarnings        []CallStackWarning
+	Fault                    error
+}
+
+// Owns the machine, only the controller's goroutine executes instructions and changes state
+type Controller struct {
+	commands    chan Command
+	done        chan struct{}
+	view        atomic.Pointer[View]
+	published   func()
+	lastPublish time.Time
+
+	// Condition ending the current run, nil runs until the program halts, is stopped or reaches a breakpoint
+	stop    func() bool
+	stopped func()
+}
+
+const (
+	VIEW_INTERVAL      time.Duration = time.Second / 60
+	COMMAND_QUEUE_SIZE int           = 16
+)
+
+/*
+OPERATIONS
+*/
+// Starts the controller, published is called from its goroutine after each new view
+func NewController(published func()) *Controller {
+	controller := &Controller{
+		commands:  make(chan Command, COMMAND_QUEUE_SIZE),
+		done:      make(chan struct{}),
+		published: published,
+	}
+	controller.publish()
+	go controller.loop()
+	return controller
+}
+
+func (controller *Controller) Send(command Command) {
+	controller.commands <- command
+}
+
+// Stops the machine and waits for the controller's goroutine to end
+func (controller *Controller) Quit() {
+	controller.Send(Command{Type: CommandQuit})
+	<-controller.done
+}
+
+// Latest published view
+func (controller *Controller) View() *View {
+	return controller.view.Load()
+}
+
+func (controller *Controller) loop() {
+	defer close(controller.done)
+	for {
+		if SimExecuteState == ExecuteStopState {
+			command := <-controller.commands
+			if command.Type == CommandQuit {
+				return
+			}
+			controller.handle(command)
+		} else {
+			select {
+			case command := <-controller.commands:
+				if command.Type == CommandQuit {
+					StopSim()
+					return
+				}
+				controller.handle(command)
+			default:
+				controller.run()
+			}
+		}
+
+		if SimExecuteState == ExecuteStopState && controller.stopped != nil {
+			stopped := controller.stopped
+			controller.stopped = nil
+			stopped()
+		}
+
+		// Running machine is published at most once per interval
+		if SimExecuteState == ExecuteStopState || time.Since(controller.lastPublish) >= VIEW_INTERVAL {
+			controller.publish()
+		}
+	}
+}
+
+func (controller *Controller) handle(command Command) {
+	switch command.Type {
+	case CommandStep:
+		if SimExecuteState == ExecuteStopState {
+			ExecuteNextInstruction()
+			controller.stopped = command.Stopped
+		}
+	case CommandStepOver:
+		controller.start(StepOverCondition(), command.Stopped)
+	case CommandStepOut:
+		controller.start(StepOutCondition(), command.Stopped)
+	case CommandRunToAddress:
+		controller.start(RunToAddressCondition(command.Address), command.Stopped)
+	case CommandRun:
+		controller.start(nil, command.Stopped)
+	case CommandStop:
+		StopSim()
+	case CommandReset:
+		ResetSim()
+	case CommandLoad:
+		loader.AssemblerOptions = command.Options
+		programName, err := LoadProgram(command.FileName)
+		if command.Loaded != nil {
+			command.Loaded(programName, err)
+		}
+	case CommandCall:
+		command.Function()
+	}
+}
+
+// Commands starting a run are ignored while running
+func (controller *Controller) start(stop func() bool, stopped func()) {
+	if SimExecuteState == ExecuteStartState {
+		return
+	}
+	controller.stop = stop
+	controller.stopped = stopped
+	SimExecuteState = ExecuteStartState
+}
+
+// Executes instructions until the next view is due or the run ends
+func (controller *Controller) run() {
+	for SimExecuteState == ExecuteStartState && time.Since(controller.lastPublish) < VIEW_INTERVAL {
+		for i := 0; i < 256 && SimExecuteState == ExecuteStartState; i++ {
+			ExecuteNextInstruction()
+			if controller.stop != nil && controller.stop() || isAtBreakpoint() {
+				StopSim()
+			}
+		}
+	}
+}
+
+func (controller *Controller) publish() {
+	controller.view.Store(takeView(controller.view.Load()))
+	controller.lastPublish = time.Now()
+	if controller.published != nil {
+		controller.published()
+	}
+}
+
+// Parts derived from the instruction list are reused while the list is unchanged
+func takeView(previous *View) *View {
+	// Proc state isn't updated while instructions are executed
+	UpdateProcState(base.GetRegisterPC())
+
+	view := &View{
+		ExecuteState:      SimExecuteState,
+		LoadedProgramType: LoadedProgramTypeState,
+		ProcState:         CurrentProcState,
+		Registers:         base.GetRegisters(),
+		Memory:            base.CopyMemory(),
+		Counters:          PerformanceCounters,
+
+		InstructionList: loader.InstructionList,
+		Labels:          loader.Labels,
+		SymbolTableList: loader.SymbolTableList,
+		CrossReferences: loader.CrossReferences,
+
+		ModifiedCode:             make(map[units.Int24]units.Int24, len(loader.ModifiedCode)),
+		Breakpoints:              make(map[units.Int24]bool, len(breakpoints)),
+		InstructionProfiles:      make(map[units.Int24]Profile, len(instructionRecords)),
+		MaxInstructionExecutions: maxInstructionExecutions,
+		InstructionHotspots:      GetInstructionHotspots(HotspotsByAddress),
+		SubroutineHotspots:       GetSubroutineHotspots(HotspotsByAddress),
+		CallStack:                GetCallStack(),
+		CallStackWarnings:        GetCallStackWarnings(),
+		Fault:                    SimFault,
+	}
+	for address, storeAddress := range loader.ModifiedCode {
+		view.ModifiedCode[address] = storeAddress
+	}
+	for address := range breakpoints {
+		view.Breakpoints[address] = true
+	}
+	for _, record := range instructionRecords {
+		view.InstructionProfiles[record.Address] = record.Profile
+	}
+
+	if previous != nil && isSameInstructionList(previous.InstructionList, view.InstructionList) {
+		view.SymbolicOperands = previous.SymbolicOperands
+	} else {
+		view.SymbolicOperands = make(map[units.Int24]string)
+		for _, instruction := range view.InstructionList {
+			if operand, symbolic := loader.GetSymbolicOperand(instruction); symbolic {
+				view.SymbolicOperands[instruction.InstructionAddress] = operand
+			}
+		}
+	}
+
+	return view
+}
+
+func isSameInstructionList(a []proc.Instruction, b []proc.Instruction) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Executions of the instruction relative to the most executed one
+func (view *View) GetInstructionHeat(address units.Int24) float32 {
+	if view.MaxInstructionExecutions == 0 {
+		return 0
+	}
+	return float32(view.InstructionProfiles[address].Executions) / float32(view.MaxInstructionExecutions)
+}
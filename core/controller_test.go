@@ -0,0 +1,93 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sicsimgo/core/loader"
+	"sicsimgo/core/units"
+)
+
+func TestController(t *testing.T) {
+	source := `prog  START 0
+      LDX   #0
+loop  TIX   #10000
+      JLT   loop
+halt  J     halt
+      END   prog
+`
+	fileName := filepath.Join(t.TempDir(), "prog.asm")
+	if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	published := make(chan struct{}, 1)
+	controller := NewController(func() {
+		select {
+		case published <- struct{}{}:
+		default:
+		}
+	})
+	defer controller.Quit()
+
+	// Waits for a view matching the condition, views are read while the machine runs
+	waitForView := func(name string, condition func(view *View) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			if condition(controller.View()) {
+				return
+			}
+			select {
+			case <-published:
+			case <-timeout:
+				t.Fatalf("%s: timed out", name)
+			}
+		}
+	}
+
+	loaded := make(chan error, 1)
+	controller.Send(Command{Type: CommandLoad, FileName: fileName, Options: loader.AssemblerOptions, Loaded: func(programName string, err error) {
+		loaded <- err
+	}})
+	if err := <-loaded; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		command   Command
+		condition func(view *View) bool
+	}{
+		{
+			name:    "Step",
+			command: Command{Type: CommandStep},
+			condition: func(view *View) bool {
+				return view.Counters.Instructions == 1 && view.Registers.PC.Compare(units.IntToInt24(0x03)) == 0
+			},
+		},
+		{
+			name:    "Run to halt",
+			command: Command{Type: CommandRun},
+			condition: func(view *View) bool {
+				return view.ExecuteState == ExecuteStopState && view.Registers.PC.Compare(units.IntToInt24(0x09)) == 0
+			},
+		},
+		{
+			name:    "Reset",
+			command: Command{Type: CommandReset},
+			condition: func(view *View) bool {
+				return view.Counters.Instructions == 0 && len(view.InstructionList) == 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller.Send(tt.command)
+			waitForView(tt.name, tt.condition)
+		})
+	}
+}
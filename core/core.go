@@ -25,90 +25,48 @@ func GetNextDisassemblyInstruction(updatePC bool) (proc.Instruction, error) {
 		return proc.UnknownInstruction, loader.ErrDisassemblyEmpty()
 	}
 	instruction, exists := loader.Disassembly[pc]
-	// Instruction not found - disassembly incorrect
-	if !exists {
+	// PC reached code the disassembler didn't - merge it into the listing
+	if !exists || instruction.Directive == proc.DirectiveBYTE {
 		if debugGetNextDisassemblyInstruction {
-			fmt.Println("Instruction not found - incorrect disassembly")
+			fmt.Printf("Instruction not found - following code from %s\n", pc.StringHex())
 		}
-		// Delete all instructions after PC
-		for addr := range loader.Disassembly {
-			if addr.Compare(pc) > 0 {
-				// Delete instructions below PC
-				delete(loader.Disassembly, addr)
-			}
-		}
-
-		// Replace 1st instruction above PC (which now has max address) with its bytes until PC
-		maxAddr := units.Int24{0x00, 0x00, 0x00}
-		for addr := range loader.Disassembly {
-			if addr.Compare(maxAddr) > 0 {
-				maxAddr = addr
-			}
-		}
-		var unknownBytes []byte
-		unknownBytesAddr := maxAddr
-		i := 0
-		for unknownBytesAddr.Compare(pc) < 0 {
-			unknownBytes = append(unknownBytes, loader.Disassembly[maxAddr].Bytes[i])
-			i++
-			unknownBytesAddr = unknownBytesAddr.Add(units.Int24{0x00, 0x00, 0x01})
-		}
-		unknownBytesInstruction := proc.Instruction{
-			Format:             proc.InstructionUnknown,
-			Directive:          proc.DirectiveBYTE,
-			Bytes:              unknownBytes,
-			InstructionAddress: maxAddr,
-		}
-		loader.Disassembly[maxAddr] = unknownBytesInstruction
+		bytecode.FollowCode(loader.Disassembly, loader.Segments, pc)
+		loader.UpdateDisassemblyInstructionAddressOperands()
+		loader.UpdateInstructionList()
+		loader.UpdateLabels()
 
-		// Disassemble from PC to last instruction byte address
-		if debugGetNextDisassemblyInstruction {
-			fmt.Println("Disassembling code from PC to LastInstructionByteAddress:")
-		}
-		codeAfterPC := base.GetSlice(pc, loader.LastInstructionByteAddress)
-		instructions, bytesFromIncompleteInstruction := bytecode.GetInstructionsFromBinary(pc, codeAfterPC)
-		for address, instruction := range instructions {
-			if debugGetNextDisassemblyInstruction {
-				fmt.Printf("    Address: %s, Format: %s, Bytes: % X, Opcode: %s, Operand: %s\n", address.StringHex(), instruction.Format.String(), instruction.Bytes, instruction.Opcode.String(), instruction.Operand.StringHex())
-			}
-			loader.Disassembly[address] = instruction
-		}
-		if len(bytesFromIncompleteInstruction) > 0 {
-			// Add incomplete instruction bytes to the end of Disassembly
-			addrLeftoverBytes := loader.LastInstructionByteAddress
-			for i := 0; i < len(bytesFromIncompleteInstruction); i++ {
-				addrLeftoverBytes.Sub(units.Int24{0x00, 0x00, 0x01})
-			}
-			loader.Disassembly[addrLeftoverBytes] = proc.Instruction{
+		// Invalid opcode, executed as data
+		instruction, exists = loader.Disassembly[pc]
+		if !exists {
+			instruction = proc.Instruction{
+				InstructionAddress: pc,
 				Format:             proc.InstructionUnknown,
-				Bytes:              bytesFromIncompleteInstruction,
-				InstructionAddress: addrLeftoverBytes,
+				Directive:          proc.DirectiveBYTE,
+				Bytes:              []byte{base.GetByte(pc)},
 			}
+			loader.Disassembly[pc] = instruction
+			loader.UpdateInstructionList()
 		}
-
-		loader.UpdateInstructionList()
-		UpdateProcState(base.GetRegisterPC())
-		return GetNextDisassemblyInstruction(updatePC)
 	}
 
 	switch instruction.Format {
 	case proc.InstructionFormat1:
-		pc = pc.Add(units.Int24{0x00, 0x00, 0x01})
+		pc = pc.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 	case proc.InstructionFormat2:
-		pc = pc.Add(units.Int24{0x00, 0x00, 0x02})
+		pc = pc.Add(units.BytesToInt24(0x00, 0x00, 0x02))
 	case proc.InstructionFormatSIC:
-		pc = pc.Add(units.Int24{0x00, 0x00, 0x03})
+		pc = pc.Add(units.BytesToInt24(0x00, 0x00, 0x03))
 	case proc.InstructionFormat3:
-		pc = pc.Add(units.Int24{0x00, 0x00, 0x03})
+		pc = pc.Add(units.BytesToInt24(0x00, 0x00, 0x03))
 	case proc.InstructionFormat4:
-		pc = pc.Add(units.Int24{0x00, 0x00, 0x04})
+		pc = pc.Add(units.BytesToInt24(0x00, 0x00, 0x04))
 	}
 	if updatePC {
 		base.SetRegisterPC(pc)
 	}
 
 	// Update operand and address values
-	if instruction.Format == proc.InstructionFormat3 || instruction.Format == proc.InstructionFormat4 {
+	if instruction.IsFormatSIC34() {
 		operand, address, _, _, _ := instruction.GetOperandAddress(pc)
 		instruction.Operand = operand
 		instruction.Address = address
@@ -118,21 +76,52 @@ func GetNextDisassemblyInstruction(updatePC bool) (proc.Instruction, error) {
 }
 
 func ExecuteNextInstruction() {
-	instruction, err := GetNextDisassemblyInstruction(true)
-	if err != nil {
-		if err == loader.ErrDisassemblyEmpty() {
-			return
+	// Only cleared when set, writing it each instruction isn't free
+	if SimFault != nil {
+		SimFault = nil
+	}
+
+	// Idle processor only waits for an interrupt
+	if base.IsIdle() {
+		advanceCycles(1)
+		taken, err := proc.HandleInterrupts()
+		if err != nil {
+			stopByFault(base.GetRegisterPC(), err)
+		} else if !taken && !proc.IsInterruptExpected() {
+			StopSim()
 		}
+		return
 	}
 
+	pc := base.GetRegisterPC()
+	instruction, err := getDecodedInstruction(pc)
+	if err != nil {
+		return
+	}
+	pc = pc.Add(units.Uint32ToInt24(instruction.Length))
+	base.SetRegisterPC(pc)
+
+	// Cached instructions covering the written memory are stale
+	var storeAddress units.Int24
+	if instruction.Store {
+		storeAddress = instruction.GetAddress(pc)
+	}
 	instruction.Execute()
-	UpdateProcState(base.GetRegisterPC())
+	if instruction.Store {
+		invalidateDecodedInstructions(storeAddress, instruction.StoreLength, instruction.InstructionAddress)
+	}
+	countInstruction(instruction)
+	taken, err := proc.HandleInterrupts()
+	if err != nil {
+		stopByFault(instruction.InstructionAddress, err)
+		return
+	}
 
-	// halt J halt -> Stop execution
+	// halt J halt -> Stop execution, unless it waits for an interrupt as an idle processor does
 	if debugExecuteNextInstruction {
 		fmt.Printf("Check for HALT: %s : %s\n", instruction.InstructionAddress.StringHex(), base.GetRegisterPC().StringHex())
 	}
-	if instruction.Opcode == proc.J && instruction.InstructionAddress.Compare(base.GetRegisterPC()) == 0 {
+	if instruction.Opcode == proc.J && instruction.InstructionAddress == base.GetRegisterPC() && !taken && !proc.IsInterruptExpected() {
 		if debugExecuteNextInstruction {
 			fmt.Println("HALT")
 		}
@@ -140,3 +129,73 @@ func ExecuteNextInstruction() {
 		return
 	}
 }
+
+// Writes memory from outside of the program, such as from a debugger. Code it changes
+// is decoded again and marked as modified by the instruction at PC.
+func WriteMemory(address units.Int24, data []byte) {
+	for i, value := range data {
+		base.SetByte(units.Uint32ToInt24(address.ToUint32()+uint32(i)), value)
+	}
+	invalidateDecodedInstructions(address, len(data), base.GetRegisterPC())
+}
+
+// Runs until the program halts or maxInstructions are executed, 0 runs without a limit.
+// Breakpoints are ignored. Returns false when stopped by the limit.
+func RunProgram(maxInstructions uint64) bool {
+	// Counters continue from a loaded snapshot
+	startInstructions := PerformanceCounters.Instructions
+	SimExecuteState = ExecuteStartState
+	for SimExecuteState == ExecuteStartState {
+		if maxInstructions > 0 && PerformanceCounters.Instructions-startInstructions >= maxInstructions {
+			StopSim()
+			return false
+		}
+		ExecuteNextInstruction()
+	}
+	return true
+}
+
+// Runs until stop returns true after an executed instruction, a breakpoint is reached,
+// the program halts or the simulation is stopped
+func RunUntil(stop func() bool) {
+	SimExecuteState = ExecuteStartState
+	for SimExecuteState == ExecuteStartState {
+		ExecuteNextInstruction()
+		if stop() || isAtBreakpoint() {
+			StopSim()
+		}
+	}
+}
+
+// Executes the next instruction, JSUB is run until its subroutine returns
+func StepOver() {
+	RunUntil(StepOverCondition())
+}
+
+// Runs until the current subroutine returns, outside of subroutines until the program halts
+func StepOut() {
+	RunUntil(StepOutCondition())
+}
+
+func RunToAddress(address units.Int24) {
+	RunUntil(RunToAddressCondition(address))
+}
+
+// Stop conditions are created before the first instruction is executed
+func StepOverCondition() func() bool {
+	depth := len(callStack)
+	return func() bool {
+		return len(callStack) <= depth
+	}
+}
+func StepOutCondition() func() bool {
+	depth := len(callStack)
+	return func() bool {
+		return len(callStack) < depth
+	}
+}
+func RunToAddressCondition(address units.Int24) func() bool {
+	return func() bool {
+		return base.GetRegisterPC().Compare(address) == 0
+	}
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/units"
+)
+
+func TestStepping(t *testing.T) {
+	// Subroutine print is at 0x09, its loop at 0x0C
+	source := `prog  START 0
+      JSUB  print
+      LDA   #1
+halt  J     halt
+print LDT   #3
+ploop TIXR  T
+      JLT   ploop
+      RSUB
+      END   prog
+`
+	tests := []struct {
+		name string
+		run  func()
+		pc   units.Int24
+	}{
+		{name: "Step over JSUB", run: StepOver, pc: units.IntToInt24(0x03)},
+		{name: "Step over other instruction", run: func() { StepOver(); StepOver() }, pc: units.IntToInt24(0x06)},
+		{name: "Step out of subroutine", run: func() { ExecuteNextInstruction(); ExecuteNextInstruction(); StepOut() }, pc: units.IntToInt24(0x03)},
+		{name: "Step out of program", run: StepOut, pc: units.IntToInt24(0x06)},
+		{name: "Run to address", run: func() { RunToAddress(units.IntToInt24(0x0C)) }, pc: units.IntToInt24(0x0C)},
+		{name: "Run to breakpoint", run: func() { SetBreakpoint(units.IntToInt24(0x0C)); StepOut() }, pc: units.IntToInt24(0x0C)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestProgram(t, source)
+			tt.run()
+			if pc := base.GetRegisterPC(); pc.Compare(tt.pc) != 0 {
+				t.Errorf("PC = %s, want %s", pc.StringHex(), tt.pc.StringHex())
+			}
+			if SimExecuteState != ExecuteStopState {
+				t.Error("simulation still running")
+			}
+		})
+	}
+}
+
+func TestSelfModifyingCode(t *testing.T) {
+	// Patched jump at 0x0C loops on itself unless its new bytes are decoded
+	source := `prog  START 0
+      LDA   patch
+      LDX   patch+1
+      STA   jmp
+      STX   jmp+1
+jmp   +J    jmp
+done  J     done
+patch +J    done
+      END   prog
+`
+	loadTestProgram(t, source)
+	if !RunProgram(100) {
+		t.Fatal("program did not halt")
+	}
+	if pc := base.GetRegisterPC(); pc != units.IntToInt24(0x10) {
+		t.Errorf("PC = %s, want %s", pc.StringHex(), units.IntToInt24(0x10).StringHex())
+	}
+	if _, modified := loader.ModifiedCode[units.IntToInt24(0x0C)]; !modified {
+		t.Error("patched jump not marked as modified code")
+	}
+}
+
+func TestChannelLoadsCode(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDirectory)
+
+	// Channel 1 reads LDA #7 from device F1 over the loop at 0x0B, which was already decoded
+	source := `prog  START 0
+      LDA   #1
+      LDS   #ccw
+      SIO
+wait  TIO
+      JEQ   wait
+patch J     patch
+done  J     done
+ccw   BYTE  X'0100F1'
+      WORD  3
+      WORD  patch
+      RESW  3
+      END   prog
+`
+	if err := os.WriteFile("F1.dev", []byte{0x01, 0x00, 0x07}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loadTestProgram(t, source)
+	if !RunProgram(1000) {
+		t.Fatal("program did not halt")
+	}
+	if pc := base.GetRegisterPC(); pc != units.IntToInt24(0x0E) {
+		t.Errorf("PC = %s, want %s", pc.StringHex(), units.IntToInt24(0x0E).StringHex())
+	}
+	if a := base.GetRegisterA(); a != units.IntToInt24(7) {
+		t.Errorf("A = %s, want %s", a.StringHex(), units.IntToInt24(7).StringHex())
+	}
+	if storeAddress := loader.ModifiedCode[units.IntToInt24(0x0B)]; storeAddress != units.IntToInt24(0x11) {
+		t.Errorf("loaded code modified by %s, want %s", storeAddress.StringHex(), units.IntToInt24(0x11).StringHex())
+	}
+}
+
+func TestFaultWithoutHandler(t *testing.T) {
+	// Program runs on into data at 0x03, no handler is installed for program interrupts
+	source := `prog  START 0
+      LDA   #1
+      BYTE  X'FFFFFF'
+      END   prog
+`
+	loadTestProgram(t, source)
+	if !RunProgram(100) {
+		t.Fatal("program did not stop")
+	}
+	if PerformanceCounters.Instructions != 2 {
+		t.Errorf("executed %d instructions, want 2", PerformanceCounters.Instructions)
+	}
+	if SimFault == nil || SimFault.Error() != "Stopped at 000003: Program interrupt 00 has no handler" {
+		t.Errorf("SimFault = %v", SimFault)
+	}
+}
+
+func TestWaitForTimer(t *testing.T) {
+	// User program waits on itself until the timer handler at 0x09 runs,
+	// the timer work area at 0x160 holds the handler's SW and PC
+	source := `prog  START 0
+      STI   ticks
+      LPS   user
+wait  J     wait
+timer LDA   #1
+halt  J     halt
+ticks WORD  50
+user  WORD  8192
+      WORD  wait
+      RESW  6
+      RESB  6
+      ORG   prog+352
+      BYTE  X'800000'
+      WORD  timer
+      END   prog
+`
+	loadTestProgram(t, source)
+	if !RunProgram(1000) {
+		t.Fatal("program did not halt")
+	}
+	if pc := base.GetRegisterPC(); pc != units.IntToInt24(0x0C) {
+		t.Errorf("PC = %s, want %s", pc.StringHex(), units.IntToInt24(0x0C).StringHex())
+	}
+	if a := base.GetRegisterA(); a != units.IntToInt24(1) {
+		t.Errorf("A = %s, want %s", a.StringHex(), units.IntToInt24(1).StringHex())
+	}
+}
+
+// Shell sort of count words stored in descending order, gaps are halved
+func getSortSource(count int) string {
+	return fmt.Sprintf(`sort  START 0
+      LDS   #3
+      +LDT  #%d
+      LDX   #0
+      +LDA  #%d
+fill  +STA  arr,X
+      SUB   #1
+      ADDR  S,X
+      COMPR X,T
+      JLT   fill
+      +LDA  #%d
+gloop COMP  #0
+      JEQ   halt
+      STA   gap
+      MUL   #3
+      STA   gapb
+      STA   i
+iloop LDA   i
+      COMPR A,T
+      JEQ   gnext
+      RMO   A,X
+      +LDA  arr,X
+      STA   temp
+      STX   j
+jloop LDA   j
+      COMP  gapb
+      JLT   place
+      SUB   gapb
+      RMO   A,X
+      +LDA  arr,X
+      COMP  temp
+      JGT   shift
+      J     place
+shift STX   jg
+      LDX   j
+      +STA  arr,X
+      LDA   jg
+      STA   j
+      J     jloop
+place LDX   j
+      LDA   temp
+      +STA  arr,X
+      LDA   i
+      ADDR  S,A
+      STA   i
+      J     iloop
+gnext LDA   gap
+      DIV   #2
+      J     gloop
+halt  J     halt
+gap   RESW  1
+gapb  RESW  1
+i     RESW  1
+j     RESW  1
+jg    RESW  1
+temp  RESW  1
+arr   RESW  %d
+      END   sort
+`, count*units.WORD_SIZE, count, count/2, count)
+}
+
+func TestSortProgram(t *testing.T) {
+	count := 100
+	loadTestProgram(t, getSortSource(count))
+	if !RunProgram(1000000) {
+		t.Fatal("program did not halt")
+	}
+	arr := loader.SymbolTable["arr"].Address
+	for i := 0; i < count; i++ {
+		address := units.IntToInt24(int(arr.ToUint32()) + i*units.WORD_SIZE)
+		if word := base.GetWord(address); word != units.IntToInt24(i+1) {
+			t.Fatalf("word %d = %s, want %s", i, word.StringHex(), units.IntToInt24(i+1).StringHex())
+		}
+	}
+}
+
+func BenchmarkRunProgram(b *testing.B) {
+	source := getSortSource(10000)
+	tests := []struct {
+		name        string
+		bookkeeping bool
+	}{
+		{"Bookkeeping", true},
+		{"Counters only", false},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			ProfilerEnabled, CoverageEnabled, CallStackEnabled = tt.bookkeeping, tt.bookkeeping, tt.bookkeeping
+			defer func() {
+				ProfilerEnabled, CoverageEnabled, CallStackEnabled = true, true, true
+			}()
+
+			// Each run sorts memory again, so the program is reloaded untimed
+			var instructions uint64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				loadTestProgram(b, source)
+				b.StartTimer()
+				RunProgram(0)
+				instructions += PerformanceCounters.Instructions
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(instructions)/b.Elapsed().Seconds(), "instr/s")
+		})
+	}
+}
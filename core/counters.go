@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sicsimgo/core/base"
+	"sicsimgo/core/proc"
+)
+
+/*
+DEFINITIONS
+*/
+type Counters struct {
+	Instructions     uint64 `json:"instructions"`
+	Cycles           uint64 `json:"cycles"`
+	MemoryReads      uint64 `json:"memoryReads"`
+	MemoryWrites     uint64 `json:"memoryWrites"`
+	DeviceOperations uint64 `json:"deviceOperations"`
+}
+
+/*
+IMPLEMENTATION
+*/
+var PerformanceCounters Counters = Counters{}
+var CycleCostTable proc.CycleCosts = proc.GetDefaultCycleCosts()
+
+/*
+OPERATIONS
+*/
+func SetCycleCosts(cycleCosts proc.CycleCosts) {
+	CycleCostTable = cycleCosts
+	base.DeviceBusyCycles = cycleCosts.DeviceBusy
+	updateDecodedInstructions()
+}
+
+// Counts the executed instruction and lets the time it took pass
+func countInstruction(instruction *proc.DecodedInstruction) {
+	PerformanceCounters.Instructions++
+	PerformanceCounters.MemoryReads += uint64(instruction.Reads)
+	PerformanceCounters.MemoryWrites += uint64(instruction.Writes)
+	if instruction.Device {
+		PerformanceCounters.DeviceOperations++
+	}
+	advanceCycles(instruction.Cycles)
+
+	if ProfilerEnabled || CoverageEnabled {
+		record := getInstructionRecord(instruction.InstructionAddress)
+		if ProfilerEnabled {
+			profileInstruction(instruction, record)
+		}
+		if CoverageEnabled {
+			coverInstruction(instruction, record)
+		}
+	}
+	if CallStackEnabled {
+		trackCallStack(&instruction.Instruction)
+	}
+}
+
+// Timer, channels and devices run alongside the processor, only while they are active
+func advanceCycles(cycles int) {
+	PerformanceCounters.Cycles += uint64(cycles)
+	if proc.IsTimerRunning() {
+		proc.TickTimer(cycles)
+	}
+	if proc.IsChannelBusy() {
+		PerformanceCounters.DeviceOperations += uint64(proc.TickChannels(cycles))
+	}
+	if base.IsAnyDeviceBusy() {
+		base.TickDevices(cycles)
+	}
+}
+
+func ResetCounters() {
+	PerformanceCounters = Counters{}
+}
+
+// Writes counters one per line, as shown after headless runs
+func WriteCounters(file io.Writer) {
+	fmt.Fprintf(file, "Instructions:      %d\n", PerformanceCounters.Instructions)
+	fmt.Fprintf(file, "Cycles:            %d\n", PerformanceCounters.Cycles)
+	fmt.Fprintf(file, "Cycles per instr.: %s\n", PerformanceCounters.StringCPI())
+	fmt.Fprintf(file, "Memory reads:      %d\n", PerformanceCounters.MemoryReads)
+	fmt.Fprintf(file, "Memory writes:     %d\n", PerformanceCounters.MemoryWrites)
+	fmt.Fprintf(file, "Device operations: %d\n", PerformanceCounters.DeviceOperations)
+}
+
+/*
+STRINGS
+*/
+// Cycles per instruction
+func (counters Counters) StringCPI() string {
+	if counters.Instructions == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", float64(counters.Cycles)/float64(counters.Instructions))
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type BranchCoverage struct {
+	Taken    uint64
+	NotTaken uint64
+}
+
+// Source line of an instruction, executions come from the profiler
+type LineCoverage struct {
+	Line       int
+	Source     string
+	Address    units.Int24
+	Executions uint64
+	Branch     bool
+	BranchCoverage
+}
+
+/*
+IMPLEMENTATION
+*/
+// Line coverage also needs the profiler, which counts executions
+var CoverageEnabled bool = true
+
+/*
+OPERATIONS
+*/
+// Called after the instruction was executed, a taken jump has changed PC.
+// Coverage is kept in the instruction's profiler record.
+func coverInstruction(instruction *proc.DecodedInstruction, record *instructionRecord) {
+	if !instruction.ConditionalJump {
+		return
+	}
+	if base.GetRegisterPC().ToUint32() == instruction.InstructionAddress.ToUint32()+instruction.Length {
+		record.NotTaken++
+	} else {
+		record.Taken++
+	}
+}
+
+func GetBranchCoverage(address units.Int24) BranchCoverage {
+	if record := findInstructionRecord(address); record != nil {
+		return record.BranchCoverage
+	}
+	return BranchCoverage{}
+}
+
+// Coverage of instruction lines of the assembled source, in source order
+func GetLineCoverage() []LineCoverage {
+	var lines []LineCoverage
+	for _, syntaxNode := range loader.SyntaxNodes {
+		if !isCodeSyntaxNode(syntaxNode) {
+			continue
+		}
+		line := LineCoverage{
+			Line:       syntaxNode.LineNumber,
+			Source:     syntaxNode.Source,
+			Address:    syntaxNode.LocationCounter,
+			Executions: GetInstructionProfile(syntaxNode.LocationCounter).Executions,
+		}
+		instruction := proc.Instruction{Opcode: assembly.GetInstructionOpcode(assembly.MnemonicName(strings.TrimPrefix(string(syntaxNode.Mnemonic), "+")))}
+		if instruction.IsConditionalJump() {
+			line.Branch = true
+			line.BranchCoverage = GetBranchCoverage(syntaxNode.LocationCounter)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func isCodeSyntaxNode(syntaxNode assembly.SyntaxNode) bool {
+	if syntaxNode.Size == 0 || len(syntaxNode.Errors) > 0 {
+		return false
+	}
+	switch syntaxNode.MnemonicType {
+	case assembly.MnemonicF1, assembly.MnemonicF2N, assembly.MnemonicF2R, assembly.MnemonicF2RN, assembly.MnemonicF2RR, assembly.MnemonicF3, assembly.MnemonicF3M, assembly.MnemonicF4M:
+		return true
+	}
+	return false
+}
+
+// Executed lines, all lines, executed branch directions and all branch directions
+func GetCoverageSummary(lines []LineCoverage) (int, int, int, int) {
+	linesHit, branchesHit, branches := 0, 0, 0
+	for _, line := range lines {
+		if line.Executions > 0 {
+			linesHit++
+		}
+		if !line.Branch {
+			continue
+		}
+		branches += 2
+		if line.Taken > 0 {
+			branchesHit++
+		}
+		if line.NotTaken > 0 {
+			branchesHit++
+		}
+	}
+	return linesHit, len(lines), branchesHit, branches
+}
+
+// Writes source with execution counts, never executed lines are marked with #####
+func WriteCoverageListing(file io.Writer) {
+	lines := GetLineCoverage()
+	coverage := make(map[int]LineCoverage)
+	for _, line := range lines {
+		coverage[line.Line] = line
+	}
+
+	fmt.Fprintf(file, "%6s  %5s  %s\n", "COUNT", "LINE", "SOURCE")
+	currentLineNumber := 1
+	for _, syntaxNode := range loader.SyntaxNodes {
+		// Blank lines aren't parsed into syntax nodes
+		for currentLineNumber < syntaxNode.LineNumber {
+			fmt.Fprintf(file, "%6s  %5d\n", "", currentLineNumber)
+			currentLineNumber++
+		}
+		currentLineNumber = syntaxNode.LineNumber + 1
+
+		line, code := coverage[syntaxNode.LineNumber]
+		count := ""
+		if code && line.Executions == 0 {
+			count = "#####"
+		} else if code {
+			count = fmt.Sprintf("%d", line.Executions)
+		}
+		fmt.Fprintf(file, "%6s  %5d  %s", count, syntaxNode.LineNumber, syntaxNode.Source)
+		if line.Branch {
+			fmt.Fprintf(file, "    [taken %d, not taken %d]", line.Taken, line.NotTaken)
+		}
+		fmt.Fprintln(file)
+	}
+
+	linesHit, linesFound, branchesHit, branchesFound := GetCoverageSummary(lines)
+	fmt.Fprintf(file, "\nLines:    %d/%d (%s)\n", linesHit, linesFound, stringPercentage(linesHit, linesFound))
+	fmt.Fprintf(file, "Branches: %d/%d (%s)\n", branchesHit, branchesFound, stringPercentage(branchesHit, branchesFound))
+}
+
+// Writes coverage as an lcov tracefile, each conditional jump is a block with a taken and a not taken branch
+func WriteLcovFile(file io.Writer) {
+	lines := GetLineCoverage()
+
+	fmt.Fprintf(file, "TN:%s\n", loader.ProgramName)
+	fmt.Fprintf(file, "SF:%s\n", loader.SourceFileName)
+	for _, line := range lines {
+		if !line.Branch {
+			continue
+		}
+		taken, notTaken := "-", "-"
+		if line.Executions > 0 {
+			taken, notTaken = fmt.Sprintf("%d", line.Taken), fmt.Sprintf("%d", line.NotTaken)
+		}
+		fmt.Fprintf(file, "BRDA:%d,0,0,%s\n", line.Line, taken)
+		fmt.Fprintf(file, "BRDA:%d,0,1,%s\n", line.Line, notTaken)
+	}
+	linesHit, linesFound, branchesHit, branchesFound := GetCoverageSummary(lines)
+	fmt.Fprintf(file, "BRF:%d\n", branchesFound)
+	fmt.Fprintf(file, "BRH:%d\n", branchesHit)
+	for _, line := range lines {
+		fmt.Fprintf(file, "DA:%d,%d\n", line.Line, line.Executions)
+	}
+	fmt.Fprintf(file, "LF:%d\n", linesFound)
+	fmt.Fprintf(file, "LH:%d\n", linesHit)
+	fmt.Fprintf(file, "end_of_record\n")
+}
+
+func ResetCoverage() {
+	for index := range instructionRecords {
+		instructionRecords[index].BranchCoverage = BranchCoverage{}
+	}
+}
+
+/*
+STRINGS
+*/
+func stringPercentage(part int, whole int) string {
+	if whole == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(whole))
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestGetLineCoverage(t *testing.T) {
+	source := `prog  START 0
+      LDA   #1
+      COMP  #0
+      JEQ   skip
+      LDX   #0
+loop  TIX   #3
+      JLT   loop
+halt  J     halt
+skip  LDA   #0
+      J     halt
+      END   prog
+`
+	loadTestProgram(t, source)
+	if !RunProgram(1000) {
+		t.Fatal("program did not halt")
+	}
+	lines := GetLineCoverage()
+
+	tests := []struct {
+		name       string
+		line       int
+		executions uint64
+		branch     BranchCoverage
+	}{
+		{name: "Jump never taken", line: 4, executions: 1, branch: BranchCoverage{Taken: 0, NotTaken: 1}},
+		{name: "Jump taken and not taken", line: 7, executions: 3, branch: BranchCoverage{Taken: 2, NotTaken: 1}},
+		{name: "Line never executed", line: 9, executions: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, line := range lines {
+				if line.Line != tt.line {
+					continue
+				}
+				if line.Executions != tt.executions {
+					t.Errorf("Executions = %d, want %d", line.Executions, tt.executions)
+				}
+				if line.BranchCoverage != tt.branch {
+					t.Errorf("BranchCoverage = %+v, want %+v", line.BranchCoverage, tt.branch)
+				}
+				return
+			}
+			t.Errorf("line %d not covered", tt.line)
+		})
+	}
+
+	if linesHit, linesFound, branchesHit, branchesFound := GetCoverageSummary(lines); linesHit != 7 || linesFound != 9 || branchesHit != 3 || branchesFound != 4 {
+		t.Errorf("GetCoverageSummary() = %d, %d, %d, %d, want 7, 9, 3, 4", linesHit, linesFound, branchesHit, branchesFound)
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+IMPLEMENTATION
+*/
+// Instructions of the disassembly decoded for execution, indexes of the instructions
+// by address are one based, zero where no instruction starts
+var decodedInstructions []proc.DecodedInstruction
+var decodedInstructionIndexes []int32 = make([]int32, base.MEMORY_SIZE)
+
+// The loader replaces its instruction list whenever the disassembly changes,
+// the table is decoded again when it no longer matches
+var decodedInstructionList []proc.Instruction
+
+func init() {
+	proc.ChannelStore = func(address units.Int24, commandAddress units.Int24) {
+		invalidateDecodedInstructions(address, 1, commandAddress)
+	}
+}
+
+/*
+OPERATIONS
+*/
+// Instruction at PC, code the disassembler didn't reach is followed first
+func getDecodedInstruction(pc units.Int24) (*proc.DecodedInstruction, error) {
+	if !isSameInstructionList(decodedInstructionList, loader.InstructionList) {
+		updateDecodedInstructions()
+	}
+	if decoded := findDecodedInstruction(pc.ToUint32()); decoded != nil && decoded.Directive != proc.DirectiveBYTE {
+		return decoded, nil
+	}
+
+	instruction, err := GetNextDisassemblyInstruction(false)
+	if err != nil {
+		return nil, err
+	}
+	updateDecodedInstructions()
+	if decoded := findDecodedInstruction(pc.ToUint32()); decoded != nil && decoded.Directive != proc.DirectiveBYTE {
+		return decoded, nil
+	}
+	// Executed as data, each time it is reached
+	decoded := proc.Decode(instruction, CycleCostTable)
+	return &decoded, nil
+}
+
+func findDecodedInstruction(address uint32) *proc.DecodedInstruction {
+	if address >= uint32(len(decodedInstructionIndexes)) || decodedInstructionIndexes[address] == 0 {
+		return nil
+	}
+	return &decodedInstructions[decodedInstructionIndexes[address]-1]
+}
+
+func updateDecodedInstructions() {
+	for _, decoded := range decodedInstructions {
+		decodedInstructionIndexes[decoded.InstructionAddress.ToUint32()] = 0
+	}
+	decodedInstructions = make([]proc.DecodedInstruction, 0, len(loader.InstructionList))
+	for _, instruction := range loader.InstructionList {
+		if address := instruction.InstructionAddress.ToUint32(); address < uint32(len(decodedInstructionIndexes)) {
+			decodedInstructions = append(decodedInstructions, proc.Decode(instruction, CycleCostTable))
+			decodedInstructionIndexes[address] = int32(len(decodedInstructions))
+		}
+	}
+	decodedInstructionList = loader.InstructionList
+}
+
+// Stores changing bytes of disassembled instructions or data are passed to the loader,
+// which decodes them again. Written data only updates the bytes in the table.
+func invalidateDecodedInstructions(address units.Int24, length int, storeAddress units.Int24) {
+	start := int(address.ToUint32())
+	end := min(start+length, len(decodedInstructionIndexes))
+
+	changed := false
+	for entryAddress := max(start-3, 0); entryAddress < end; entryAddress++ {
+		decoded := findDecodedInstruction(uint32(entryAddress))
+		if decoded != nil && entryAddress+len(decoded.Bytes) > start && !bytes.Equal(getDecodedBytes(decoded), decoded.Bytes) {
+			changed = true
+			break
+		}
+	}
+	if !changed || loader.InvalidateDisassembly(address, length, storeAddress) {
+		return
+	}
+
+	for entryAddress := max(start-3, 0); entryAddress < end; entryAddress++ {
+		decoded := findDecodedInstruction(uint32(entryAddress))
+		if decoded != nil && entryAddress+len(decoded.Bytes) > start {
+			decoded.Bytes = append([]byte{}, getDecodedBytes(decoded)...)
+		}
+	}
+}
+
+// Bytes in memory where the instruction was decoded from
+func getDecodedBytes(decoded *proc.DecodedInstruction) []byte {
+	start := decoded.InstructionAddress.ToUint32()
+	return base.GetSlice(decoded.InstructionAddress, units.Uint32ToInt24(start+uint32(len(decoded.Bytes))))
+}
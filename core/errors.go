@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+
+	"sicsimgo/core/units"
+)
+
+func ErrUnknownHotspotOrder(name string) error {
+	return fmt.Errorf("Unknown hotspot order: %s", name)
+}
+
+func ErrNotSnapshot() error {
+	return fmt.Errorf("Not a snapshot file")
+}
+
+func ErrUnsupportedSnapshotVersion(version int) error {
+	return fmt.Errorf("Unsupported snapshot version: %d", version)
+}
+
+func ErrInvalidSnapshot(fileName string, err error) error {
+	return fmt.Errorf("Invalid snapshot %s: %v", fileName, err)
+}
+
+func ErrInvalidSnapshotRegister(name string, value string) error {
+	return fmt.Errorf("Invalid snapshot register %s: %s", name, value)
+}
+
+func ErrInvalidSnapshotChannel(number int) error {
+	return fmt.Errorf("Invalid snapshot channel: %d", number)
+}
+
+func ErrInvalidSnapshotInstruction(address string) error {
+	return fmt.Errorf("Invalid snapshot instruction at %s", address)
+}
+
+func ErrFault(address units.Int24, err error) error {
+	return fmt.Errorf("Stopped at %06X: %v", address.ToUint32(), err)
+}
@@ -2,8 +2,10 @@ package assembly
 
 import (
 	"bufio"
+	"encoding/hex"
 	"fmt"
-	"os"
+	"io"
+	"math"
 	"sicsimgo/core/base"
 	"sicsimgo/core/proc"
 	"sicsimgo/core/units"
@@ -14,15 +16,34 @@ import (
 /*
 DEFINITIONS
 */
+type SymbolType int
+
 type Symbol struct {
 	Name       string
 	Address    units.Int24
+	Type       SymbolType
 	Data       bool
 	DataLength int
 	Value      []byte
 }
 type SymbolTable map[string]Symbol
 
+// Assembler settings that are not part of the source file
+type Options struct {
+	Dialect Dialect
+
+	// Promote instructions whose operand does not fit format 3
+	AutoExtend bool
+
+	// Plain SIC instruction set and encoding, also set by the SIC directive
+	SIC bool
+}
+
+const (
+	SymbolRelative SymbolType = 0
+	SymbolAbsolute SymbolType = 1
+)
+
 /*
 DEBUG
 */
@@ -31,98 +52,178 @@ var debugParseProgram bool = false
 /*
 OPERATIONS
 */
-func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instruction, SymbolTable, []SyntaxNode) {
+func LoadProgram(file io.Reader, options Options) (string, units.Int24, map[units.Int24]proc.Instruction, SymbolTable, []SyntaxNode) {
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if hasSICDirective(lines, options) {
+		options.SIC = true
+	}
+
+	// Promoting instructions to format 4 moves everything after them, so
+	// passes repeat until no more instructions need to be extended
+	extendedLines := make(map[int]bool)
+	for {
+		programName, endPC, disassembly, symbolTable, syntaxNodes, resized := assemble(lines, options, extendedLines)
+		if resized {
+			continue
+		}
+
+		for _, syntaxNode := range syntaxNodes {
+			if len(syntaxNode.Errors) == 0 {
+				setMemory(syntaxNode.LocationCounter, syntaxNode.ObjectCode)
+			}
+		}
+		return programName, endPC, disassembly, symbolTable, syntaxNodes
+	}
+}
+
+// Runs both passes, reports whether instruction sizes changed
+func assemble(lines []string, options Options, extendedLines map[int]bool) (string, units.Int24, map[units.Int24]proc.Instruction, SymbolTable, []SyntaxNode, bool) {
 	var programName string
 	var endPC units.Int24
 	var disassembly map[units.Int24]proc.Instruction = make(map[units.Int24]proc.Instruction)
 	var symbolTable SymbolTable = make(SymbolTable)
 	var syntaxNodes []SyntaxNode
 
+	endPCSet := false
+	var firstInstruction units.Int24
+	firstInstructionSet := false
+
 	// First pass
-	LocationCounter := units.Int24{0x00, 0x00, 0x00}
+	LocationCounter := units.BytesToInt24(0x00, 0x00, 0x00)
 	LineCounter := 0
-	baseEnabled := false
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
+	for _, line := range lines {
 		LineCounter++
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) == 0 {
+		if len(strings.TrimSpace(line)) == 0 {
 			continue
 		}
 
 		// Get syntax node
-		syntaxNode := getSyntaxNode(line, LineCounter)
-		if syntaxNode == nil {
-			continue
-		} else if syntaxNode.IsComment {
+		syntaxNode := getSyntaxNode(line, LineCounter, options)
+		syntaxNode.LocationCounter = LocationCounter
+		if syntaxNode.IsComment {
 			syntaxNodes = append(syntaxNodes, *syntaxNode)
 			continue
 		} else if syntaxNode.Mnemonic == "" {
+			// Keep erroneous lines for diagnostics
+			syntaxNodes = append(syntaxNodes, *syntaxNode)
+			continue
+		}
+		if !hasRequiredOperands(*syntaxNode) {
+			syntaxNode.addError(syntaxNode.MnemonicColumn, ErrMissingOperand(syntaxNode.Mnemonic))
+			syntaxNodes = append(syntaxNodes, *syntaxNode)
 			continue
 		}
+		if syntaxNode.Label != "" {
+			if _, exists := symbolTable[syntaxNode.Label]; exists {
+				syntaxNode.addError(syntaxNode.LabelColumn, ErrDuplicateSymbol(syntaxNode.Label))
+			}
+		}
 
-		syntaxNode.LocationCounter = LocationCounter
+		if options.SIC {
+			checkSICMode(syntaxNode)
+		}
 
 		// Directives
 		switch syntaxNode.Mnemonic {
 		case START:
 			programName = syntaxNode.Label
+			if len(syntaxNode.Operands) > 0 {
+				startAddress, err := GetAbsoluteOperandAddress(syntaxNode.Operands[0])
+				if err != nil {
+					syntaxNode.addError(syntaxNode.operandColumn(0), err)
+				}
+				LocationCounter = startAddress
+				syntaxNode.LocationCounter = startAddress
+			}
+			if syntaxNode.Label != "" {
+				symbolTable[syntaxNode.Label] = Symbol{Name: syntaxNode.Label, Address: LocationCounter}
+			}
 		case ORG:
-			orgValue := GetAbsoluteOperandAddress(syntaxNode.Operands[0])
+			orgValue, _, err := GetOperandAddress(syntaxNode.Operands[0], LocationCounter, symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
 			LocationCounter = orgValue
-		case BASE:
-			baseEnabled = true
-		case NOBASE:
-			baseEnabled = false
-		case RESW:
+		case EQU:
+			// EQU operands may only reference previously defined symbols
+			equValue, equType, err := GetOperandAddress(syntaxNode.Operands[0], LocationCounter, symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
 			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Name = syntaxNode.Label
-				symbol.Address = LocationCounter
-				symbol.Data = true
-				symbol.DataLength = 3
+				symbol := defineSymbol(symbolTable, syntaxNode.Label, equValue, 3)
+				symbol.Type = equType
 				symbolTable[syntaxNode.Label] = symbol
 			}
-			LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x03})
+		case RESW:
+			count, err := getReservationCount(syntaxNode.Operands[0], symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
+			if syntaxNode.Label != "" {
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 3)
+			}
+			syntaxNode.Size = 3 * count
 		case WORD:
 			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Name = syntaxNode.Label
-				symbol.Address = LocationCounter
-				symbol.Data = true
-				symbol.DataLength = 3
-				symbolTable[syntaxNode.Label] = symbol
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 3)
 			}
-			LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x03})
+			syntaxNode.Size = 3
 		case RESB:
+			count, err := getReservationCount(syntaxNode.Operands[0], symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
 			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Name = syntaxNode.Label
-				symbol.Address = LocationCounter
-				symbol.Data = true
-				symbol.DataLength = 1
-				symbolTable[syntaxNode.Label] = symbol
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 1)
 			}
-			LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x01})
+			syntaxNode.Size = count
 		case BYTE:
+			byteConstant, err := GetByteConstant(syntaxNode.Operands[0])
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
 			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Name = syntaxNode.Label
-				symbol.Address = LocationCounter
-				symbol.Data = true
-				symbol.DataLength = 1
-				symbolTable[syntaxNode.Label] = symbol
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 1)
+			}
+			syntaxNode.Size = len(byteConstant)
+		case RESF:
+			count, err := getReservationCount(syntaxNode.Operands[0], symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
+			if syntaxNode.Label != "" {
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 6)
+			}
+			syntaxNode.Size = 6 * count
+		case FLOT:
+			if _, err := GetFloatConstant(syntaxNode.Operands[0]); err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
+			if syntaxNode.Label != "" {
+				symbolTable[syntaxNode.Label] = defineSymbol(symbolTable, syntaxNode.Label, LocationCounter, 6)
 			}
-			LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x01})
+			syntaxNode.Size = 6
 		}
 
 		// Instructions
+		if syntaxNode.MnemonicType == MnemonicF3M && extendedLines[syntaxNode.LineNumber] {
+			syntaxNode.MnemonicType = MnemonicF4M
+			syntaxNode.addWarning(syntaxNode.MnemonicColumn, WarnExtendedToFormat4(syntaxNode.Mnemonic))
+		}
 		if IsMnemonicInstruction(syntaxNode.MnemonicType) {
 			instruction := GetInstructionFromSyntaxNode(*syntaxNode, LocationCounter)
-			if baseEnabled {
-				instruction.RelativeAddressingMode = proc.BaseRelativeAddressing
+			if options.SIC {
+				instruction.Format = proc.InstructionFormatSIC
+			}
+			if len(syntaxNode.Errors) == 0 {
+				disassembly[LocationCounter] = instruction
 			}
-			disassembly[LocationCounter] = instruction
 			if syntaxNode.Label != "" {
 				symbol := symbolTable[syntaxNode.Label]
 				symbol.Name = syntaxNode.Label
@@ -132,15 +233,16 @@ func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instr
 
 			switch instruction.Format {
 			case proc.InstructionFormat1:
-				LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x01})
+				syntaxNode.Size = 1
 			case proc.InstructionFormat2:
-				LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x02})
-			case proc.InstructionFormat3:
-				LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x03})
+				syntaxNode.Size = 2
+			case proc.InstructionFormatSIC, proc.InstructionFormat3:
+				syntaxNode.Size = 3
 			case proc.InstructionFormat4:
-				LocationCounter = LocationCounter.Add(units.Int24{0x00, 0x00, 0x04})
+				syntaxNode.Size = 4
 			}
 		}
+		LocationCounter = LocationCounter.Add(units.IntToInt24(syntaxNode.Size))
 
 		syntaxNodes = append(syntaxNodes, *syntaxNode)
 	}
@@ -162,40 +264,63 @@ func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instr
 	}
 
 	// Second pass
-	for _, syntaxNode := range syntaxNodes {
+	resized := false
+	baseEnabled := false
+	var baseAddress units.Int24
+	for idx := range syntaxNodes {
+		syntaxNode := &syntaxNodes[idx]
 
-		if syntaxNode.IsComment {
+		if syntaxNode.IsComment || syntaxNode.Mnemonic == "" || len(syntaxNode.Errors) > 0 {
 			continue
 		}
 
 		// Directives
 		switch syntaxNode.Mnemonic {
-		case EQU:
-			equValue := GetOperandAddress(syntaxNode.Operands[0], symbolTable)
-			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Name = syntaxNode.Label
-				symbol.Address = equValue
-				symbol.Data = true
-				symbol.DataLength = 3
-				symbolTable[syntaxNode.Label] = symbol
+		case BASE:
+			address, _, err := GetOperandAddress(syntaxNode.Operands[0], syntaxNode.LocationCounter, symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
+			baseAddress = address
+			baseEnabled = true
+		case NOBASE:
+			baseEnabled = false
+		case END:
+			if len(syntaxNode.Operands) > 0 {
+				endAddress, _, err := GetOperandAddress(syntaxNode.Operands[0], syntaxNode.LocationCounter, symbolTable)
+				if err != nil {
+					syntaxNode.addError(syntaxNode.operandColumn(0), err)
+				}
+				endPC = endAddress
+				endPCSet = true
 			}
 		case WORD:
-			absoluteOperandAddress := GetAbsoluteOperandAddress(syntaxNode.Operands[0])
-			if syntaxNode.Label != "" {
-				symbol := symbolTable[syntaxNode.Label]
-				symbol.Value = []byte{absoluteOperandAddress[0], absoluteOperandAddress[1], absoluteOperandAddress[2]}
-				symbolTable[syntaxNode.Label] = symbol
+			wordValue, wordType, err := GetOperandAddress(syntaxNode.Operands[0], syntaxNode.LocationCounter, symbolTable)
+			if err != nil {
+				syntaxNode.addError(syntaxNode.operandColumn(0), err)
+			}
+			wordBytes := wordValue.Bytes()
+			syntaxNode.ObjectCode = wordBytes[:]
+			// Plain SIC programs are absolute
+			if err == nil && wordType == SymbolRelative && !options.SIC {
+				syntaxNode.Modifications = append(syntaxNode.Modifications, Modification{
+					Address:   syntaxNode.LocationCounter,
+					HalfBytes: 6,
+				})
 			}
-			base.SetWord(syntaxNode.LocationCounter, absoluteOperandAddress)
 		case BYTE:
-			absoluteOperandAddress := GetAbsoluteOperandAddress(syntaxNode.Operands[0])
+			byteConstant, _ := GetByteConstant(syntaxNode.Operands[0])
+			syntaxNode.ObjectCode = byteConstant
+		case FLOT:
+			floatConstant, _ := GetFloatConstant(syntaxNode.Operands[0])
+			syntaxNode.ObjectCode = floatConstant[:]
+		}
+		if syntaxNode.MnemonicType == MnemonicStorageN {
 			if syntaxNode.Label != "" {
 				symbol := symbolTable[syntaxNode.Label]
-				symbol.Value = []byte{absoluteOperandAddress[0]}
+				symbol.Value = syntaxNode.ObjectCode
 				symbolTable[syntaxNode.Label] = symbol
 			}
-			base.SetByte(syntaxNode.LocationCounter, absoluteOperandAddress[0])
 		}
 
 		// Instructions
@@ -204,97 +329,330 @@ func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instr
 
 			switch syntaxNode.MnemonicType {
 			case MnemonicF2N:
-				// TODO: SYSCALL
+				// SVC n, the number takes place of the first register
+				n, err := parseNumber(syntaxNode.Operands[0])
+				if err != nil || n < 0 || n > 15 {
+					syntaxNode.addError(syntaxNode.operandColumn(0), ErrInvalidOperand(syntaxNode.Operands[0]))
+				}
+				instruction.R1 = base.RegisterId(uint8(n))
 			case MnemonicF2R:
-				instruction.R1 = GetRegisterIdFromMnemonic(syntaxNode.Operands[0])
+				instruction.R1 = getRegisterOperand(syntaxNode, 0)
 			case MnemonicF2RN:
-				instruction.R1 = GetRegisterIdFromMnemonic(syntaxNode.Operands[0])
-				n, _ := strconv.Atoi(syntaxNode.Operands[1])
+				operands := syntaxNode.Operands
+				if len(operands) != 2 {
+					syntaxNode.addError(syntaxNode.MnemonicColumn, ErrMissingOperand(syntaxNode.Mnemonic))
+					break
+				}
+				instruction.R1 = getRegisterOperand(syntaxNode, 0)
+				n, err := parseNumber(operands[1])
+				if err != nil || n < 1 || n > 16 {
+					syntaxNode.addError(syntaxNode.operandColumn(1), ErrInvalidOperand(operands[1]))
+				}
 				instruction.R2 = base.RegisterId(uint8(n - 1))
 			case MnemonicF2RR:
-				if strings.Contains(syntaxNode.Operands[0], ",") {
-					r1r2Strings := strings.Split(syntaxNode.Operands[0], ",")
-					instruction.R1 = GetRegisterIdFromMnemonic(r1r2Strings[0])
-					instruction.R2 = GetRegisterIdFromMnemonic(r1r2Strings[1])
-				} else {
-					instruction.R1 = GetRegisterIdFromMnemonic(syntaxNode.Operands[0])
-					instruction.R2 = GetRegisterIdFromMnemonic(syntaxNode.Operands[1])
+				if len(syntaxNode.Operands) != 2 {
+					syntaxNode.addError(syntaxNode.MnemonicColumn, ErrMissingOperand(syntaxNode.Mnemonic))
+					break
 				}
+				instruction.R1 = getRegisterOperand(syntaxNode, 0)
+				instruction.R2 = getRegisterOperand(syntaxNode, 1)
 			case MnemonicF3:
 				instruction.AbsoluteAddressingMode = proc.DirectAbsoluteAddressing
+				if options.SIC {
+					instruction.AbsoluteAddressingMode = proc.SICAbsoluteAddressing
+				}
 			case MnemonicF3M:
-				pcAfterInstruction := syntaxNode.LocationCounter.Add(units.Int24{0x00, 0x00, 0x03})
-				baseEnabled := instruction.RelativeAddressingMode == proc.BaseRelativeAddressing
-				operandAddress, absoluteAddressingMode, relativeAddressingMode, indexAddressingMode := GetOperandAddressAddressingModes(syntaxNode.Operands[0], pcAfterInstruction, baseEnabled, symbolTable)
-				instruction.Address = operandAddress
+				pcAfterInstruction := syntaxNode.LocationCounter.Add(units.BytesToInt24(0x00, 0x00, 0x03))
+				operand, absoluteAddressingMode, indexAddressingMode := GetOperandAddressingModes(syntaxNode.addressOperand())
+				operandAddress, operandType, err := GetOperandAddress(operand, syntaxNode.LocationCounter, symbolTable)
+				if err != nil {
+					syntaxNode.addError(syntaxNode.operandColumn(0), err)
+					break
+				}
 				instruction.AbsoluteAddressingMode = absoluteAddressingMode
-				instruction.RelativeAddressingMode = relativeAddressingMode
 				instruction.IndexAddressingMode = indexAddressingMode
+
+				// Plain SIC uses the direct 15-bit address
+				if options.SIC {
+					if !isSICAddress(operandAddress) {
+						syntaxNode.addError(syntaxNode.operandColumn(0), ErrAddressOutOfRange(operand, proc.InstructionFormatSIC))
+						break
+					}
+					instruction.Address = operandAddress
+					instruction.AbsoluteAddressingMode = proc.SICAbsoluteAddressing
+					instruction.RelativeAddressingMode = proc.DirectRelativeAddressing
+					break
+				}
+
+				displacement, relativeAddressingMode, fits := GetRelativeAddressing(operandAddress, absoluteAddressingMode, operandType == SymbolRelative, pcAfterInstruction, baseAddress, baseEnabled)
+				switch {
+				case fits:
+					instruction.Address = displacement
+					instruction.RelativeAddressingMode = relativeAddressingMode
+				case !options.AutoExtend:
+					syntaxNode.addError(syntaxNode.operandColumn(0), ErrAddressOutOfRange(operand, proc.InstructionFormat3))
+				case absoluteAddressingMode == proc.DirectAbsoluteAddressing && isSICAddress(operandAddress):
+					// SIC format reaches 15-bit addresses without growing the instruction
+					instruction.Format = proc.InstructionFormatSIC
+					instruction.Address = operandAddress
+					instruction.AbsoluteAddressingMode = proc.SICAbsoluteAddressing
+					instruction.RelativeAddressingMode = proc.DirectRelativeAddressing
+					syntaxNode.addWarning(syntaxNode.MnemonicColumn, WarnExtendedToSIC(syntaxNode.Mnemonic))
+					if operandType == SymbolRelative {
+						syntaxNode.Modifications = append(syntaxNode.Modifications, Modification{
+							Address:   syntaxNode.LocationCounter.Add(units.BytesToInt24(0x00, 0x00, 0x01)),
+							HalfBytes: 4,
+						})
+					}
+				default:
+					extendedLines[syntaxNode.LineNumber] = true
+					resized = true
+				}
 			case MnemonicF4M:
-				pcAfterInstruction := syntaxNode.LocationCounter.Add(units.Int24{0x00, 0x00, 0x04})
-				operandAddress, absoluteAddressingMode, _, indexAddressingMode := GetOperandAddressAddressingModes(syntaxNode.Operands[0], pcAfterInstruction, false, symbolTable)
+				operand, absoluteAddressingMode, indexAddressingMode := GetOperandAddressingModes(syntaxNode.addressOperand())
+				operandAddress, operandType, err := GetOperandAddress(operand, syntaxNode.LocationCounter, symbolTable)
+				if err != nil {
+					syntaxNode.addError(syntaxNode.operandColumn(0), err)
+				}
 				instruction.Address = operandAddress
 				instruction.RelativeAddressingMode = proc.DirectRelativeAddressing
 				instruction.AbsoluteAddressingMode = absoluteAddressingMode
 				instruction.IndexAddressingMode = indexAddressingMode
+
+				// Absolute 20-bit address of a relocatable symbol
+				if err == nil && operandType == SymbolRelative {
+					syntaxNode.Modifications = append(syntaxNode.Modifications, Modification{
+						Address:   syntaxNode.LocationCounter.Add(units.BytesToInt24(0x00, 0x00, 0x01)),
+						HalfBytes: 5,
+					})
+				}
 			}
 
 			instruction.Bytes = instruction.GetInstructionBytes()
-
-			instructionBytesAddress := instruction.InstructionAddress
-			for i := 0; i < len(instruction.Bytes); i++ {
-				base.SetByte(instructionBytesAddress, instruction.Bytes[i])
-				instructionBytesAddress = instructionBytesAddress.Add(units.Int24{0x00, 0x00, 0x01})
+			if len(syntaxNode.Errors) == 0 {
+				syntaxNode.ObjectCode = instruction.Bytes
 			}
 
 			disassembly[syntaxNode.LocationCounter] = instruction
+
+			if !firstInstructionSet {
+				firstInstruction = syntaxNode.LocationCounter
+				firstInstructionSet = true
+			}
+		}
+	}
+
+	// Without END operand, execution starts at the first instruction
+	if !endPCSet {
+		endPC = firstInstruction
+	}
+
+	return programName, endPC, disassembly, symbolTable, syntaxNodes, resized
+}
+
+func hasSICDirective(lines []string, options Options) bool {
+	for lineNumber, line := range lines {
+		if len(strings.TrimSpace(line)) > 0 && getSyntaxNode(line, lineNumber+1, options).Mnemonic == SIC {
+			return true
 		}
 	}
+	return false
+}
 
-	return programName, endPC, disassembly, symbolTable, syntaxNodes
+// Plain SIC has no format 1, 2 and 4 instructions, nor immediate and indirect addressing
+func checkSICMode(syntaxNode *SyntaxNode) {
+	switch {
+	case syntaxNode.Mnemonic == BASE || syntaxNode.Mnemonic == NOBASE:
+		syntaxNode.addError(syntaxNode.MnemonicColumn, ErrNotInSICMode(syntaxNode.Mnemonic))
+	case IsMnemonicInstruction(syntaxNode.MnemonicType) && (syntaxNode.MnemonicType == MnemonicF4M || !IsSICMnemonic(syntaxNode.Mnemonic)):
+		syntaxNode.addError(syntaxNode.MnemonicColumn, ErrNotInSICMode(syntaxNode.Mnemonic))
+	case syntaxNode.MnemonicType == MnemonicF3M:
+		if _, absoluteAddressingMode, _ := GetOperandAddressingModes(syntaxNode.Operands[0]); absoluteAddressingMode != proc.DirectAbsoluteAddressing {
+			syntaxNode.addError(syntaxNode.operandColumn(0), ErrAddressingNotInSICMode(syntaxNode.Operands[0]))
+		}
+	}
+}
+
+func defineSymbol(symbolTable SymbolTable, label string, address units.Int24, dataLength int) Symbol {
+	symbol := symbolTable[label]
+	symbol.Name = label
+	symbol.Address = address
+	symbol.Data = true
+	symbol.DataLength = dataLength
+	return symbol
+}
+
+func setMemory(address units.Int24, bytes []byte) {
+	for i := 0; i < len(bytes); i++ {
+		base.SetByte(address, bytes[i])
+		address = address.Add(units.BytesToInt24(0x00, 0x00, 0x01))
+	}
 }
 
-func getSyntaxNode(line string, lineNumber int) *SyntaxNode {
+func getReservationCount(operand string, symbolTable SymbolTable) (int, error) {
+	count, countType, err := GetOperandAddress(operand, units.Int24{}, symbolTable)
+	if err != nil {
+		return 0, err
+	}
+	if countType != SymbolAbsolute || count.IsNegative() {
+		return 0, ErrInvalidOperand(operand)
+	}
+	return int(count.ToUint32()), nil
+}
+
+// Returns bytes of a C'...', X'...' or numeric BYTE constant
+func GetByteConstant(operand string) ([]byte, error) {
+	if len(operand) >= 3 && operand[1] == '\'' && strings.HasSuffix(operand, "'") {
+		value := operand[2 : len(operand)-1]
+		switch operand[0] {
+		case 'C', 'c':
+			return []byte(value), nil
+		case 'X', 'x':
+			byteConstant, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, ErrInvalidOperand(operand)
+			}
+			return byteConstant, nil
+		}
+		return nil, ErrInvalidOperand(operand)
+	}
+
+	value, err := GetAbsoluteOperandAddress(operand)
+	if err != nil {
+		return nil, err
+	}
+	if intValue := value.ToInt32(); intValue < -128 || intValue > 255 {
+		return nil, ErrInvalidOperand(operand)
+	}
+	return []byte{value.Bytes()[2]}, nil
+}
+
+// Returns Float48 encoding of a decimal constant, e.g. 3.14 or -2.5E3
+func GetFloatConstant(operand string) (units.Float48, error) {
+	value, err := strconv.ParseFloat(operand, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) || strings.HasPrefix(operand, "0x") {
+		return units.Float48{}, ErrInvalidOperand(operand)
+	}
+	return units.Float64ToFloat48(value), nil
+}
+
+func getSyntaxNode(line string, lineNumber int, options Options) *SyntaxNode {
 	var syntaxNode SyntaxNode
+	syntaxNode.Source = strings.TrimRight(line, " \t\r")
 	syntaxNode.LineNumber = lineNumber
+	syntaxNode.MnemonicType = MnemonicUnknown
+
+	lexer := newLexer(line)
+	inFirstColumn := !lexer.skipWhitespace()
 
 	// Check for comment
-	commentIndex := strings.Index(line, ".")
-	if commentIndex != -1 {
-		syntaxNode.Comment = strings.TrimSpace(line[commentIndex+1:])
-		line = strings.TrimSpace(line[:commentIndex])
+	if lexer.atComment() {
+		syntaxNode.IsComment = true
+		syntaxNode.Comment = lexer.readComment()
+		return &syntaxNode
+	}
 
-		if len(line) == 0 {
-			syntaxNode.IsComment = true
+	// Check for label
+	word := lexer.readWord()
+	hasLabel := inFirstColumn
+	if !options.Dialect.hasLabelColumn() {
+		hasLabel = GetMnemonic(options.Dialect.mnemonicName(word.Text)) == MnemonicUnknown
+
+		// Indented line without a valid mnemonic - misspelled mnemonic rather than label
+		nextWord := lexer.peekWord()
+		if hasLabel && !inFirstColumn && nextWord.Text != "" && GetMnemonic(options.Dialect.mnemonicName(nextWord.Text)) == MnemonicUnknown {
+			syntaxNode.addError(word.Column, ErrUnknownMnemonic(word.Text))
 			return &syntaxNode
 		}
 	}
+	if hasLabel {
+		syntaxNode.Label = word.Text
+		syntaxNode.LabelColumn = word.Column
 
-	tokens := strings.Fields(line)
-
-	// Check for label
-	if mnemonicType := GetMnemonic(MnemonicName(tokens[0])); mnemonicType == MnemonicUnknown {
-		// First token is label
-		syntaxNode.Label = tokens[0]
-		tokens = tokens[1:]
+		lexer.skipWhitespace()
+		if lexer.atEnd() || lexer.atComment() {
+			syntaxNode.addError(syntaxNode.LabelColumn, ErrLabelWithoutMnemonic(syntaxNode.Label))
+			return &syntaxNode
+		}
+		word = lexer.readWord()
 	}
 
 	// Get mnemonic
-	mnemonicType := GetMnemonic(MnemonicName(tokens[0]))
+	mnemonicName := options.Dialect.mnemonicName(word.Text)
+	mnemonicType := GetMnemonic(mnemonicName)
 	if mnemonicType == MnemonicUnknown {
-		ErrLabelWithoutMnemonic(syntaxNode.Label)
-		return nil
+		syntaxNode.addError(word.Column, ErrUnknownMnemonic(word.Text))
+		return &syntaxNode
 	}
-	syntaxNode.Mnemonic = MnemonicName(tokens[0])
+	syntaxNode.Mnemonic = mnemonicName
 	syntaxNode.MnemonicType = mnemonicType
-	tokens = tokens[1:]
+	syntaxNode.MnemonicColumn = word.Column
 
 	// Get operands
-	syntaxNode.Operands = tokens
+	if hasOperands(syntaxNode) {
+		for _, operand := range lexer.readOperands(syntaxNode.Mnemonic == FLOT) {
+			syntaxNode.Operands = append(syntaxNode.Operands, operand.Text)
+			syntaxNode.OperandColumns = append(syntaxNode.OperandColumns, operand.Column)
+		}
+		normalizeRegisterOperands(&syntaxNode, options.Dialect)
+	}
+
+	// Rest of the line is a comment
+	lexer.skipWhitespace()
+	if !lexer.atEnd() {
+		syntaxNode.Comment = lexer.readComment()
+	}
 
 	return &syntaxNode
 }
 
+func hasOperands(syntaxNode SyntaxNode) bool {
+	switch syntaxNode.MnemonicType {
+	case MnemonicF1, MnemonicF3:
+		return false
+	case MnemonicDirective:
+		return syntaxNode.Mnemonic == BASE
+	}
+	return true
+}
+
+// Register operands and the index register, in the dialect's spelling
+func normalizeRegisterOperands(syntaxNode *SyntaxNode, dialect Dialect) {
+	switch syntaxNode.MnemonicType {
+	case MnemonicF2R, MnemonicF2RN, MnemonicF2RR:
+		syntaxNode.Operands[0] = dialect.registerName(syntaxNode.Operands[0])
+		if syntaxNode.MnemonicType == MnemonicF2RR && len(syntaxNode.Operands) > 1 {
+			syntaxNode.Operands[1] = dialect.registerName(syntaxNode.Operands[1])
+		}
+	case MnemonicF3M, MnemonicF4M:
+		for i := 1; i < len(syntaxNode.Operands); i++ {
+			syntaxNode.Operands[i] = dialect.registerName(syntaxNode.Operands[i])
+		}
+	}
+}
+
+func hasRequiredOperands(syntaxNode SyntaxNode) bool {
+	switch syntaxNode.MnemonicType {
+	case MnemonicF2N, MnemonicF2R, MnemonicF2RN, MnemonicF2RR, MnemonicF3M, MnemonicF4M, MnemonicStorageD, MnemonicStorageN:
+		return len(syntaxNode.Operands) > 0
+	case MnemonicDirectiveN:
+		// START and END operands are optional
+		if syntaxNode.Mnemonic == START || syntaxNode.Mnemonic == END {
+			return true
+		}
+		return len(syntaxNode.Operands) > 0
+	}
+	return true
+}
+
+func getRegisterOperand(syntaxNode *SyntaxNode, index int) base.RegisterId {
+	registerId, err := GetRegisterIdFromMnemonic(syntaxNode.Operands[index])
+	if err != nil {
+		syntaxNode.addError(syntaxNode.operandColumn(index), err)
+	}
+	return registerId
+}
+
 func GetInstructionFromSyntaxNode(syntaxNode SyntaxNode, locationCounter units.Int24) proc.Instruction {
 	instruction := proc.Instruction{}
 
@@ -311,12 +669,46 @@ func GetInstructionFromSyntaxNode(syntaxNode SyntaxNode, locationCounter units.I
 		instruction.Format = proc.InstructionFormat4
 	}
 
-	instruction.Opcode = GetInstructionOpcode(syntaxNode.Mnemonic)
+	// Format 4 mnemonics are prefixed with +
+	instruction.Opcode = GetInstructionOpcode(MnemonicName(strings.TrimPrefix(string(syntaxNode.Mnemonic), "+")))
 
 	return instruction
 }
 
-func GetOperandAddressAddressingModes(operand string, pcFromLocationCounter units.Int24, baseEnabled bool, symbolTable SymbolTable) (units.Int24, proc.AbsoluteAddressingMode, proc.RelativeAddressingMode, proc.IndexAddressingMode) {
+// Returns format 3 displacement of address and its relative addressing mode,
+// or false when it can not be reached
+func GetRelativeAddressing(address units.Int24, absoluteAddressingMode proc.AbsoluteAddressingMode, relocatable bool, pc units.Int24, baseAddress units.Int24, baseEnabled bool) (units.Int24, proc.RelativeAddressingMode, bool) {
+	// Immediate values may be used as they are, relocatable addresses
+	// would need a modification of the 12-bit field
+	if absoluteAddressingMode == proc.ImmediateAbsoluteAddressing && !relocatable {
+		if value := address.ToInt32(); value >= -2048 && value <= 2047 {
+			return address, proc.DirectRelativeAddressing, true
+		}
+	}
+
+	// Try PC-relative addressing
+	if displacement, fits := getDisplacement(address, pc); fits {
+		return displacement, proc.PCRelativeAddressing, true
+	}
+
+	// Try base-relative addressing, displacement is unsigned
+	if baseEnabled {
+		displacement := int(address.ToUint32()) - int(baseAddress.ToUint32())
+		if displacement >= 0 && displacement <= 4095 {
+			return units.IntToInt24(displacement), proc.BaseRelativeAddressing, true
+		}
+	}
+
+	return units.Int24{}, proc.DirectRelativeAddressing, false
+}
+
+// SIC format instructions hold a 15-bit address
+func isSICAddress(address units.Int24) bool {
+	return address.ToUint32() <= 0x7FFF
+}
+
+// Strips addressing mode prefix and index suffix from operand
+func GetOperandAddressingModes(operand string) (string, proc.AbsoluteAddressingMode, proc.IndexAddressingMode) {
 	// Absolute addressing mode
 	var absoluteAddressingMode proc.AbsoluteAddressingMode
 	if strings.HasPrefix(operand, "#") {
@@ -336,90 +728,55 @@ func GetOperandAddressAddressingModes(operand string, pcFromLocationCounter unit
 		operand = operand[:len(operand)-2]
 	}
 
-	// Operand address
-	operandAddress := GetOperandAddress(operand, symbolTable)
+	return operand, absoluteAddressingMode, indexAddressingMode
+}
 
-	// Relative addressing
-	var relativeAddressingMode proc.RelativeAddressingMode
-	if baseEnabled {
-		relativeAddressingMode = proc.BaseRelativeAddressing
-	} else {
-		switch absoluteAddressingMode {
-		case proc.ImmediateAbsoluteAddressing: // signed absolute / pc / base
-			// Try Direct-relative addressing
-			if operandAddress.Compare(units.IntToInt24(-2048)) >= 0 && operandAddress.Compare(units.IntToInt24(2047)) < 0 {
-				relativeAddressingMode = proc.DirectRelativeAddressing
-			} else {
-				// Try PC-relative addressing
-				pcRelativeAddress := operandAddress.Sub(pcFromLocationCounter)
-				if pcRelativeAddress.Compare(units.IntToInt24(-2048)) >= 0 && pcRelativeAddress.Compare(units.IntToInt24(2047)) < 0 {
-					relativeAddressingMode = proc.PCRelativeAddressing
-					operandAddress = pcRelativeAddress
-				} else {
-					relativeAddressingMode = proc.BaseRelativeAddressing
-				}
-			}
-		case proc.IndirectAbsoluteAddressing: // pc, base, absolute
-			// Try PC-relative addressing
-			pcRelativeAddress := operandAddress.Sub(pcFromLocationCounter)
-			if pcRelativeAddress.Compare(units.IntToInt24(-2048)) >= 0 && pcRelativeAddress.Compare(units.IntToInt24(2047)) < 0 {
-				relativeAddressingMode = proc.PCRelativeAddressing
-				operandAddress = pcRelativeAddress
-			} else {
-				relativeAddressingMode = proc.BaseRelativeAddressing
-			}
-		case proc.DirectAbsoluteAddressing: // pc / base / absolute / sic absolute
-			// Try PC-relative addressing
-			pcRelativeAddress := operandAddress.Sub(pcFromLocationCounter)
-			if pcRelativeAddress.Compare(units.IntToInt24(-2048)) >= 0 && pcRelativeAddress.Compare(units.IntToInt24(2047)) < 0 {
-				relativeAddressingMode = proc.PCRelativeAddressing
-				operandAddress = pcRelativeAddress
-			} else {
-				relativeAddressingMode = proc.BaseRelativeAddressing
-			}
-		}
-	}
+// Returns address relative to pc and whether it fits into a format 3 displacement
+func getDisplacement(address units.Int24, pc units.Int24) (units.Int24, bool) {
+	displacement := int(address.ToUint32()) - int(pc.ToUint32())
+	return units.IntToInt24(displacement), displacement >= -2048 && displacement <= 2047
+}
 
-	return operandAddress, absoluteAddressingMode, relativeAddressingMode, indexAddressingMode
+// Evaluates operand expression, * being the location counter
+func GetOperandAddress(operand string, locationCounter units.Int24, symbolTable SymbolTable) (units.Int24, SymbolType, error) {
+	return evaluateExpression(operand, locationCounter, symbolTable)
 }
 
-func GetOperandAddress(operand string, symbolTable SymbolTable) units.Int24 {
-	var operandAddress units.Int24
-	// Check for relative symbol - label
-	labelOperand, ok := symbolTable[operand]
-	if ok {
-		operandAddress = labelOperand.Address
-	} else {
-		// Check for absolute symbol
-		operandAddress = GetAbsoluteOperandAddress(operand)
+func GetAbsoluteOperandAddress(operand string) (units.Int24, error) {
+	intOperand, err := parseNumber(operand)
+	if err != nil || intOperand < -0x800000 || intOperand > 0xFFFFFF {
+		return units.Int24{}, ErrInvalidOperand(operand)
 	}
 
-	return operandAddress
+	return units.IntToInt24(intOperand), nil
 }
 
-func GetAbsoluteOperandAddress(operand string) units.Int24 {
-	var operandAddress units.Int24
-	// Check for absolute symbol - dec number
-	intOperand, err := strconv.Atoi(operand)
-	if err == nil {
-		operandAddress = units.IntToInt24(intOperand)
-	}
-	// Check for absolute symbol - hex number
-	if strings.HasPrefix(operand, "0x") {
-		operand = operand[2:]
-		intOperand, err := strconv.ParseUint(operand, 16, 8)
-		if err == nil {
-			operandAddress = units.IntToInt24(int(intOperand))
-		}
+func isSymbolName(operand string) bool {
+	if len(operand) == 0 {
+		return false
 	}
-	//Check for absolute symbol - bin number
-	if strings.HasPrefix(operand, "0b") {
-		operand = operand[2:]
-		intOperand, err := strconv.ParseUint(operand, 2, 8)
-		if err == nil {
-			operandAddress = units.IntToInt24(int(intOperand))
+	first := operand[0]
+	return first == '_' || (first >= 'A' && first <= 'Z') || (first >= 'a' && first <= 'z')
+}
+
+func ParseSymbolType(name string) (SymbolType, error) {
+	for _, symbolType := range []SymbolType{SymbolRelative, SymbolAbsolute} {
+		if name == symbolType.String() {
+			return symbolType, nil
 		}
 	}
+	return SymbolRelative, ErrUnknownSymbolType(name)
+}
 
-	return operandAddress
+/*
+STRINGS
+*/
+func (symbolType SymbolType) String() string {
+	switch symbolType {
+	case SymbolRelative:
+		return "Relative"
+	case SymbolAbsolute:
+		return "Absolute"
+	}
+	return "Not implemented"
 }
@@ -0,0 +1,64 @@
+package assembly
+
+import (
+	"strings"
+)
+
+/*
+DEFINITIONS
+*/
+type Dialect int
+
+const (
+	DialectSicSimGo Dialect = iota
+	DialectSicTools
+)
+
+var Dialects = []Dialect{DialectSicSimGo, DialectSicTools}
+
+/*
+OPERATIONS
+*/
+func ParseDialect(name string) (Dialect, error) {
+	for _, dialect := range Dialects {
+		if strings.EqualFold(name, dialect.String()) {
+			return dialect, nil
+		}
+	}
+	return DialectSicSimGo, ErrUnknownDialect(name)
+}
+
+// SicTools labels must start in the first column, any indented word is a mnemonic
+func (dialect Dialect) hasLabelColumn() bool {
+	return dialect == DialectSicTools
+}
+
+// SicTools mnemonics and register names are case insensitive
+func (dialect Dialect) mnemonicName(word string) MnemonicName {
+	if dialect == DialectSicTools {
+		return MnemonicName(strings.ToUpper(word))
+	}
+	return MnemonicName(word)
+}
+
+func (dialect Dialect) registerName(operand string) string {
+	if dialect == DialectSicTools {
+		if _, err := GetRegisterIdFromMnemonic(strings.ToUpper(operand)); err == nil {
+			return strings.ToUpper(operand)
+		}
+	}
+	return operand
+}
+
+/*
+STRINGS
+*/
+func (dialect Dialect) String() string {
+	switch dialect {
+	case DialectSicSimGo:
+		return "sicsimgo"
+	case DialectSicTools:
+		return "sictools"
+	}
+	return "Not implemented"
+}
@@ -2,8 +2,122 @@ package assembly
 
 import (
 	"fmt"
+	"sicsimgo/core/proc"
 )
 
+/*
+DEFINITIONS
+*/
+type AssemblyError struct {
+	LineNumber int
+	Column     int
+	Message    string
+}
+
+/*
+ERRORS
+*/
 func ErrLabelWithoutMnemonic(label string) error {
 	return fmt.Errorf("Label without mnemonic: %s", label)
 }
+
+func ErrUnknownMnemonic(mnemonic string) error {
+	return fmt.Errorf("Unknown mnemonic: %s", mnemonic)
+}
+
+func ErrDuplicateSymbol(symbol string) error {
+	return fmt.Errorf("Duplicate symbol: %s", symbol)
+}
+
+func ErrUndefinedSymbol(symbol string) error {
+	return fmt.Errorf("Undefined symbol: %s", symbol)
+}
+
+func ErrInvalidOperand(operand string) error {
+	return fmt.Errorf("Invalid operand: %s", operand)
+}
+
+func ErrInvalidExpression(expression string) error {
+	return fmt.Errorf("Invalid expression: %s", expression)
+}
+
+func ErrMissingOperand(mnemonic MnemonicName) error {
+	return fmt.Errorf("Missing operand for %s", mnemonic)
+}
+
+func ErrInvalidRegister(register string) error {
+	return fmt.Errorf("Invalid register: %s", register)
+}
+
+func ErrAddressOutOfRange(operand string, format proc.InstructionFormat) error {
+	return fmt.Errorf("Address out of range for %s: %s", format, operand)
+}
+
+func ErrNotInSICMode(mnemonic MnemonicName) error {
+	return fmt.Errorf("%s is not available in SIC mode", mnemonic)
+}
+
+func ErrAddressingNotInSICMode(operand string) error {
+	return fmt.Errorf("Immediate and indirect addressing are not available in SIC mode: %s", operand)
+}
+
+func ErrUnknownSymbolType(symbolType string) error {
+	return fmt.Errorf("Unknown symbol type: %s", symbolType)
+}
+
+func ErrUnknownDialect(dialect string) error {
+	return fmt.Errorf("Unknown dialect: %s", dialect)
+}
+
+/*
+WARNINGS
+*/
+func WarnExtendedToFormat4(mnemonic MnemonicName) error {
+	return fmt.Errorf("%s extended to format 4, address out of range for format 3", mnemonic)
+}
+
+func WarnExtendedToSIC(mnemonic MnemonicName) error {
+	return fmt.Errorf("%s encoded in SIC format, address out of range for format 3", mnemonic)
+}
+
+/*
+OPERATIONS
+*/
+func (syntaxNode *SyntaxNode) addError(column int, err error) {
+	syntaxNode.Errors = append(syntaxNode.Errors, AssemblyError{
+		LineNumber: syntaxNode.LineNumber,
+		Column:     column,
+		Message:    err.Error(),
+	})
+}
+
+func (syntaxNode *SyntaxNode) addWarning(column int, err error) {
+	syntaxNode.Warnings = append(syntaxNode.Warnings, AssemblyError{
+		LineNumber: syntaxNode.LineNumber,
+		Column:     column,
+		Message:    err.Error(),
+	})
+}
+
+func GetErrors(syntaxNodes []SyntaxNode) []AssemblyError {
+	var assemblyErrors []AssemblyError
+	for _, syntaxNode := range syntaxNodes {
+		assemblyErrors = append(assemblyErrors, syntaxNode.Errors...)
+	}
+	return assemblyErrors
+}
+
+func GetWarnings(syntaxNodes []SyntaxNode) []AssemblyError {
+	var assemblyWarnings []AssemblyError
+	for _, syntaxNode := range syntaxNodes {
+		assemblyWarnings = append(assemblyWarnings, syntaxNode.Warnings...)
+	}
+	return assemblyWarnings
+}
+
+/*
+STRINGS
+*/
+func (assemblyError AssemblyError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", assemblyError.LineNumber, assemblyError.Column, assemblyError.Message)
+}
@@ -0,0 +1,196 @@
+package assembly
+
+import (
+	"sicsimgo/core/units"
+	"strconv"
+	"strings"
+)
+
+/*
+DEFINITIONS
+*/
+type expressionParser struct {
+	expression      string
+	position        int
+	locationCounter units.Int24
+	symbolTable     SymbolTable
+}
+
+// Value of (sub)expression and the number of relative terms in it:
+// 0 for absolute, 1 for relative values
+type expressionValue struct {
+	value         int
+	relativeTerms int
+}
+
+/*
+OPERATIONS
+*/
+// Evaluates expression of numbers, symbols and * (location counter)
+// combined with + - * / and parentheses
+func evaluateExpression(expression string, locationCounter units.Int24, symbolTable SymbolTable) (units.Int24, SymbolType, error) {
+	parser := expressionParser{
+		expression:      expression,
+		locationCounter: locationCounter,
+		symbolTable:     symbolTable,
+	}
+
+	result, err := parser.parseExpression()
+	if err != nil {
+		return units.Int24{}, SymbolAbsolute, err
+	}
+	if parser.position < len(expression) {
+		return units.Int24{}, SymbolAbsolute, ErrInvalidOperand(expression)
+	}
+	if result.value < -0x800000 || result.value > 0xFFFFFF {
+		return units.Int24{}, SymbolAbsolute, ErrInvalidOperand(expression)
+	}
+
+	switch result.relativeTerms {
+	case 0:
+		return units.IntToInt24(result.value), SymbolAbsolute, nil
+	case 1:
+		return units.IntToInt24(result.value), SymbolRelative, nil
+	}
+	return units.Int24{}, SymbolAbsolute, ErrInvalidExpression(expression)
+}
+
+func (parser *expressionParser) parseExpression() (expressionValue, error) {
+	left, err := parser.parseTerm()
+	if err != nil {
+		return left, err
+	}
+
+	for parser.accept('+') || parser.accept('-') {
+		operator := parser.expression[parser.position-1]
+		right, err := parser.parseTerm()
+		if err != nil {
+			return left, err
+		}
+		if operator == '+' {
+			left.value += right.value
+			left.relativeTerms += right.relativeTerms
+		} else {
+			left.value -= right.value
+			left.relativeTerms -= right.relativeTerms
+		}
+	}
+
+	return left, nil
+}
+
+func (parser *expressionParser) parseTerm() (expressionValue, error) {
+	left, err := parser.parseFactor()
+	if err != nil {
+		return left, err
+	}
+
+	for parser.accept('*') || parser.accept('/') {
+		operator := parser.expression[parser.position-1]
+		right, err := parser.parseFactor()
+		if err != nil {
+			return left, err
+		}
+
+		// Relative values can only be added and subtracted
+		if left.relativeTerms != 0 || right.relativeTerms != 0 {
+			return left, ErrInvalidExpression(parser.expression)
+		}
+		if operator == '*' {
+			left.value *= right.value
+		} else {
+			if right.value == 0 {
+				return left, ErrInvalidExpression(parser.expression)
+			}
+			left.value /= right.value
+		}
+	}
+
+	return left, nil
+}
+
+func (parser *expressionParser) parseFactor() (expressionValue, error) {
+	switch {
+	case parser.accept('+'):
+		return parser.parseFactor()
+	case parser.accept('-'):
+		factor, err := parser.parseFactor()
+		factor.value = -factor.value
+		factor.relativeTerms = -factor.relativeTerms
+		return factor, err
+	case parser.accept('('):
+		factor, err := parser.parseExpression()
+		if err == nil && !parser.accept(')') {
+			err = ErrInvalidOperand(parser.expression)
+		}
+		return factor, err
+	case parser.accept('*'):
+		return expressionValue{value: int(parser.locationCounter.ToUint32()), relativeTerms: 1}, nil
+	}
+
+	name := parser.readName()
+	if isSymbolName(name) {
+		symbol, exists := parser.symbolTable[name]
+		if !exists {
+			return expressionValue{}, ErrUndefinedSymbol(name)
+		}
+		factor := expressionValue{value: int(symbol.Address.ToUint32())}
+		if symbol.Type == SymbolRelative {
+			factor.relativeTerms = 1
+		} else {
+			factor.value = int(symbol.Address.ToInt32())
+		}
+		return factor, nil
+	}
+
+	value, err := parseNumber(name)
+	if err != nil {
+		return expressionValue{}, ErrInvalidOperand(parser.expression)
+	}
+	return expressionValue{value: value}, nil
+}
+
+func (parser *expressionParser) accept(c byte) bool {
+	if parser.position < len(parser.expression) && parser.expression[parser.position] == c {
+		parser.position++
+		return true
+	}
+	return false
+}
+
+func (parser *expressionParser) readName() string {
+	start := parser.position
+	for parser.position < len(parser.expression) && strings.IndexByte("+-*/()", parser.expression[parser.position]) == -1 {
+		parser.position++
+	}
+	return parser.expression[start:parser.position]
+}
+
+// Returns names of all symbols used in expression
+func getExpressionSymbols(expression string) []string {
+	var symbols []string
+	for _, name := range strings.FieldsFunc(expression, func(r rune) bool {
+		return strings.ContainsRune("+-*/()", r)
+	}) {
+		if isSymbolName(name) {
+			symbols = append(symbols, name)
+		}
+	}
+	return symbols
+}
+
+// Parses decimal, 0x hexadecimal or 0b binary number
+func parseNumber(number string) (int, error) {
+	var value int64
+	var err error
+
+	if strings.HasPrefix(number, "0x") {
+		value, err = strconv.ParseInt(number[2:], 16, 32)
+	} else if strings.HasPrefix(number, "0b") {
+		value, err = strconv.ParseInt(number[2:], 2, 32)
+	} else {
+		value, err = strconv.ParseInt(number, 10, 32)
+	}
+
+	return int(value), err
+}
@@ -0,0 +1,199 @@
+package assembly
+
+import (
+	"strings"
+)
+
+/*
+DEFINITIONS
+*/
+type lexer struct {
+	line     string
+	position int
+}
+
+type token struct {
+	Text   string
+	Column int
+}
+
+const operandOperators = "+-*/"
+const operandPrefixes = "#@=+-("
+
+/*
+OPERATIONS
+*/
+func newLexer(line string) *lexer {
+	return &lexer{line: line}
+}
+
+func (lexer *lexer) atEnd() bool {
+	return lexer.position >= len(lexer.line)
+}
+
+func (lexer *lexer) peek() byte {
+	return lexer.line[lexer.position]
+}
+
+// Skips whitespace and reports whether there was any
+func (lexer *lexer) skipWhitespace() bool {
+	start := lexer.position
+	for !lexer.atEnd() && isWhitespace(lexer.peek()) {
+		lexer.position++
+	}
+	return lexer.position > start
+}
+
+// Comments start with . at the beginning of a field
+func (lexer *lexer) atComment() bool {
+	return !lexer.atEnd() && lexer.peek() == '.'
+}
+
+// Returns rest of the line without the comment marker
+func (lexer *lexer) readComment() string {
+	comment := strings.TrimSpace(lexer.line[lexer.position:])
+	lexer.position = len(lexer.line)
+	return strings.TrimSpace(strings.TrimPrefix(comment, "."))
+}
+
+// Reads whitespace delimited word - label or mnemonic
+func (lexer *lexer) readWord() token {
+	start := lexer.position
+	for !lexer.atEnd() && !isWhitespace(lexer.peek()) {
+		lexer.position++
+	}
+	return token{Text: lexer.line[start:lexer.position], Column: start + 1}
+}
+
+// Returns next word without consuming it, empty at end of line or comment
+func (lexer *lexer) peekWord() token {
+	position := lexer.position
+	defer func() { lexer.position = position }()
+
+	lexer.skipWhitespace()
+	if lexer.atEnd() || lexer.atComment() {
+		return token{}
+	}
+	return lexer.readWord()
+}
+
+// Reads comma separated operands with whitespace removed. Whitespace is
+// allowed around operators and commas, any other text after the operands
+// is a comment. Float operands may start with a dot, e.g. .5 is not a
+// comment.
+func (lexer *lexer) readOperands(floatOperands bool) []token {
+	var operands []token
+	var operand strings.Builder
+	operandColumn := 0
+	expectTerm := true
+
+	for done := false; !done; {
+		skipped := lexer.skipWhitespace()
+		if lexer.atEnd() {
+			break
+		}
+		c := lexer.peek()
+
+		if c == ',' {
+			operands = append(operands, token{Text: operand.String(), Column: max(operandColumn, lexer.position+1)})
+			operand.Reset()
+			operandColumn = 0
+			expectTerm = true
+			lexer.position++
+			continue
+		}
+
+		if expectTerm {
+			if skipped && c == '.' && !(floatOperands && lexer.atDigit(lexer.position+1)) {
+				break
+			}
+			if operandColumn == 0 {
+				operandColumn = lexer.position + 1
+			}
+			switch {
+			case lexer.atQuotedLiteral():
+				operand.WriteString(lexer.readQuotedLiteral())
+				expectTerm = false
+			case c == '*':
+				// Location counter
+				operand.WriteByte(c)
+				lexer.position++
+				expectTerm = false
+			case strings.IndexByte(operandPrefixes, c) != -1:
+				operand.WriteByte(c)
+				lexer.position++
+			default:
+				operand.WriteString(lexer.readTerm())
+				expectTerm = false
+			}
+			continue
+		}
+
+		switch {
+		case strings.IndexByte(operandOperators, c) != -1:
+			operand.WriteByte(c)
+			lexer.position++
+			expectTerm = true
+		case c == ')':
+			operand.WriteByte(c)
+			lexer.position++
+		case skipped:
+			// Text after operands is a comment
+			done = true
+		default:
+			// Malformed operand, kept whole for diagnostics
+			operand.WriteString(lexer.readTerm())
+		}
+	}
+
+	if operandColumn != 0 {
+		operands = append(operands, token{Text: operand.String(), Column: operandColumn})
+	}
+
+	return operands
+}
+
+// C'...' and X'...' are read verbatim, including whitespace and dots
+func (lexer *lexer) atQuotedLiteral() bool {
+	if lexer.position+1 >= len(lexer.line) || lexer.line[lexer.position+1] != '\'' {
+		return false
+	}
+	switch lexer.peek() {
+	case 'C', 'c', 'X', 'x':
+		return true
+	}
+	return false
+}
+
+func (lexer *lexer) readQuotedLiteral() string {
+	start := lexer.position
+	end := strings.IndexByte(lexer.line[start+2:], '\'')
+	if end == -1 {
+		lexer.position = len(lexer.line)
+	} else {
+		lexer.position = start + 2 + end + 1
+	}
+	return lexer.line[start:lexer.position]
+}
+
+// Reads symbol or number, or at least one character of anything else
+func (lexer *lexer) readTerm() string {
+	start := lexer.position
+	lexer.position++
+	for !lexer.atEnd() {
+		c := lexer.peek()
+		if isWhitespace(c) || c == ',' || c == '(' || c == ')' || strings.IndexByte(operandOperators, c) != -1 {
+			break
+		}
+		lexer.position++
+	}
+	return lexer.line[start:lexer.position]
+}
+
+func (lexer *lexer) atDigit(position int) bool {
+	return position < len(lexer.line) && lexer.line[position] >= '0' && lexer.line[position] <= '9'
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
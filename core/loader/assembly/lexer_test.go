@@ -0,0 +1,105 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetSyntaxNode(t *testing.T) {
+	tests := []struct {
+		name             string
+		line             string
+		dialect          Dialect
+		expectedLabel    string
+		expectedMnemonic MnemonicName
+		expectedOperands []string
+		expectedComment  string
+	}{
+		{
+			name:             "Label, index and comment",
+			line:             "LOOP  STCH  BUFFER,X  . store",
+			dialect:          DialectSicSimGo,
+			expectedLabel:    "LOOP",
+			expectedMnemonic: STCH,
+			expectedOperands: []string{"BUFFER", "X"},
+			expectedComment:  "store",
+		},
+		{
+			name:             "Dot inside character constant",
+			line:             "STR\tBYTE\tC'a.b c'\t. text",
+			dialect:          DialectSicSimGo,
+			expectedLabel:    "STR",
+			expectedMnemonic: BYTE,
+			expectedOperands: []string{"C'a.b c'"},
+			expectedComment:  "text",
+		},
+		{
+			name:             "Expression with spaces",
+			line:             "LEN EQU * - BUF + 3",
+			dialect:          DialectSicSimGo,
+			expectedLabel:    "LEN",
+			expectedMnemonic: EQU,
+			expectedOperands: []string{"*-BUF+3"},
+		},
+		{
+			name:             "Lowercase format 4 immediate",
+			line:             "\t+ldt\t#max - 1",
+			dialect:          DialectSicTools,
+			expectedMnemonic: "+LDT",
+			expectedOperands: []string{"#max-1"},
+		},
+		{
+			name:             "Lowercase registers",
+			line:             "first\tcompr a, x\tcomment without dot",
+			dialect:          DialectSicTools,
+			expectedLabel:    "first",
+			expectedMnemonic: COMPR,
+			expectedOperands: []string{"A", "X"},
+			expectedComment:  "comment without dot",
+		},
+		{
+			name:             "Float constant without leading zero",
+			line:             "HALF  FLOT  .5  . one half",
+			dialect:          DialectSicSimGo,
+			expectedLabel:    "HALF",
+			expectedMnemonic: FLOT,
+			expectedOperands: []string{".5"},
+			expectedComment:  "one half",
+		},
+		{
+			name:             "Comment starting with a digit",
+			line:             "\tLDA\t.5 times",
+			dialect:          DialectSicSimGo,
+			expectedMnemonic: LDA,
+			expectedComment:  "5 times",
+		},
+		{
+			name:             "Operands of instruction without operands",
+			line:             "\tRSUB\treturn",
+			dialect:          DialectSicTools,
+			expectedMnemonic: RSUB,
+			expectedComment:  "return",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syntaxNode := getSyntaxNode(tt.line, 1, Options{Dialect: tt.dialect})
+			if len(syntaxNode.Errors) > 0 {
+				t.Fatalf("getSyntaxNode() errors = %v", syntaxNode.Errors)
+			}
+			if syntaxNode.Label != tt.expectedLabel {
+				t.Errorf("Label = %q, want %q", syntaxNode.Label, tt.expectedLabel)
+			}
+			if syntaxNode.Mnemonic != tt.expectedMnemonic {
+				t.Errorf("Mnemonic = %q, want %q", syntaxNode.Mnemonic, tt.expectedMnemonic)
+			}
+			if !reflect.DeepEqual(syntaxNode.Operands, tt.expectedOperands) {
+				t.Errorf("Operands = %q, want %q", syntaxNode.Operands, tt.expectedOperands)
+			}
+			if syntaxNode.Comment != tt.expectedComment {
+				t.Errorf("Comment = %q, want %q", syntaxNode.Comment, tt.expectedComment)
+			}
+		})
+	}
+}
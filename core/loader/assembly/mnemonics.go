@@ -17,6 +17,10 @@ const (
 	LTORG  MnemonicName = "LTORG"
 )
 
+const (
+	SIC MnemonicName = "SIC"
+)
+
 const (
 	START MnemonicName = "START"
 	END   MnemonicName = "END"
@@ -107,11 +111,13 @@ const (
 const (
 	BYTE MnemonicName = "BYTE"
 	WORD MnemonicName = "WORD"
+	FLOT MnemonicName = "FLOT"
 )
 
 const (
 	RESB MnemonicName = "RESB"
 	RESW MnemonicName = "RESW"
+	RESF MnemonicName = "RESF"
 )
 
 type MnemonicType int
@@ -138,6 +144,8 @@ var Mnemonics = map[MnemonicName]MnemonicType{
 	NOBASE: MnemonicDirective,
 	LTORG:  MnemonicDirective,
 
+	SIC: MnemonicDirective,
+
 	START: MnemonicDirectiveN,
 	END:   MnemonicDirectiveN,
 	ORG:   MnemonicDirectiveN,
@@ -211,9 +219,11 @@ var Mnemonics = map[MnemonicName]MnemonicType{
 
 	RESB: MnemonicStorageD,
 	RESW: MnemonicStorageD,
+	RESF: MnemonicStorageD,
 
 	BYTE: MnemonicStorageN,
 	WORD: MnemonicStorageN,
+	FLOT: MnemonicStorageN,
 }
 
 /*
@@ -244,6 +254,15 @@ func IsMnemonicInstruction(mnemonicType MnemonicType) bool {
 	return false
 }
 
+// Instructions of the original SIC machine
+func IsSICMnemonic(mnemonic MnemonicName) bool {
+	switch mnemonic {
+	case ADD, AND, COMP, DIV, J, JEQ, JGT, JLT, JSUB, LDA, LDCH, LDL, LDX, MUL, OR, RD, RSUB, STA, STCH, STL, STSW, STX, SUB, TD, TIX, WD:
+		return true
+	}
+	return false
+}
+
 func GetInstructionOpcode(mnemonic MnemonicName) proc.Opcode {
 	switch mnemonic {
 	case ADD:
@@ -369,27 +388,27 @@ func GetInstructionOpcode(mnemonic MnemonicName) proc.Opcode {
 	return proc.Opcode(0x00)
 }
 
-func GetRegisterIdFromMnemonic(operand string) base.RegisterId {
+func GetRegisterIdFromMnemonic(operand string) (base.RegisterId, error) {
 	switch operand {
 	case "A":
-		return base.RegisterAId
+		return base.RegisterAId, nil
 	case "X":
-		return base.RegisterXId
+		return base.RegisterXId, nil
 	case "L":
-		return base.RegisterLId
+		return base.RegisterLId, nil
 	case "B":
-		return base.RegisterBId
+		return base.RegisterBId, nil
 	case "S":
-		return base.RegisterSID
+		return base.RegisterSID, nil
 	case "T":
-		return base.RegisterTId
+		return base.RegisterTId, nil
 	case "F":
-		return base.RegisterFId
+		return base.RegisterFId, nil
 	case "PC":
-		return base.RegisterPCId
+		return base.RegisterPCId, nil
 	case "SW":
-		return base.RegisterSWId
+		return base.RegisterSWId, nil
 	}
 
-	return base.RegisterId(0x00)
+	return base.RegisterId(0x00), ErrInvalidRegister(operand)
 }
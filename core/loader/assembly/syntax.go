@@ -3,8 +3,14 @@ package assembly
 import (
 	"fmt"
 	"sicsimgo/core/units"
+	"strings"
 )
 
+type Modification struct {
+	Address   units.Int24
+	HalfBytes int
+}
+
 type SyntaxNode struct {
 	Label        string
 	Mnemonic     MnemonicName
@@ -14,8 +20,32 @@ type SyntaxNode struct {
 	IsComment bool
 	Comment   string
 
+	Source          string
 	LineNumber      int
 	LocationCounter units.Int24
+	Size            int
+
+	ObjectCode    []byte
+	Modifications []Modification
+
+	LabelColumn    int
+	MnemonicColumn int
+	OperandColumns []int
+
+	Errors   []AssemblyError
+	Warnings []AssemblyError
+}
+
+func (syntaxNode SyntaxNode) operandColumn(index int) int {
+	if index < len(syntaxNode.OperandColumns) {
+		return syntaxNode.OperandColumns[index]
+	}
+	return syntaxNode.MnemonicColumn
+}
+
+// Returns address operand with index register as written, e.g. BUFFER,X
+func (syntaxNode SyntaxNode) addressOperand() string {
+	return strings.Join(syntaxNode.Operands, ",")
 }
 
 func (syntaxNode SyntaxNode) String() string {
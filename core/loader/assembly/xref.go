@@ -0,0 +1,156 @@
+package assembly
+
+import (
+	"fmt"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+	"sort"
+	"strings"
+)
+
+/*
+DEFINITIONS
+*/
+type ReferenceKind int
+
+type SymbolReference struct {
+	LineNumber int
+	Address    units.Int24
+	Kind       ReferenceKind
+}
+
+type CrossReference struct {
+	Symbol         Symbol
+	DefinitionLine int
+	References     []SymbolReference
+}
+
+const (
+	ReferenceRead      ReferenceKind = 0
+	ReferenceWrite     ReferenceKind = 1
+	ReferenceJump      ReferenceKind = 2
+	ReferenceImmediate ReferenceKind = 3
+	ReferenceDirective ReferenceKind = 4
+)
+
+/*
+OPERATIONS
+*/
+// Returns cross-references of all symbols, sorted by symbol name
+func GetCrossReferences(syntaxNodes []SyntaxNode, symbolTable SymbolTable) []CrossReference {
+	crossReferenceIndexes := make(map[string]int)
+	crossReferences := make([]CrossReference, 0, len(symbolTable))
+	for name, symbol := range symbolTable {
+		crossReferenceIndexes[name] = len(crossReferences)
+		crossReferences = append(crossReferences, CrossReference{Symbol: symbol})
+	}
+
+	for _, syntaxNode := range syntaxNodes {
+		if syntaxNode.IsComment || syntaxNode.Mnemonic == "" {
+			continue
+		}
+
+		if idx, exists := crossReferenceIndexes[syntaxNode.Label]; exists && crossReferences[idx].DefinitionLine == 0 {
+			crossReferences[idx].DefinitionLine = syntaxNode.LineNumber
+		}
+
+		for _, operand := range getReferencingOperands(syntaxNode) {
+			expression, absoluteAddressingMode, _ := GetOperandAddressingModes(operand)
+			for _, symbolName := range getExpressionSymbols(expression) {
+				idx, exists := crossReferenceIndexes[symbolName]
+				if !exists {
+					continue
+				}
+				crossReferences[idx].References = append(crossReferences[idx].References, SymbolReference{
+					LineNumber: syntaxNode.LineNumber,
+					Address:    syntaxNode.LocationCounter,
+					Kind:       getReferenceKind(syntaxNode, absoluteAddressingMode),
+				})
+			}
+		}
+	}
+
+	sort.Slice(crossReferences, func(i, j int) bool {
+		return crossReferences[i].Symbol.Name < crossReferences[j].Symbol.Name
+	})
+
+	return crossReferences
+}
+
+// Operands which may contain symbols, register operands never do
+func getReferencingOperands(syntaxNode SyntaxNode) []string {
+	switch syntaxNode.MnemonicType {
+	case MnemonicF2N, MnemonicF2R, MnemonicF2RN, MnemonicF2RR:
+		return nil
+	case MnemonicF3M, MnemonicF4M:
+		return []string{syntaxNode.addressOperand()}
+	}
+	return syntaxNode.Operands
+}
+
+func getReferenceKind(syntaxNode SyntaxNode, absoluteAddressingMode proc.AbsoluteAddressingMode) ReferenceKind {
+	// Directives use the symbol's value, END names the entry point
+	if !IsMnemonicInstruction(syntaxNode.MnemonicType) {
+		if syntaxNode.Mnemonic == END {
+			return ReferenceJump
+		}
+		return ReferenceDirective
+	}
+
+	switch absoluteAddressingMode {
+	case proc.ImmediateAbsoluteAddressing:
+		return ReferenceImmediate
+	case proc.IndirectAbsoluteAddressing:
+		// Symbol holds the target address, it is only read
+		return ReferenceRead
+	}
+
+	instruction := proc.Instruction{Opcode: GetInstructionOpcode(MnemonicName(strings.TrimPrefix(string(syntaxNode.Mnemonic), "+")))}
+	if instruction.IsJumpInstruction() {
+		return ReferenceJump
+	}
+	if instruction.IsStoreInstruction() {
+		return ReferenceWrite
+	}
+	return ReferenceRead
+}
+
+/*
+STRINGS
+*/
+func (referenceKind ReferenceKind) String() string {
+	switch referenceKind {
+	case ReferenceRead:
+		return "Read"
+	case ReferenceWrite:
+		return "Write"
+	case ReferenceJump:
+		return "Jump"
+	case ReferenceImmediate:
+		return "Immediate"
+	case ReferenceDirective:
+		return "Directive"
+	}
+	return "Not implemented"
+}
+
+// Single letter used in listings and reports
+func (referenceKind ReferenceKind) StringShort() string {
+	switch referenceKind {
+	case ReferenceRead:
+		return "R"
+	case ReferenceWrite:
+		return "W"
+	case ReferenceJump:
+		return "J"
+	case ReferenceImmediate:
+		return "I"
+	case ReferenceDirective:
+		return "D"
+	}
+	return "?"
+}
+
+func (symbolReference SymbolReference) String() string {
+	return fmt.Sprintf("%d%s", symbolReference.LineNumber, symbolReference.Kind.StringShort())
+}
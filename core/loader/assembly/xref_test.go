@@ -0,0 +1,77 @@
+package assembly
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetCrossReferences(t *testing.T) {
+	source := `prog  START 0
+      LDA   num
+      STA   num
+      LDB   #num
+      BASE  num
+      J     @ptr
+loop  JSUB  loop
+ptr   WORD  loop
+num   WORD  7
+size  EQU   *-num
+      END   prog
+`
+	tests := []struct {
+		name     string
+		symbol   string
+		expected string
+	}{
+		{
+			name:     "Read and write",
+			symbol:   "num",
+			expected: "2R 3W 4I 5D 10D",
+		},
+		{
+			name:     "Jump",
+			symbol:   "loop",
+			expected: "7J 8D",
+		},
+		{
+			name:     "Indirect jump reads the pointer",
+			symbol:   "ptr",
+			expected: "6R",
+		},
+		{
+			name:     "Entry point",
+			symbol:   "prog",
+			expected: "11J",
+		},
+		{
+			name:     "Unreferenced",
+			symbol:   "size",
+			expected: "",
+		},
+	}
+
+	_, _, _, symbolTable, syntaxNodes := LoadProgram(strings.NewReader(source), Options{})
+	if assemblyErrors := GetErrors(syntaxNodes); len(assemblyErrors) > 0 {
+		t.Fatalf("assembly errors: %v", assemblyErrors)
+	}
+	crossReferences := GetCrossReferences(syntaxNodes, symbolTable)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, crossReference := range crossReferences {
+				if crossReference.Symbol.Name != tt.symbol {
+					continue
+				}
+				var references []string
+				for _, reference := range crossReference.References {
+					references = append(references, reference.String())
+				}
+				if result := strings.Join(references, " "); result != tt.expected {
+					t.Errorf("references of %s = %q, want %q", tt.symbol, result, tt.expected)
+				}
+				return
+			}
+			t.Errorf("no cross-reference for %s", tt.symbol)
+		})
+	}
+}
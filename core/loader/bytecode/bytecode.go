@@ -3,7 +3,7 @@ package bytecode
 import (
 	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 
@@ -20,19 +20,21 @@ var debugLoadProgram bool = false
 /*
 OPERATIONS
 */
-func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instruction, units.Int24) {
+// Bounds are the addresses given by the header record, with reservations which aren't loaded
+func LoadProgram(file io.Reader) (string, units.Int24, map[units.Int24]proc.Instruction, []Segment, map[string]units.Int24, Segment) {
 	var programName string
-	var codeOffset units.Int24
+	var startAddress units.Int24
 	var disassembly map[units.Int24]proc.Instruction = make(map[units.Int24]proc.Instruction)
-	var lastInstructionByteAddress units.Int24
-
-	var previousTextRecordAddr units.Int24
-	var previousTextrecordCodeLen int
-	var leftoverBytes []byte
+	var segments []Segment
+	var definitions map[string]units.Int24 = make(map[string]units.Int24)
+	var bounds Segment
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		record := scanner.Text()
+		record := strings.TrimSpace(scanner.Text())
+		if len(record) == 0 {
+			continue
+		}
 		if debugLoadProgram {
 			fmt.Println(record)
 		}
@@ -43,75 +45,28 @@ func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instr
 			}
 
 			programName = progName
-			codeOffset = codeAddr
-			leftoverBytes = []byte{}
+			startAddress = codeAddr
+			bounds = Segment{Start: codeAddr, End: units.IntToInt24(int(codeAddr.ToUint32()) + int(codeLen.ToUint32()))}
 		} else if record[0] == 'T' {
 			codeAddress, code := GetTextRecord(record)
 			if debugLoadProgram {
 				fmt.Printf("  Text: %s|% X\n", codeAddress.StringHex(), code)
 			}
 
-			// Update last instruction byte address
-			lastInstructionByteAddress = codeAddress
-			if debugLoadProgram {
-				fmt.Printf("Fixing last instruction byte address - %s: adding %d\n", lastInstructionByteAddress.StringHex(), len(code))
-			}
-			for i := 0; i < len(code)-1; i++ {
-				lastInstructionByteAddress = lastInstructionByteAddress.Add(units.Int24{0x00, 0x00, 0x01})
-			}
-
-			// Memory
+			// Memory - text record addresses are absolute
 			idx := units.Int24{}
 			for i := 0; i < len(code); i++ {
-				base.SetByte(codeAddress.Add(idx.Add(codeOffset)), code[i])
-				idx = idx.Add(units.Int24{0x00, 0x00, 0x01})
+				base.SetByte(codeAddress.Add(idx), code[i])
+				idx = idx.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 			}
 
-			// Handle leftover bytes
-			if len(leftoverBytes) > 0 {
+			segments = addSegment(segments, codeAddress, len(code))
+		} else if record[0] == 'D' {
+			for name, address := range GetDefineRecord(record) {
 				if debugLoadProgram {
-					fmt.Printf("Leftover bytes: % X\n", leftoverBytes)
-				}
-				savePreviousTextRecordAddr := previousTextRecordAddr
-				// Check for text record continuity if leftover bytes exist
-				for i := 0; i < previousTextrecordCodeLen; i++ {
-					previousTextRecordAddr = previousTextRecordAddr.Add(units.Int24{0x00, 0x00, 0x01})
+					fmt.Printf("  Define: %s|%s\n", name, address.StringHex())
 				}
-				if codeAddress.Compare(previousTextRecordAddr) != 0 {
-					if debugLoadProgram {
-						fmt.Printf("codeAddress: %s, previousTextRecordAddr: %s, previousTextrecordCodeLen: %d\n", codeAddress.StringHex(), savePreviousTextRecordAddr.StringHex(), previousTextrecordCodeLen)
-					}
-					// Text records aren't continuing, error
-					panic(fmt.Errorf("Previous T record ended with leftover bytes, but current T record isn't continuing from there onwards!"))
-				}
-
-				// Add leftover bytes
-				newCode := leftoverBytes
-				newCode = append(newCode, code...)
-				code = newCode
-
-				// Fix start address
-				for i := 0; i < len(leftoverBytes); i++ {
-					codeAddress = codeAddress.Sub(units.Int24{0x00, 0x00, 0x01})
-				}
-
-				leftoverBytes = []byte{}
-			}
-
-			// Dissasembly
-			instructions, bytesFromIncompleteInstruction := GetInstructionsFromBinary(codeAddress, code)
-			for address, instruction := range instructions {
-				if debugLoadProgram {
-					fmt.Printf("    Address: %s, Bytes: % X, Format: %s, Opcode: %s, Operand: %s\n", address.StringHex(), instruction.Bytes, instruction.Format.String(), instruction.Opcode.String(), instruction.Operand.StringHex())
-				}
-				disassembly[address] = instruction
-			}
-
-			// Prepare next text record for leftover bytes
-			if len(bytesFromIncompleteInstruction) != 0 {
-				leftoverBytes = bytesFromIncompleteInstruction
-				previousTextRecordAddr = codeAddress
-				previousTextrecordCodeLen = len(code)
+				definitions[name] = address
 			}
 		} else if record[0] == 'E' {
 			endAddress := GetEndRecord(record)
@@ -119,15 +74,29 @@ func LoadProgram(file *os.File) (string, units.Int24, map[units.Int24]proc.Instr
 				fmt.Printf("  End: %s\n", endAddress.StringHex())
 			}
 
-			codeOffset = endAddress.Add(codeOffset)
-			leftoverBytes = []byte{}
+			startAddress = endAddress
 		}
 	}
 
+	// Dissasembly - code is whatever execution can reach from the start
+	FollowCode(disassembly, segments, startAddress)
 	if debugLoadProgram {
-		fmt.Printf("Last instruction byte address: %s\n", lastInstructionByteAddress.StringHex())
+		for _, segment := range segments {
+			fmt.Printf("Segment: %s-%s\n", segment.Start.StringHex(), segment.End.StringHex())
+		}
 	}
-	return programName, codeOffset, disassembly, lastInstructionByteAddress
+
+	return programName, startAddress, disassembly, segments, definitions, bounds
+}
+
+// Adds loaded bytes to segments, joining them with the previous segment when continuing it
+func addSegment(segments []Segment, address units.Int24, length int) []Segment {
+	end := units.IntToInt24(int(address.ToUint32()) + length)
+	if len(segments) > 0 && segments[len(segments)-1].End == address {
+		segments[len(segments)-1].End = end
+		return segments
+	}
+	return append(segments, Segment{Start: address, End: end})
 }
 
 func GetHeaderRecord(record string) (string, units.Int24, units.Int24) {
@@ -152,6 +121,16 @@ func GetTextRecord(record string) (units.Int24, []byte) {
 	return units.StringToInt24(codeAddressStr), code
 }
 
+// D records hold pairs of 6 character names and their addresses
+func GetDefineRecord(record string) map[string]units.Int24 {
+	definitions := make(map[string]units.Int24)
+	for i := 1; i+12 <= len(record); i += 12 {
+		definitions[strings.TrimSpace(record[i:i+6])] = units.StringToInt24(record[i+6 : i+12])
+	}
+
+	return definitions
+}
+
 func GetEndRecord(record string) units.Int24 {
 	startAddressStr := record[1:7]
 
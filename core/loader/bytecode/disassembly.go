@@ -2,10 +2,23 @@ package bytecode
 
 import (
 	"fmt"
+	"sicsimgo/core/base"
 	"sicsimgo/core/proc"
 	"sicsimgo/core/units"
 )
 
+/*
+DEFINITIONS
+*/
+// Memory loaded from text records, End is the first address after it
+type Segment struct {
+	Start units.Int24
+	End   units.Int24
+}
+
+// Data not reached by execution is shown in word sized pieces
+const dataChunkLength = units.WORD_SIZE
+
 /*
 DEBUG
 */
@@ -14,6 +27,130 @@ const debugGetDisassemblyInstructionsFromTextRecord bool = false
 /*
 OPERATIONS
 */
+// Disassembles code reachable from entry address by following jumps and fall-through paths.
+// Instructions on the way replace data and instructions they overlap, paths end at
+// already known instructions. The entry may lie outside segments when found at runtime.
+func FollowCode(disassembly map[units.Int24]proc.Instruction, segments []Segment, entryAddress units.Int24) {
+	addresses := []units.Int24{entryAddress}
+	for len(addresses) > 0 {
+		address := addresses[len(addresses)-1]
+		addresses = addresses[:len(addresses)-1]
+
+		for {
+			if known, exists := disassembly[address]; exists && known.Directive != proc.DirectiveBYTE {
+				break
+			}
+			instruction, decoded := decodeInstruction(address)
+			if !decoded {
+				break
+			}
+			lastByteAddress := units.IntToInt24(int(address.ToUint32()) + len(instruction.Bytes) - 1)
+			if address != entryAddress && (!isLoaded(segments, address) || !isLoaded(segments, lastByteAddress)) {
+				break
+			}
+
+			removeOverlapping(disassembly, instruction)
+			disassembly[address] = instruction
+			if debugGetDisassemblyInstructionsFromTextRecord {
+				fmt.Printf("    Code: %s %s\n", address.StringHex(), instruction.Opcode.String())
+			}
+
+			if target, known := getJumpTarget(instruction); known {
+				addresses = append(addresses, target)
+			}
+			if instruction.Opcode == proc.J || instruction.Opcode == proc.RSUB {
+				break
+			}
+			address = lastByteAddress.Add(units.BytesToInt24(0x00, 0x00, 0x01))
+		}
+	}
+
+	fillData(disassembly, segments)
+}
+
+func decodeInstruction(address units.Int24) (proc.Instruction, bool) {
+	var code []byte
+	for i := 0; i < 4 && address.ToUint32()+uint32(i) <= base.MAX_ADDRESS; i++ {
+		code = append(code, base.GetByte(units.IntToInt24(int(address.ToUint32())+i)))
+	}
+
+	instructions, _ := GetInstructionsFromBinary(address, code)
+	instruction, exists := instructions[address]
+	if !exists || instruction.Directive == proc.DirectiveBYTE {
+		return proc.Instruction{}, false
+	}
+	return instruction, true
+}
+
+// Destination of direct jumps, indirect and indexed ones are only known at runtime
+func getJumpTarget(instruction proc.Instruction) (units.Int24, bool) {
+	if !instruction.IsJumpInstruction() {
+		return units.Int24{}, false
+	}
+	n, i, x, _, _, _ := instruction.GetNIXBPEBits()
+	if x || proc.GetAbsoluteAdressingModes(n, i) == proc.IndirectAbsoluteAddressing {
+		return units.Int24{}, false
+	}
+	return GetStaticAddress(instruction, units.Int24{}, false)
+}
+
+// Removes entries other than the one at instruction's address which share bytes with it
+func removeOverlapping(disassembly map[units.Int24]proc.Instruction, instruction proc.Instruction) {
+	start := int(instruction.InstructionAddress.ToUint32())
+	end := start + len(instruction.Bytes)
+	for address := start - 3; address < end; address++ {
+		if address < 0 || address == start {
+			continue
+		}
+		known, exists := disassembly[units.IntToInt24(address)]
+		if exists && address+len(known.Bytes) > start {
+			delete(disassembly, units.IntToInt24(address))
+		}
+	}
+}
+
+// Bytes of segments not covered by instructions become data
+func fillData(disassembly map[units.Int24]proc.Instruction, segments []Segment) {
+	for address, instruction := range disassembly {
+		if instruction.Directive == proc.DirectiveBYTE && isLoaded(segments, address) {
+			delete(disassembly, address)
+		}
+	}
+
+	for _, segment := range segments {
+		address := segment.Start
+		for address.Compare(segment.End) < 0 {
+			if instruction, exists := disassembly[address]; exists {
+				address = units.IntToInt24(int(address.ToUint32()) + len(instruction.Bytes))
+				continue
+			}
+
+			data := proc.Instruction{
+				InstructionAddress: address,
+				Format:             proc.InstructionUnknown,
+				Directive:          proc.DirectiveBYTE,
+			}
+			for len(data.Bytes) < dataChunkLength && address.Compare(segment.End) < 0 {
+				if _, exists := disassembly[address]; exists {
+					break
+				}
+				data.Bytes = append(data.Bytes, base.GetByte(address))
+				address = address.Add(units.BytesToInt24(0x00, 0x00, 0x01))
+			}
+			disassembly[data.InstructionAddress] = data
+		}
+	}
+}
+
+func isLoaded(segments []Segment, address units.Int24) bool {
+	for _, segment := range segments {
+		if address.Compare(segment.Start) >= 0 && address.Compare(segment.End) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func GetInstructionsFromBinary(codeAddress units.Int24, binaryCode []byte) (map[units.Int24]proc.Instruction, []byte) {
 	disassemblyInstructions := make(map[units.Int24]proc.Instruction)
 
@@ -47,7 +184,7 @@ func GetInstructionsFromBinary(codeAddress units.Int24, binaryCode []byte) (map[
 
 			disassemblyInstructions[instruction.InstructionAddress] = instruction
 			byteIndex += 1
-			relativeAddress = relativeAddress.Add(units.Int24{0x00, 0x00, 0x01})
+			relativeAddress = relativeAddress.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 			continue
 		}
 		instruction.Format = instructionFormatFromOpcode
@@ -103,7 +240,7 @@ func GetInstructionsFromBinary(codeAddress units.Int24, binaryCode []byte) (map[
 			} else if instructionType == proc.InstructionFormat4 {
 				// Check for incomplete instruction
 				if (byteIndex + 3) >= len(binaryCode) {
-					return disassemblyInstructions, []byte{byte1}
+					return disassemblyInstructions, []byte{byte1, byte2, byte3}
 				}
 				byte4 := binaryCode[byteIndex+3]
 
@@ -121,7 +258,7 @@ func GetInstructionsFromBinary(codeAddress units.Int24, binaryCode []byte) (map[
 
 		byteIndex += len(instructionBytes)
 		for i := 0; i < len(instructionBytes); i++ {
-			relativeAddress = relativeAddress.Add(units.Int24{0x00, 0x00, 0x01})
+			relativeAddress = relativeAddress.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 		}
 
 		disassemblyInstructions[instruction.InstructionAddress] = instruction
@@ -0,0 +1,209 @@
+package bytecode
+
+import (
+	"fmt"
+	"sort"
+
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type ReferenceType int
+
+const (
+	ReferenceValue ReferenceType = iota
+	ReferenceCode
+	ReferenceData
+)
+
+// Operand of an instruction resolved without running the program,
+// index register is not added
+type Reference struct {
+	Address units.Int24
+	Type    ReferenceType
+}
+
+/*
+OPERATIONS
+*/
+// Operand address or immediate value of an instruction, base-relative
+// operands are only known together with the base
+func GetStaticAddress(instruction proc.Instruction, baseAddress units.Int24, baseKnown bool) (units.Int24, bool) {
+	if !instruction.IsFormatSIC34() || len(instruction.Bytes) < 3 {
+		return units.Int24{}, false
+	}
+
+	_, _, _, b, p, _ := instruction.GetNIXBPEBits()
+	relativeAddressingMode, err := proc.GetRelativeAdressingModes(b, p)
+	if err != nil {
+		return units.Int24{}, false
+	}
+
+	switch instruction.Format {
+	case proc.InstructionFormatSIC:
+		return units.BytesToInt24(0x00, instruction.Bytes[1]&0b01111111, instruction.Bytes[2]), true
+	case proc.InstructionFormat4:
+		if len(instruction.Bytes) < 4 {
+			return units.Int24{}, false
+		}
+		return units.BytesToInt24(instruction.Bytes[1]&0b00001111, instruction.Bytes[2], instruction.Bytes[3]), true
+	}
+
+	displacement := int(instruction.Bytes[1]&0b00001111)<<8 | int(instruction.Bytes[2])
+	pc := int(instruction.InstructionAddress.ToUint32()) + len(instruction.Bytes)
+	switch relativeAddressingMode {
+	case proc.PCRelativeAddressing:
+		if displacement >= 0x800 {
+			displacement -= 0x1000
+		}
+		return units.IntToInt24(pc + displacement), true
+	case proc.BaseRelativeAddressing:
+		if !baseKnown {
+			return units.Int24{}, false
+		}
+		return units.IntToInt24(int(baseAddress.ToUint32()) + displacement), true
+	}
+
+	// Direct format 3 displacement is signed
+	if displacement >= 0x800 {
+		displacement -= 0x1000
+	}
+	return units.IntToInt24(displacement), true
+}
+
+// Resolves operands of instructions in address order, the base follows LDB # as the BASE directive would
+func GetReferences(instructions []proc.Instruction) map[units.Int24]Reference {
+	references := make(map[units.Int24]Reference)
+
+	// Data decoded as instructions mostly looks like the other kind of program
+	sicProgram := IsSICProgram(instructions)
+
+	var baseAddress units.Int24
+	baseKnown := false
+	for _, instruction := range instructions {
+		if instruction.Directive == proc.DirectiveBYTE || !instruction.IsFormatSIC34() || instruction.Opcode == proc.RSUB {
+			continue
+		}
+		if sicProgram != (instruction.Format == proc.InstructionFormatSIC) {
+			continue
+		}
+
+		address, known := GetStaticAddress(instruction, baseAddress, baseKnown)
+		if instruction.Opcode == proc.LDB {
+			n, i, _, _, _, _ := instruction.GetNIXBPEBits()
+			baseAddress, baseKnown = address, known && proc.GetAbsoluteAdressingModes(n, i) == proc.ImmediateAbsoluteAddressing
+		}
+		if !known {
+			continue
+		}
+
+		references[instruction.InstructionAddress] = Reference{Address: address, Type: getReferenceType(instruction)}
+	}
+
+	return references
+}
+
+// Names referenced addresses, real symbols are used before generated labels.
+// Addresses inside an instruction are named by the instruction they're in.
+// Only addresses in the program's segments get generated labels, others stay numeric.
+func GetLabels(instructions []proc.Instruction, references map[units.Int24]Reference, symbols map[units.Int24]string, segments []Segment) map[units.Int24]string {
+	labels := make(map[units.Int24]string)
+	if len(instructions) == 0 {
+		return labels
+	}
+	programStart := instructions[0].InstructionAddress
+
+	// Code labels take precedence over data labels for the same address
+	codeAddresses := make(map[units.Int24]bool)
+	for _, reference := range references {
+		if reference.Type == ReferenceValue || reference.Address.Compare(programStart) < 0 || !isLoaded(segments, reference.Address) {
+			continue
+		}
+		address, _ := GetLabelAddress(instructions, reference.Address)
+		codeAddresses[address] = codeAddresses[address] || reference.Type == ReferenceCode
+	}
+
+	for address, code := range codeAddresses {
+		if code {
+			labels[address] = fmt.Sprintf("L%04X", address.ToUint32())
+		} else {
+			labels[address] = fmt.Sprintf("D%04X", address.ToUint32())
+		}
+	}
+	for address, name := range symbols {
+		if address.Compare(programStart) < 0 {
+			continue
+		}
+		if labelAddress, offset := GetLabelAddress(instructions, address); offset == 0 {
+			labels[labelAddress] = name
+		}
+	}
+
+	return labels
+}
+
+// Start of the instruction containing address and the offset from it,
+// addresses outside instructions are returned as they are
+func GetLabelAddress(instructions []proc.Instruction, address units.Int24) (units.Int24, int) {
+	index := sort.Search(len(instructions), func(i int) bool {
+		return instructions[i].InstructionAddress.Compare(address) > 0
+	}) - 1
+	if index < 0 {
+		return address, 0
+	}
+
+	instruction := instructions[index]
+	offset := int(address.ToUint32()) - int(instruction.InstructionAddress.ToUint32())
+	if offset >= len(instruction.Bytes) {
+		return address, 0
+	}
+	return instruction.InstructionAddress, offset
+}
+
+// Plain SIC programs have only SIC format instructions, data reached
+// by running on from code may decode as either kind
+func IsSICProgram(instructions []proc.Instruction) bool {
+	sicInstructions := 0
+	for _, instruction := range instructions {
+		switch {
+		case instruction.Directive == proc.DirectiveBYTE:
+		case instruction.Format == proc.InstructionFormatSIC:
+			sicInstructions++
+		default:
+			return false
+		}
+	}
+	return sicInstructions > 0
+}
+
+func getReferenceType(instruction proc.Instruction) ReferenceType {
+	n, i, _, b, p, _ := instruction.GetNIXBPEBits()
+	absoluteAddressingMode := proc.GetAbsoluteAdressingModes(n, i)
+
+	switch {
+	case absoluteAddressingMode == proc.ImmediateAbsoluteAddressing && !b && !p:
+		// Constant, unless it's relative to PC or base
+		return ReferenceValue
+	case instruction.IsJumpInstruction() && (absoluteAddressingMode == proc.DirectAbsoluteAddressing || absoluteAddressingMode == proc.SICAbsoluteAddressing):
+		return ReferenceCode
+	}
+	return ReferenceData
+}
+
+/*
+STRINGS
+*/
+func (referenceType ReferenceType) String() string {
+	switch referenceType {
+	case ReferenceValue:
+		return "Value"
+	case ReferenceCode:
+		return "Code"
+	case ReferenceData:
+		return "Data"
+	}
+	return "Not implemented"
+}
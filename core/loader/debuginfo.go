@@ -0,0 +1,178 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type SourceLocation struct {
+	File string
+	Line int
+}
+
+// JSON sidecar of an object file, addresses are hex strings as in the listing
+type debugFile struct {
+	Program string        `json:"program"`
+	Start   string        `json:"start"`
+	Lines   []debugLine   `json:"lines"`
+	Symbols []debugSymbol `json:"symbols"`
+}
+
+type debugLine struct {
+	Address string `json:"address"`
+	Size    int    `json:"size"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// Size is the size of a data element in bytes, 0 for code labels
+type debugSymbol struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Size    int    `json:"size"`
+}
+
+/*
+IMPLEMENTATION
+*/
+var SourceFileName string
+var SourceMap map[units.Int24]SourceLocation
+
+/*
+OPERATIONS
+*/
+func GetDbgFileName(objFileName string) string {
+	return strings.TrimSuffix(objFileName, filepath.Ext(objFileName)) + ".dbg"
+}
+
+// Maps addresses of code and data to the source lines which produced them
+func UpdateSourceMap() {
+	SourceMap = make(map[units.Int24]SourceLocation)
+	for _, syntaxNode := range SyntaxNodes {
+		if syntaxNode.Size == 0 || len(syntaxNode.Errors) > 0 {
+			continue
+		}
+		SourceMap[syntaxNode.LocationCounter] = SourceLocation{File: SourceFileName, Line: syntaxNode.LineNumber}
+	}
+}
+
+// Writes debug file with source lines and symbols of the assembled program
+func WriteDbgFile(file io.Writer) {
+	debugInfo := debugFile{
+		Program: ProgramName,
+		Start:   fmt.Sprintf("%06X", StartPC.ToUint32()),
+		Lines:   []debugLine{},
+		Symbols: []debugSymbol{},
+	}
+
+	for _, syntaxNode := range SyntaxNodes {
+		if syntaxNode.Size == 0 || len(syntaxNode.Errors) > 0 {
+			continue
+		}
+		debugInfo.Lines = append(debugInfo.Lines, debugLine{
+			Address: fmt.Sprintf("%06X", syntaxNode.LocationCounter.ToUint32()),
+			Size:    syntaxNode.Size,
+			File:    filepath.Base(SourceFileName),
+			Line:    syntaxNode.LineNumber,
+		})
+	}
+
+	symbolNames := make([]string, 0, len(SymbolTable))
+	for name := range SymbolTable {
+		symbolNames = append(symbolNames, name)
+	}
+	sort.Strings(symbolNames)
+	for _, name := range symbolNames {
+		symbol := SymbolTable[name]
+		debugInfo.Symbols = append(debugInfo.Symbols, debugSymbol{
+			Name:    symbol.Name,
+			Address: fmt.Sprintf("%06X", symbol.Address.ToUint32()),
+			Type:    symbol.Type.String(),
+			Size:    symbol.DataLength,
+		})
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(debugInfo)
+}
+
+// Loads symbols and source map from debug file, source files are relative to directory
+func LoadDbgFile(file io.Reader, directory string) error {
+	var debugInfo debugFile
+	if err := json.NewDecoder(file).Decode(&debugInfo); err != nil {
+		return err
+	}
+
+	sourceMap := make(map[units.Int24]SourceLocation)
+	for _, line := range debugInfo.Lines {
+		address, err := parseDebugAddress(line.Address)
+		if err != nil {
+			return err
+		}
+		sourceFileName := line.File
+		if !filepath.IsAbs(sourceFileName) {
+			sourceFileName = filepath.Join(directory, sourceFileName)
+		}
+		sourceMap[address] = SourceLocation{File: sourceFileName, Line: line.Line}
+	}
+
+	symbolTable := make(assembly.SymbolTable)
+	for _, symbol := range debugInfo.Symbols {
+		address, err := parseDebugAddress(symbol.Address)
+		if err != nil {
+			return err
+		}
+		symbolType, err := assembly.ParseSymbolType(symbol.Type)
+		if err != nil {
+			return err
+		}
+		symbolTable[symbol.Name] = assembly.Symbol{
+			Name:       symbol.Name,
+			Address:    address,
+			Type:       symbolType,
+			Data:       symbol.Size > 0,
+			DataLength: symbol.Size,
+		}
+	}
+
+	SourceMap = sourceMap
+	SymbolTable = symbolTable
+	return nil
+}
+
+// Debug file is optional, only a malformed one is an error
+func loadDbgFileFor(objFileName string) error {
+	dbgFileName := GetDbgFileName(objFileName)
+	file, err := os.Open(dbgFileName)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	if err := LoadDbgFile(file, filepath.Dir(dbgFileName)); err != nil {
+		return ErrInvalidDebugFile(dbgFileName, err)
+	}
+	return nil
+}
+
+func parseDebugAddress(address string) (units.Int24, error) {
+	value, err := strconv.ParseUint(address, 16, 24)
+	if err != nil {
+		return units.Int24{}, err
+	}
+	return units.IntToInt24(int(value)), nil
+}
@@ -0,0 +1,396 @@
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/loader/bytecode"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+IMPLEMENTATION
+*/
+var Labels map[units.Int24]string
+var References map[units.Int24]bytecode.Reference
+
+// Instructions patched at runtime and the stores which patched them
+var ModifiedCode map[units.Int24]units.Int24 = make(map[units.Int24]units.Int24)
+
+const asmByteConstantLength = 16
+
+/*
+OPERATIONS
+*/
+// Names addresses referenced by the disassembly, preferring relative symbols
+func UpdateLabels() {
+	symbolNames := make([]string, 0, len(SymbolTable))
+	for name, symbol := range SymbolTable {
+		if symbol.Type == assembly.SymbolRelative {
+			symbolNames = append(symbolNames, name)
+		}
+	}
+	sort.Strings(symbolNames)
+
+	symbols := make(map[units.Int24]string)
+	for _, name := range symbolNames {
+		address := SymbolTable[name].Address
+		if _, exists := symbols[address]; !exists {
+			symbols[address] = name
+		}
+	}
+
+	// Program name is the label of START
+	if len(InstructionList) > 0 && ProgramName != "" {
+		if _, exists := symbols[InstructionList[0].InstructionAddress]; !exists {
+			symbols[InstructionList[0].InstructionAddress] = ProgramName
+		}
+	}
+
+	References = bytecode.GetReferences(InstructionList)
+	Labels = bytecode.GetLabels(InstructionList, References, symbols, getProgramSegments())
+}
+
+// Loaded segments and the program bounds, which also hold reservations
+func getProgramSegments() []bytecode.Segment {
+	segments := append([]bytecode.Segment{}, Segments...)
+	if ProgramBounds.Start.Compare(ProgramBounds.End) < 0 {
+		segments = append(segments, ProgramBounds)
+	}
+	return segments
+}
+
+func isInProgram(address units.Int24) bool {
+	for _, segment := range getProgramSegments() {
+		if address.Compare(segment.Start) >= 0 && address.Compare(segment.End) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Decodes entries covering written memory again, returns whether the store modified code
+func InvalidateDisassembly(address units.Int24, length int, storeAddress units.Int24) bool {
+	start := int(address.ToUint32())
+	end := start + length
+	modified := false
+	for entryAddress := start - 3; entryAddress < end; entryAddress++ {
+		if entryAddress < 0 {
+			continue
+		}
+		instruction, exists := Disassembly[units.IntToInt24(entryAddress)]
+		if !exists || entryAddress+len(instruction.Bytes) <= start {
+			continue
+		}
+		current := base.GetSlice(instruction.InstructionAddress, addressAfter(instruction.InstructionAddress, len(instruction.Bytes)))
+		if bytes.Equal(current, instruction.Bytes) {
+			continue
+		}
+
+		if instruction.Directive == proc.DirectiveBYTE {
+			instruction.Bytes = append([]byte{}, current...)
+			Disassembly[instruction.InstructionAddress] = instruction
+			continue
+		}
+
+		// Self-modifying write
+		delete(Disassembly, instruction.InstructionAddress)
+		bytecode.FollowCode(Disassembly, Segments, instruction.InstructionAddress)
+		ModifiedCode[instruction.InstructionAddress] = storeAddress
+		modified = true
+	}
+
+	if modified {
+		UpdateDisassemblyInstructionAddressOperands()
+		UpdateInstructionList()
+		UpdateLabels()
+	}
+	return modified
+}
+
+// Label of address, addresses inside labeled instructions are given as label+offset
+func GetAddressName(address units.Int24) (string, bool) {
+	labelAddress, offset := bytecode.GetLabelAddress(InstructionList, address)
+	label, exists := Labels[labelAddress]
+	if !exists {
+		return "", false
+	}
+	if offset > 0 {
+		return fmt.Sprintf("%s+%d", label, offset), true
+	}
+	return label, true
+}
+
+// Label of address or its hex value when it has none
+func StringAddressName(address units.Int24) string {
+	if name, exists := GetAddressName(address); exists {
+		return name
+	}
+	return base.StringAddress(address)
+}
+
+// Operand as written in source, false when it depends on registers at runtime
+func GetSymbolicOperand(instruction proc.Instruction) (string, bool) {
+	if instruction.Directive == proc.DirectiveBYTE {
+		return fmt.Sprintf("X'%X'", instruction.Bytes), true
+	}
+
+	switch instruction.Format {
+	case proc.InstructionFormat1:
+		return "", true
+	case proc.InstructionFormat2:
+		switch assembly.GetMnemonic(assembly.MnemonicName(instruction.Opcode.String())) {
+		case assembly.MnemonicF2N:
+			return fmt.Sprintf("%d", instruction.R1), true
+		case assembly.MnemonicF2R:
+			return instruction.R1.String(), true
+		case assembly.MnemonicF2RN:
+			return fmt.Sprintf("%s,%d", instruction.R1.String(), instruction.R2+1), true
+		case assembly.MnemonicF2RR:
+			return fmt.Sprintf("%s,%s", instruction.R1.String(), instruction.R2.String()), true
+		}
+		return "", false
+	}
+	if instruction.Opcode == proc.RSUB {
+		return "", true
+	}
+
+	reference, exists := References[instruction.InstructionAddress]
+	if !exists {
+		return "", false
+	}
+
+	var operand string
+	n, i, x, _, _, _ := instruction.GetNIXBPEBits()
+	switch proc.GetAbsoluteAdressingModes(n, i) {
+	case proc.ImmediateAbsoluteAddressing:
+		operand = "#"
+	case proc.IndirectAbsoluteAddressing:
+		operand = "@"
+	}
+	operand += getReferenceOperand(reference)
+	if x {
+		operand += ",X"
+	}
+	return operand, true
+}
+
+// Writes disassembly as source which assembles to the same bytes,
+// instructions the assembler would encode differently are written as BYTE constants
+func WriteAsmFile(file io.Writer) {
+	if len(InstructionList) == 0 {
+		return
+	}
+	programStart := InstructionList[0].InstructionAddress
+	if ProgramBounds.Start.Compare(ProgramBounds.End) < 0 && ProgramBounds.Start.Compare(programStart) < 0 {
+		programStart = ProgramBounds.Start
+	}
+	sicProgram := bytecode.IsSICProgram(InstructionList)
+
+	// Labels outside the program, such as symbols of other programs, are defined by EQU
+	labelAddresses := units.Int24Slice{}
+	equAddresses := units.Int24Slice{}
+	for address := range Labels {
+		if isInProgram(address) {
+			labelAddresses = append(labelAddresses, address)
+		} else {
+			equAddresses = append(equAddresses, address)
+		}
+	}
+	sort.Sort(labelAddresses)
+	sort.Sort(equAddresses)
+
+	writeAsmLine(file, ProgramName, assembly.START, fmt.Sprintf("%d", programStart.ToUint32()))
+	if sicProgram {
+		writeAsmLine(file, "", assembly.SIC, "")
+	}
+	for _, address := range equAddresses {
+		writeAsmLine(file, Labels[address], assembly.EQU, fmt.Sprintf("%d", address.ToUint32()))
+	}
+
+	var baseAddress units.Int24
+	baseEnabled := false
+	locationCounter := programStart
+	for index := 0; index < len(InstructionList); index++ {
+		instruction := InstructionList[index]
+		writeAsmReservations(file, labelAddresses, locationCounter, instruction.InstructionAddress)
+		label := getAsmLabel(instruction.InstructionAddress)
+		locationCounter = addressAfter(instruction.InstructionAddress, len(instruction.Bytes))
+
+		// Consecutive data bytes up to the next label
+		if instruction.Directive == proc.DirectiveBYTE {
+			data := instruction.Bytes
+			for index+1 < len(InstructionList) && len(data) < asmByteConstantLength {
+				next := InstructionList[index+1]
+				if next.Directive != proc.DirectiveBYTE || next.InstructionAddress != locationCounter || Labels[next.InstructionAddress] != "" {
+					break
+				}
+				data = append(data, next.Bytes...)
+				locationCounter = addressAfter(next.InstructionAddress, len(next.Bytes))
+				index++
+			}
+			writeAsmLine(file, label, assembly.BYTE, fmt.Sprintf("X'%X'", data))
+			continue
+		}
+
+		operand, resolved := GetSymbolicOperand(instruction)
+		if resolved && canReassemble(instruction, sicProgram, baseAddress, baseEnabled) {
+			mnemonic := assembly.MnemonicName(instruction.Opcode.String())
+			if instruction.Format == proc.InstructionFormat4 {
+				mnemonic = "+" + mnemonic
+			}
+			writeAsmLine(file, label, mnemonic, operand)
+		} else {
+			writeAsmLine(file, label, assembly.BYTE, fmt.Sprintf("X'%X'", instruction.Bytes))
+		}
+
+		// Base follows LDB # the same way labeling does
+		if instruction.Opcode == proc.LDB && instruction.IsFormatSIC34() && !sicProgram {
+			reference, exists := References[instruction.InstructionAddress]
+			n, i, _, _, _, _ := instruction.GetNIXBPEBits()
+			if exists && proc.GetAbsoluteAdressingModes(n, i) == proc.ImmediateAbsoluteAddressing {
+				baseAddress, baseEnabled = reference.Address, true
+				writeAsmLine(file, "", assembly.BASE, getReferenceOperand(reference))
+			} else if baseEnabled {
+				baseEnabled = false
+				writeAsmLine(file, "", assembly.NOBASE, "")
+			}
+		}
+	}
+
+	// Reservations after the last instruction, up to the end of the program
+	if ProgramBounds.End.Compare(locationCounter) > 0 {
+		writeAsmReservations(file, labelAddresses, locationCounter, ProgramBounds.End)
+	}
+
+	endOperand, named := GetAddressName(StartPC)
+	if !named {
+		endOperand = fmt.Sprintf("%d", StartPC.ToUint32())
+	}
+	writeAsmLine(file, "", assembly.END, endOperand)
+}
+
+// Whether the assembler encodes the symbolic form of instruction into the same bytes
+func canReassemble(instruction proc.Instruction, sicProgram bool, baseAddress units.Int24, baseEnabled bool) bool {
+	mnemonic := assembly.MnemonicName(instruction.Opcode.String())
+	mnemonicType := assembly.GetMnemonic(mnemonic)
+	if !assembly.IsMnemonicInstruction(mnemonicType) {
+		return false
+	}
+	if sicProgram && (instruction.Format != proc.InstructionFormatSIC || !assembly.IsSICMnemonic(mnemonic)) {
+		return false
+	}
+
+	reassembled := proc.Instruction{
+		Opcode: instruction.Opcode,
+		Format: instruction.Format,
+	}
+	n, i, x, _, _, _ := instruction.GetNIXBPEBits()
+	reference := References[instruction.InstructionAddress]
+
+	switch instruction.Format {
+	case proc.InstructionFormat1:
+	case proc.InstructionFormat2:
+		switch mnemonicType {
+		case assembly.MnemonicF2N, assembly.MnemonicF2R:
+			reassembled.R1 = instruction.R1
+		case assembly.MnemonicF2RN, assembly.MnemonicF2RR:
+			reassembled.R1, reassembled.R2 = instruction.R1, instruction.R2
+		}
+		if _, err := assembly.GetRegisterIdFromMnemonic(instruction.R1.String()); err != nil && mnemonicType != assembly.MnemonicF2N {
+			return false
+		}
+		if _, err := assembly.GetRegisterIdFromMnemonic(instruction.R2.String()); err != nil && mnemonicType == assembly.MnemonicF2RR {
+			return false
+		}
+	case proc.InstructionFormatSIC:
+		if !sicProgram {
+			return false
+		}
+		reassembled.Address = reference.Address
+		reassembled.AbsoluteAddressingMode = proc.SICAbsoluteAddressing
+		reassembled.IndexAddressingMode = proc.IndexAddressingMode(x)
+	case proc.InstructionFormat3:
+		reassembled.AbsoluteAddressingMode = proc.GetAbsoluteAdressingModes(n, i)
+		reassembled.IndexAddressingMode = proc.IndexAddressingMode(x)
+		if mnemonicType == assembly.MnemonicF3M {
+			pc := addressAfter(instruction.InstructionAddress, len(instruction.Bytes))
+			// Operands written as labels are relocatable
+			_, named := GetAddressName(reference.Address)
+			relocatable := reference.Type != bytecode.ReferenceValue && named
+			displacement, relativeAddressingMode, fits := assembly.GetRelativeAddressing(reference.Address, reassembled.AbsoluteAddressingMode, relocatable, pc, baseAddress, baseEnabled)
+			if !fits {
+				return false
+			}
+			reassembled.Address = displacement
+			reassembled.RelativeAddressingMode = relativeAddressingMode
+		}
+	case proc.InstructionFormat4:
+		if mnemonicType != assembly.MnemonicF3M {
+			return false
+		}
+		reassembled.Address = reference.Address
+		reassembled.AbsoluteAddressingMode = proc.GetAbsoluteAdressingModes(n, i)
+		reassembled.IndexAddressingMode = proc.IndexAddressingMode(x)
+	default:
+		return false
+	}
+
+	return bytes.Equal(reassembled.GetInstructionBytes(), instruction.Bytes)
+}
+
+func getReferenceOperand(reference bytecode.Reference) string {
+	if reference.Type != bytecode.ReferenceValue {
+		if name, named := GetAddressName(reference.Address); named {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d", reference.Address.ToInt32())
+}
+
+// Label defined on the line at address, START already defines the program name
+func getAsmLabel(address units.Int24) string {
+	label := Labels[address]
+	if label == ProgramName && len(InstructionList) > 0 && address == InstructionList[0].InstructionAddress {
+		return ""
+	}
+	return label
+}
+
+// Reserves memory between from and to, split at labels inside it
+func writeAsmReservations(file io.Writer, labelAddresses units.Int24Slice, from units.Int24, to units.Int24) {
+	for from.Compare(to) < 0 {
+		next := to
+		for _, address := range labelAddresses {
+			if address.Compare(from) > 0 {
+				if address.Compare(to) < 0 {
+					next = address
+				}
+				break
+			}
+		}
+
+		size := int(next.ToUint32()) - int(from.ToUint32())
+		if size%units.WORD_SIZE == 0 {
+			writeAsmLine(file, getAsmLabel(from), assembly.RESW, fmt.Sprintf("%d", size/units.WORD_SIZE))
+		} else {
+			writeAsmLine(file, getAsmLabel(from), assembly.RESB, fmt.Sprintf("%d", size))
+		}
+		from = next
+	}
+}
+
+func writeAsmLine(file io.Writer, label string, mnemonic assembly.MnemonicName, operand string) {
+	line := fmt.Sprintf("%-7s %-6s %s", label, mnemonic, operand)
+	fmt.Fprintln(file, strings.TrimRight(line, " "))
+}
+
+func addressAfter(address units.Int24, size int) units.Int24 {
+	return units.IntToInt24(int(address.ToUint32()) + size)
+}
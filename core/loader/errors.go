@@ -13,3 +13,11 @@ func ErrDisassemblyIncorrect() error {
 	log.Fatalf("Disassembly is incorrect")
 	return fmt.Errorf("Disassembly is incorrect")
 }
+
+func ErrUnknownFileType(fileName string) error {
+	return fmt.Errorf("Unknown program file type: %s", fileName)
+}
+
+func ErrInvalidDebugFile(fileName string, err error) error {
+	return fmt.Errorf("Invalid debug file %s: %v", fileName, err)
+}
@@ -2,17 +2,15 @@ package loader
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sicsimgo/core/loader/assembly"
 	"sicsimgo/core/loader/bytecode"
 	"sicsimgo/core/proc"
 	"sicsimgo/core/units"
-	"sicsimgo/internal"
 	"sort"
 	"strings"
-
-	"github.com/sqweek/dialog"
 )
 
 /*
@@ -32,56 +30,78 @@ IMPLEMENTATION
 var Disassembly map[units.Int24]proc.Instruction
 var InstructionList []proc.Instruction
 
-var LastInstructionByteAddress units.Int24
+// Memory loaded from object file
+var Segments []bytecode.Segment
+
+// Addresses of the program with its reservations, from the header record or the assembled source
+var ProgramBounds bytecode.Segment
 
 var SymbolTable assembly.SymbolTable
 var SymbolTableList []assembly.Symbol
 
 var SyntaxNodes []assembly.SyntaxNode
+var CrossReferences []assembly.CrossReference
 
 var ProgramName string
 var StartPC units.Int24
 
+// Options used when loading assembly sources
+var AssemblerOptions assembly.Options
+
+const maxTextRecordLength = 0x1E
+
+const lstObjectCodeBytes = 6
+const lstObjectCodeWidth = 12
+
 /*
 OPERATIONS
 */
-func OpenAsmObjFile() (string, units.Int24, LoadedProgramType) {
-	fileName, err := dialog.File().Filter("Assembly / Object files", "asm", "obj").Filter("Assembly files", "asm").Filter("Object files", "obj").Title("Select object / assembly file").Load()
-	if err != nil {
-		return internal.DefaultWindowTitle, units.Int24{}, None
-	}
-
+func LoadProgramFile(fileName string) (string, units.Int24, LoadedProgramType, error) {
 	file, err := os.Open(fileName)
 	if err != nil {
-		return internal.DefaultWindowTitle, units.Int24{}, None
+		return "", units.Int24{}, None, err
 	}
 	defer file.Close()
 
 	var loadedProgramType LoadedProgramType
 
+	SourceFileName = fileName
 	switch filepath.Ext(fileName) {
 	case ".asm":
 		loadedProgramType = Assembly
-		ProgramName, StartPC, Disassembly, SymbolTable, SyntaxNodes = assembly.LoadProgram(file)
+		ProgramName, StartPC, Disassembly, SymbolTable, SyntaxNodes = assembly.LoadProgram(file, AssemblerOptions)
+		programStart, programEnd := getProgramBounds()
+		ProgramBounds = bytecode.Segment{Start: units.Uint32ToInt24(programStart), End: units.Uint32ToInt24(programEnd)}
+		UpdateSourceMap()
 	case ".obj":
 		loadedProgramType = Bytecode
-		ProgramName, StartPC, Disassembly, LastInstructionByteAddress = bytecode.LoadProgram(file)
+		var definitions map[string]units.Int24
+		ProgramName, StartPC, Disassembly, Segments, definitions, ProgramBounds = bytecode.LoadProgram(file)
+		SymbolTable = make(assembly.SymbolTable)
+		for name, address := range definitions {
+			SymbolTable[name] = assembly.Symbol{Name: name, Address: address, Type: assembly.SymbolRelative}
+		}
+		if err := loadDbgFileFor(fileName); err != nil {
+			return "", units.Int24{}, None, err
+		}
 	default:
-		return internal.DefaultWindowTitle, units.Int24{}, None
+		return "", units.Int24{}, None, ErrUnknownFileType(fileName)
 	}
 
 	UpdateDisassemblyInstructionAddressOperands()
 	UpdateInstructionList()
+	UpdateLabels()
 	UpdateSymbolTableList()
+	UpdateCrossReferences()
 
-	return ProgramName, StartPC, loadedProgramType
+	return ProgramName, StartPC, loadedProgramType, nil
 }
 
 func UpdateDisassemblyInstructionAddressOperands() {
 	for address, instruction := range Disassembly {
 		nextInstructionAddress := address
 		for i := 0; i < len(instruction.Bytes); i++ {
-			nextInstructionAddress = nextInstructionAddress.Add(units.Int24{0x00, 0x00, 0x01})
+			nextInstructionAddress = nextInstructionAddress.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 		}
 		if instruction.IsFormatSIC34() {
 			operand, address, relativeAddressingMode, indexAddressingMode, absoluteAddressingMode := instruction.GetOperandAddress(nextInstructionAddress)
@@ -121,184 +141,201 @@ func UpdateSymbolTableList() {
 	})
 }
 
+func UpdateCrossReferences() {
+	CrossReferences = assembly.GetCrossReferences(SyntaxNodes, SymbolTable)
+}
+
 func ResetDissasembly() {
 	Disassembly = make(map[units.Int24]proc.Instruction)
 	InstructionList = make([]proc.Instruction, 0)
 
-	LastInstructionByteAddress = units.Int24{}
+	Segments = nil
+	ProgramBounds = bytecode.Segment{}
 
 	SymbolTable = make(assembly.SymbolTable)
 	SymbolTableList = make([]assembly.Symbol, 0)
-}
 
-func OutputLstFile() {
-	fileName, err := dialog.File().Filter("List file", "lst").Title("Save list file").Save()
-	if err != nil {
-		return
-	}
-	if !strings.HasSuffix(fileName, ".lst") {
-		fileName += ".lst"
-	}
+	SyntaxNodes = make([]assembly.SyntaxNode, 0)
+	CrossReferences = make([]assembly.CrossReference, 0)
 
-	file, err := os.Create(fileName)
-	if err != nil {
-		return
-	}
-	defer file.Close()
+	Labels = make(map[units.Int24]string)
+	References = make(map[units.Int24]bytecode.Reference)
+	ModifiedCode = make(map[units.Int24]units.Int24)
 
-	currentLineNumber := 0
+	SourceFileName = ""
+	SourceMap = make(map[units.Int24]SourceLocation)
+}
+
+// Writes the assembly listing, followed by the symbol table
+func WriteLstFile(file io.Writer) {
+	fmt.Fprintf(file, "%5s  %-6s  %-*s  %s\n", "LINE", "LOC", lstObjectCodeWidth, "OBJECT CODE", "SOURCE")
+
+	currentLineNumber := 1
+	errorCount := 0
+	warningCount := 0
 	for _, syntaxNode := range SyntaxNodes {
+		// Blank lines aren't parsed into syntax nodes
 		for currentLineNumber < syntaxNode.LineNumber {
-			file.WriteString("\n")
+			fmt.Fprintf(file, "%5d\n", currentLineNumber)
 			currentLineNumber++
 		}
 
-		if syntaxNode.IsComment {
-			file.WriteString(fmt.Sprintf("%-23s . %s\n", "", syntaxNode.Comment))
-		} else if syntaxNode.MnemonicType == assembly.MnemonicDirective {
-			if syntaxNode.Label != "" {
-				file.WriteString(fmt.Sprintf("%s %s\n", syntaxNode.Label, syntaxNode.Mnemonic))
-			} else {
-				file.WriteString(fmt.Sprintf("%s\n", syntaxNode.Mnemonic))
-			}
-		} else if syntaxNode.MnemonicType == assembly.MnemonicDirectiveN {
-			if syntaxNode.Label != "" {
-				file.WriteString(fmt.Sprintf("%s %s %s\n", syntaxNode.Label, syntaxNode.Mnemonic, syntaxNode.Operands[0]))
-			} else {
-				file.WriteString(fmt.Sprintf("%s %s\n", syntaxNode.Mnemonic, syntaxNode.Operands[0]))
-			}
+		var location string
+		if syntaxNode.Mnemonic == assembly.EQU {
+			location = fmt.Sprintf("%06X", SymbolTable[syntaxNode.Label].Address.ToUint32())
+		} else if !syntaxNode.IsComment {
+			location = fmt.Sprintf("%06X", syntaxNode.LocationCounter.ToUint32())
+		}
+
+		objectCode := syntaxNode.ObjectCode
+		var objectCodeText string
+		if syntaxNode.MnemonicType == assembly.MnemonicStorageD {
+			objectCodeText = fmt.Sprintf("(%d bytes)", syntaxNode.Size)
 		} else {
-			file.WriteString(fmt.Sprintf("%-12s%-11X%-9s%-9s%s%-5s%s\n",
-				syntaxNode.LocationCounter.StringHex(),
-				func() []byte {
-					if assembly.IsMnemonicInstruction(syntaxNode.MnemonicType) {
-						return Disassembly[syntaxNode.LocationCounter].Bytes
-					} else if syntaxNode.MnemonicType == assembly.MnemonicStorageD || syntaxNode.MnemonicType == assembly.MnemonicStorageN {
-						return SymbolTable[syntaxNode.Label].Value
-					}
-					return []byte{}
-				}(),
-				syntaxNode.Label,
-				syntaxNode.Mnemonic,
-				strings.Join(syntaxNode.Operands, " "),
-				"",
-				func() string {
-					if syntaxNode.Comment != "" {
-						return ". " + syntaxNode.Comment
-					}
-					return ""
-				}(),
-			))
+			objectCodeText = fmt.Sprintf("%X", objectCode[:min(lstObjectCodeBytes, len(objectCode))])
+		}
+		line := fmt.Sprintf("%5d  %-6s  %-*s  %s", syntaxNode.LineNumber, location, lstObjectCodeWidth, objectCodeText, syntaxNode.Source)
+		fmt.Fprintln(file, strings.TrimRight(line, " "))
+
+		// Wrap long object code (BYTE constants) onto continuation lines
+		for offset := lstObjectCodeBytes; offset < len(objectCode); offset += lstObjectCodeBytes {
+			continuationAddress := syntaxNode.LocationCounter.ToUint32() + uint32(offset)
+			fmt.Fprintf(file, "%5s  %06X  %X\n", "", continuationAddress, objectCode[offset:min(offset+lstObjectCodeBytes, len(objectCode))])
+		}
+
+		for _, assemblyError := range syntaxNode.Errors {
+			fmt.Fprintf(file, "%5s  ***** Error (column %d): %s\n", "", assemblyError.Column, assemblyError.Message)
+			errorCount++
+		}
+		for _, assemblyWarning := range syntaxNode.Warnings {
+			fmt.Fprintf(file, "%5s  ***** Warning (column %d): %s\n", "", assemblyWarning.Column, assemblyWarning.Message)
+			warningCount++
 		}
 
-		currentLineNumber++
+		currentLineNumber = syntaxNode.LineNumber + 1
 	}
+
+	fmt.Fprintf(file, "\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+
+	fmt.Fprintln(file)
+	WriteSymbolTable(file)
 }
 
-func OutputObjFile() {
-	fileName, err := dialog.File().Filter("Object file", "obj").Title("Save object file").Save()
-	if err != nil {
-		return
-	}
-	if !strings.HasSuffix(fileName, ".obj") {
-		fileName += ".obj"
+// Writes the symbol table with cross-references of every symbol
+func WriteSymbolTable(file io.Writer) {
+	section := ProgramName
+	if section == "" {
+		section = "-"
 	}
 
-	file, err := os.Create(fileName)
-	if err != nil {
-		return
+	fmt.Fprintf(file, "SYMBOL TABLE\n")
+	fmt.Fprintf(file, "%-10s  %-6s  %-8s  %-8s  %-7s  %s\n", "NAME", "VALUE", "TYPE", "SECTION", "DEFINED", "REFERENCES")
+	for _, crossReference := range CrossReferences {
+		symbol := crossReference.Symbol
+		var references []string
+		for _, reference := range crossReference.References {
+			references = append(references, reference.String())
+		}
+		line := fmt.Sprintf("%-10s  %06X  %-8s  %-8s  %-7d  %s", symbol.Name, symbol.Address.ToUint32(), symbol.Type.String(), section, crossReference.DefinitionLine, strings.Join(references, " "))
+		fmt.Fprintln(file, strings.TrimRight(line, " "))
 	}
-	defer file.Close()
+	fmt.Fprintf(file, "\nR = read, W = write, J = jump target, I = immediate use, D = directive operand\n")
+}
 
-	// Generate text (T) & modification (M) sections
-	var textSections []string
-	var modificationSections []string
-	var totalByteCount int
+// Writes the assembled program as H, T, M and E records
+func WriteObjFile(file io.Writer) {
+	programStart, programEnd := getProgramBounds()
 
-	var bytesBuffer []byte
-	var lastByteAddress units.Int24 = InstructionList[0].InstructionAddress
-	const maxBufferLength = 30
-	for _, syntaxNode := range SyntaxNodes {
+	// Generate text (T) & modification (M) records
+	var textRecords []string
+	var modificationRecords []string
 
-		goToNextTRecord := false
-		// Determine bytes to add
-		var bytesToAdd []byte
-		if syntaxNode.Mnemonic == assembly.BYTE {
-			absoluteOperandAddress := assembly.GetAbsoluteOperandAddress(syntaxNode.Operands[0])
-			bytesToAdd = []byte{absoluteOperandAddress[0]}
-		} else if syntaxNode.Mnemonic == assembly.WORD {
-			absoluteOperandAddress := assembly.GetAbsoluteOperandAddress(syntaxNode.Operands[0])
-			bytesToAdd = []byte{absoluteOperandAddress[0], absoluteOperandAddress[1], absoluteOperandAddress[2]}
-		} else if syntaxNode.Mnemonic == assembly.RESB {
-			goToNextTRecord = true
-			lastByteAddress = lastByteAddress.Add(units.IntToInt24(1))
-		} else if syntaxNode.Mnemonic == assembly.RESW {
-			goToNextTRecord = true
-			lastByteAddress = lastByteAddress.Add(units.IntToInt24(3))
-		} else if assembly.IsMnemonicInstruction(syntaxNode.MnemonicType) {
-			instruction := Disassembly[syntaxNode.LocationCounter]
-			bytesToAdd = instruction.Bytes
-
-			if instruction.RelativeAddressingMode == proc.DirectRelativeAddressing {
-				modificationOffset := lastByteAddress.Add(units.IntToInt24(len(bytesBuffer) + 1))
-				switch instruction.Format {
-				case proc.InstructionFormatSIC | proc.InstructionFormat3:
-					modificationRecord := fmt.Sprintf("M%X%02X\n", modificationOffset, 0x03)
-					modificationSections = append(modificationSections, modificationRecord)
-				case proc.InstructionFormat4:
-					modificationRecord := fmt.Sprintf("M%X%02X\n", modificationOffset, 0x05)
-					modificationSections = append(modificationSections, modificationRecord)
-				}
-			}
+	var recordAddress uint32
+	var recordCode []byte
+	writeTextRecord := func() {
+		if len(recordCode) > 0 {
+			textRecords = append(textRecords, fmt.Sprintf("T%06X%02X%X\n", recordAddress, len(recordCode), recordCode))
+		}
+		recordCode = []byte{}
+	}
+
+	for _, syntaxNode := range SyntaxNodes {
+		if syntaxNode.IsComment || syntaxNode.Mnemonic == "" {
+			continue
 		}
 
-		if goToNextTRecord {
-			textRecord := fmt.Sprintf("T%X%02X%X\n", lastByteAddress, byte(len(bytesBuffer)), bytesBuffer)
-			textSections = append(textSections, textRecord)
-			totalByteCount += len(bytesBuffer)
+		// Reservations and ORG leave gaps, which end the current T record
+		if syntaxNode.Mnemonic == assembly.ORG || syntaxNode.MnemonicType == assembly.MnemonicStorageD {
+			writeTextRecord()
+			continue
+		}
 
-			lastByteAddress = lastByteAddress.Add(units.IntToInt24(len(bytesBuffer)))
-			bytesBuffer = []byte{}
-		} else if len(bytesBuffer)+len(bytesToAdd) > maxBufferLength {
-			appendBytes := bytesToAdd[:maxBufferLength-len(bytesBuffer)]
-			leftOverBytes := bytesToAdd[maxBufferLength-len(bytesBuffer):]
-			bytesBuffer = append(bytesBuffer, appendBytes...)
+		code := syntaxNode.ObjectCode
+		address := syntaxNode.LocationCounter.ToUint32()
+		if len(code) == 0 {
+			continue
+		}
 
-			textRecord := fmt.Sprintf("T%X%02X%X\n", lastByteAddress, byte(len(bytesBuffer)), bytesBuffer)
-			textSections = append(textSections, textRecord)
-			totalByteCount += len(bytesBuffer)
+		// Keep instructions whole unless they can't fit into a single record
+		if recordAddress+uint32(len(recordCode)) != address {
+			writeTextRecord()
+		} else if len(code) <= maxTextRecordLength && len(recordCode)+len(code) > maxTextRecordLength {
+			writeTextRecord()
+		}
+		for len(code) > 0 {
+			if len(recordCode) == 0 {
+				recordAddress = address
+			}
+			n := min(maxTextRecordLength-len(recordCode), len(code))
+			recordCode = append(recordCode, code[:n]...)
+			code = code[n:]
+			address += uint32(n)
+			if len(recordCode) == maxTextRecordLength {
+				writeTextRecord()
+			}
+		}
 
-			lastByteAddress = lastByteAddress.Add(units.IntToInt24(len(bytesBuffer)))
-			bytesBuffer = leftOverBytes
-		} else {
-			bytesBuffer = append(bytesBuffer, bytesToAdd...)
+		// Modification offsets are relative to program start
+		for _, modification := range syntaxNode.Modifications {
+			modificationOffset := modification.Address.ToUint32() - programStart
+			modificationRecords = append(modificationRecords, fmt.Sprintf("M%06X%02X\n", modificationOffset, modification.HalfBytes))
 		}
 	}
-	if len(bytesBuffer) > 0 {
-		textRecord := fmt.Sprintf("T%X%02X%X\n", lastByteAddress, byte(len(bytesBuffer)), bytesBuffer)
-		textSections = append(textSections, textRecord)
-		totalByteCount += len(bytesBuffer)
-	}
+	writeTextRecord()
 
-	// Write header (H) section
-	headerRecord := fmt.Sprintf("H%-6s%X%X\n", ProgramName, StartPC, units.IntToInt24(totalByteCount))
-	file.WriteString(headerRecord)
-	fmt.Println(headerRecord)
+	// Write header (H) record
+	fmt.Fprintf(file, "H%-6.6s%06X%06X\n", ProgramName, programStart, programEnd-programStart)
 
-	// Write text (T) sections
-	for _, textSection := range textSections {
-		file.WriteString(textSection)
-		fmt.Println(textSection)
+	// Write text (T) records
+	for _, textRecord := range textRecords {
+		io.WriteString(file, textRecord)
 	}
 
-	// Write modification (M) sections
-	for _, modificationSection := range modificationSections {
-		file.WriteString(modificationSection)
-		fmt.Println(modificationSection)
+	// Write modification (M) records
+	for _, modificationRecord := range modificationRecords {
+		io.WriteString(file, modificationRecord)
 	}
 
-	// Write end (E) section
-	endRecord := fmt.Sprintf("E%X\n", LastInstructionByteAddress)
-	file.WriteString(endRecord)
-	fmt.Println(endRecord)
+	// Write end (E) record - address of first executable instruction
+	fmt.Fprintf(file, "E%06X\n", StartPC.ToUint32())
+}
+
+// Returns first address and the address after last byte of the program
+func getProgramBounds() (uint32, uint32) {
+	var programStart, programEnd uint32
+	boundsSet := false
+	for _, syntaxNode := range SyntaxNodes {
+		if syntaxNode.IsComment || syntaxNode.Mnemonic == "" {
+			continue
+		}
+
+		address := syntaxNode.LocationCounter.ToUint32()
+		if !boundsSet {
+			programStart, programEnd = address, address
+			boundsSet = true
+		}
+		programStart = min(programStart, address)
+		programEnd = max(programEnd, address+uint32(syntaxNode.Size))
+	}
+	return programStart, programEnd
 }
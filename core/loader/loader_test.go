@@ -0,0 +1,607 @@
+package loader
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/loader/bytecode"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+func assembleSource(t *testing.T, source string, options assembly.Options) {
+	t.Helper()
+
+	base.ResetMemory()
+	ResetDissasembly()
+	ProgramName, StartPC, Disassembly, SymbolTable, SyntaxNodes = assembly.LoadProgram(strings.NewReader(source), options)
+	UpdateCrossReferences()
+	if assemblyErrors := assembly.GetErrors(SyntaxNodes); len(assemblyErrors) > 0 {
+		t.Fatalf("assembly errors: %v", assemblyErrors)
+	}
+}
+
+func TestWriteObjFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		options  assembly.Options
+		expected string
+	}{
+		{
+			name: "Simple program",
+			source: `prog  START 0
+      LDA   #5
+      STA   num
+halt  J     halt
+num   WORD  7
+      END   prog
+`,
+			expected: "Hprog  00000000000C\n" +
+				"T0000000C0100050F20033F2FFD000007\n" +
+				"E000000\n",
+		},
+		{
+			name: "Reservations, format 4 and relocatable words",
+			source: `copy  START 4096
+first +JSUB  sub
+      J     first
+buf   RESB  10
+ptr   WORD  buf
+sub   RSUB
+      END   first
+`,
+			expected: "Hcopy  001000000017\n" +
+				"T001000074B1010143F2FF9\n" +
+				"T001011060010074F0000\n" +
+				"M00000105\n" +
+				"M00001106\n" +
+				"E001000\n",
+		},
+		{
+			name: "Relocatable immediate",
+			source: `imm   START 0
+      LDA   #buf
+      LDB   #buf
+      BASE  buf
+halt  J     halt
+buf   RESB  3
+      END   imm
+`,
+			expected: "Himm   00000000000C\n" +
+				"T000000090120066920033F2FFD\n" +
+				"E000000\n",
+		},
+		{
+			name: "ORG gap",
+			source: `gap   START 0
+      LDA   #1
+      ORG   256
+      LDA   #2
+      END
+`,
+			expected: "Hgap   000000000103\n" +
+				"T00000003010001\n" +
+				"T00010003010002\n" +
+				"E000000\n",
+		},
+		{
+			name: "Auto-extend to format 4",
+			source: `ext   START 0
+      LDA   #far
+      RESB  4000
+far   WORD  5
+      END
+`,
+			options: assembly.Options{AutoExtend: true},
+			expected: "Hext   000000000FA7\n" +
+				"T0000000401100FA4\n" +
+				"T000FA403000005\n" +
+				"M00000105\n" +
+				"E000000\n",
+		},
+		{
+			name: "Plain SIC",
+			source: `sic   START 0x1000
+      SIC
+loop  LDCH  str,X
+      RSUB
+ptr   WORD  loop
+str   BYTE  C'A'
+      END   loop
+`,
+			expected: "Hsic   00100000000A\n" +
+				"T0010000A5090094C000000100041\n" +
+				"E001000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, tt.source, tt.options)
+
+			var objFile bytes.Buffer
+			WriteObjFile(&objFile)
+			if objFile.String() != tt.expected {
+				t.Errorf("WriteObjFile() =\n%s\nwant\n%s", objFile.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteLstFile(t *testing.T) {
+	source := `prog  START 0
+. store num
+      LDA   num
+      STA   num
+
+      JSUB  nosuch
+buf   RESB  16
+num   WORD  7
+      COMP  num
+      END   prog
+`
+	expected := ` LINE  LOC     OBJECT CODE   SOURCE
+    1  000000                prog  START 0
+    2                        . store num
+    3  000000  032016              LDA   num
+    4  000003  0F2013              STA   num
+    5
+    6  000006                      JSUB  nosuch
+       ***** Error (column 13): Undefined symbol: nosuch
+    7  000009  (16 bytes)    buf   RESB  16
+    8  000019  000007        num   WORD  7
+    9  00001C  2B2FFA              COMP  num
+   10  00001F                      END   prog
+
+1 error(s), 0 warning(s)
+
+SYMBOL TABLE
+NAME        VALUE   TYPE      SECTION   DEFINED  REFERENCES
+buf         000009  Relative  prog      7
+num         000019  Relative  prog      8        3R 4W 9R
+prog        000000  Relative  prog      1        10J
+
+R = read, W = write, J = jump target, I = immediate use, D = directive operand
+`
+
+	// Listings are written for sources with errors too
+	base.ResetMemory()
+	ResetDissasembly()
+	ProgramName, StartPC, Disassembly, SymbolTable, SyntaxNodes = assembly.LoadProgram(strings.NewReader(source), assembly.Options{})
+	UpdateCrossReferences()
+
+	var buffer bytes.Buffer
+	WriteLstFile(&buffer)
+	if buffer.String() != expected {
+		t.Errorf("WriteLstFile() =\n%s\nwant\n%s", buffer.String(), expected)
+	}
+}
+
+func TestWriteObjFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{
+			name: "Loop with data",
+			source: `sum   START 0
+      LDX   #0
+      LDA   #0
+loop  ADD   nums,X
+      TIX   #9
+      JLT   loop
+      STA   total
+halt  J     halt
+nums  WORD  1
+      WORD  2
+      WORD  3
+total RESW  1
+      END   sum
+`,
+		},
+		{
+			name: "Long byte constant split across records",
+			source: `text  START 2048
+      LDA   #0
+msg   BYTE  C'The quick brown fox jumps over the lazy dog'
+hex   BYTE  X'F1E2D3'
+      +LDT  #4096
+      END
+`,
+		},
+		{
+			name: "Reservations between code",
+			source: `res   START 0
+      J     next
+tmp   RESW  2
+next  LDA   tmp
+      ORG   512
+      STA   tmp
+      END
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, tt.source, assembly.Options{})
+
+			// Expected memory image from assembler output
+			expected := map[uint32]byte{}
+			for _, syntaxNode := range SyntaxNodes {
+				for i, b := range syntaxNode.ObjectCode {
+					expected[syntaxNode.LocationCounter.ToUint32()+uint32(i)] = b
+				}
+			}
+
+			var objFile bytes.Buffer
+			WriteObjFile(&objFile)
+			for _, record := range strings.Split(strings.TrimSpace(objFile.String()), "\n") {
+				if record[0] == 'T' && len(record) > 9+2*maxTextRecordLength {
+					t.Errorf("T record too long: %s", record)
+				}
+			}
+
+			base.ResetMemory()
+			programName, startPC, _, _, _, _ := bytecode.LoadProgram(strings.NewReader(objFile.String()))
+			if programName != ProgramName {
+				t.Errorf("program name = %q, want %q", programName, ProgramName)
+			}
+			if startPC != StartPC {
+				t.Errorf("start PC = %s, want %s", startPC.StringHex(), StartPC.StringHex())
+			}
+			for address, b := range expected {
+				if loaded := base.GetByte(base.ToAddress(address)); loaded != b {
+					t.Errorf("byte at %05X = %02X, want %02X", address, loaded, b)
+				}
+			}
+		})
+	}
+}
+
+func TestDbgFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		lines  map[uint32]int
+	}{
+		{
+			name: "Code and data",
+			source: `prog  START 0
+      LDA   num
+loop  J     loop
+num   WORD  5
+buf   RESB  10
+      END   prog
+`,
+			lines: map[uint32]int{0x000000: 2, 0x000003: 3, 0x000006: 4, 0x000009: 5},
+		},
+		{
+			name: "Constant symbols",
+			source: `prog  START 4096
+size  EQU   3
+      LDA   #size
+      END   prog
+`,
+			lines: map[uint32]int{0x001000: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, tt.source, assembly.Options{})
+			SourceFileName = "prog.asm"
+			symbolTable := SymbolTable
+
+			var dbgFile bytes.Buffer
+			WriteDbgFile(&dbgFile)
+
+			ResetDissasembly()
+			if err := LoadDbgFile(&dbgFile, "src"); err != nil {
+				t.Fatalf("LoadDbgFile() error: %v", err)
+			}
+
+			if len(SymbolTable) != len(symbolTable) {
+				t.Errorf("loaded %d symbols, want %d", len(SymbolTable), len(symbolTable))
+			}
+			for name, symbol := range symbolTable {
+				loaded := SymbolTable[name]
+				if loaded.Address != symbol.Address || loaded.Type != symbol.Type || loaded.Data != symbol.Data || loaded.DataLength != symbol.DataLength {
+					t.Errorf("symbol %s = %+v, want %+v", name, loaded, symbol)
+				}
+			}
+			if len(SourceMap) != len(tt.lines) {
+				t.Errorf("loaded %d source lines, want %d", len(SourceMap), len(tt.lines))
+			}
+			for address, line := range tt.lines {
+				location := SourceMap[units.IntToInt24(int(address))]
+				if location.Line != line || location.File != filepath.Join("src", "prog.asm") {
+					t.Errorf("source at %06X = %s:%d, want line %d", address, location.File, location.Line, line)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAsmFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		lines  []string
+	}{
+		{
+			name: "Labels for jumps and data",
+			source: `sum   START 0
+      LDX   #0
+loop  ADD   nums,X
+      TIX   #9
+      JLT   loop
+      STA   total
+halt  J     halt
+nums  WORD  1
+      BYTE  X'FF'
+total RESW  1
+      END   sum
+`,
+			lines: []string{
+				"L0003   ADD    D0012,X",
+				"        JLT    L0003",
+				"        STA    D0016",
+				"D0016   RESW   1",
+				"        END    sum",
+			},
+		},
+		{
+			name: "Base-relative and format 4",
+			source: `big   START 4096
+      LDB   #2000
+      BASE  2000
+      STCH  3000
+      +JSUB far
+      CLEAR A
+      SHIFTL T,4
+      RSUB
+      ORG   8192
+far   J     @far
+      END   big
+`,
+			lines: []string{
+				"        BASE   2000",
+				"        +JSUB  L2000",
+				"L2000   J      @L2000",
+			},
+		},
+		{
+			name: "Plain SIC",
+			source: `copy  START 4096
+      SIC
+first STL   retadr
+      RSUB
+eof   BYTE  C'EOF'
+retadr RESW 1
+      END   first
+`,
+			lines: []string{
+				"        SIC",
+				"        STL    D1009",
+				"        BYTE   X'454F46'",
+			},
+		},
+		{
+			name: "Data after code without halt",
+			source: `prog  START 0
+      LDA   #3
+      STA   x
+      LDT   y
+x     WORD  77808
+y     WORD  -400
+      BYTE  X'FFFFFF'
+buf   RESB  16
+      END   prog
+`,
+			lines: []string{
+				"        STA    D0009",
+				"D0009   BYTE   X'012FF0'",
+				"        RESB   16",
+			},
+		},
+		{
+			name: "Format 3 followed by floats",
+			source: `prog  START 0
+      LDA   #0
+x     FLOT  0.5
+y     FLOT  2
+      END   prog
+`,
+			lines: []string{
+				"        LDA    #0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, tt.source, assembly.Options{})
+
+			// Loaded without symbols, as from an object file
+			var objFile bytes.Buffer
+			WriteObjFile(&objFile)
+			expected := objFile.String()
+			base.ResetMemory()
+			ResetDissasembly()
+			ProgramName, StartPC, Disassembly, Segments, _, ProgramBounds = bytecode.LoadProgram(&objFile)
+			UpdateDisassemblyInstructionAddressOperands()
+			UpdateInstructionList()
+			UpdateLabels()
+
+			var asmFile bytes.Buffer
+			WriteAsmFile(&asmFile)
+			for _, line := range tt.lines {
+				if !strings.Contains(asmFile.String(), line+"\n") {
+					t.Errorf("missing line %q in:\n%s", line, asmFile.String())
+				}
+			}
+
+			// Header and text records are the same, symbol names aren't kept
+			assembleSource(t, asmFile.String(), assembly.Options{})
+			var reassembled bytes.Buffer
+			WriteObjFile(&reassembled)
+			expectedRecords := getRecords(expected, "HT")
+			reassembledRecords := getRecords(reassembled.String(), "HT")
+			if strings.Join(reassembledRecords, "\n") != strings.Join(expectedRecords, "\n") {
+				t.Errorf("reassembled records:\n%s\nwant:\n%s\nfrom:\n%s", strings.Join(reassembledRecords, "\n"), strings.Join(expectedRecords, "\n"), asmFile.String())
+			}
+		})
+	}
+}
+
+// Records of an object file of the given types, names in header records are left out
+func getRecords(objFile string, types string) []string {
+	var records []string
+	for _, record := range strings.Split(objFile, "\n") {
+		if record == "" || !strings.ContainsRune(types, rune(record[0])) {
+			continue
+		}
+		if record[0] == 'H' {
+			record = "H" + record[7:]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestFollowCode(t *testing.T) {
+	source := `prog  START 0
+      JSUB  sub
+      J     @vec
+msg   BYTE  X'FFFF4F0000'
+sub   RSUB
+hidden LDA  #5
+halt  J     halt
+vec   WORD  hidden
+      END   prog
+`
+	tests := []struct {
+		name    string
+		runtime []uint32
+		code    []uint32
+		data    []uint32
+	}{
+		{
+			name: "Reachable from entry",
+			code: []uint32{0x000000, 0x000003, 0x00000B},
+			data: []uint32{0x000006, 0x000009, 0x00000E, 0x000011, 0x000014},
+		},
+		{
+			name:    "Indirect jump target found at runtime",
+			runtime: []uint32{0x00000E},
+			code:    []uint32{0x000000, 0x000003, 0x00000B, 0x00000E, 0x000011},
+			data:    []uint32{0x000006, 0x000009, 0x000014},
+		},
+		{
+			name:    "Entry inside data",
+			runtime: []uint32{0x000008},
+			code:    []uint32{0x000000, 0x000003, 0x000008, 0x00000B},
+			data:    []uint32{0x000006, 0x00000E},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, source, assembly.Options{})
+			var objFile bytes.Buffer
+			WriteObjFile(&objFile)
+			base.ResetMemory()
+			ResetDissasembly()
+			_, _, Disassembly, Segments, _, _ = bytecode.LoadProgram(&objFile)
+
+			for _, address := range tt.runtime {
+				bytecode.FollowCode(Disassembly, Segments, units.IntToInt24(int(address)))
+			}
+
+			for _, address := range tt.code {
+				instruction, exists := Disassembly[units.IntToInt24(int(address))]
+				if !exists || instruction.Directive == proc.DirectiveBYTE {
+					t.Errorf("no instruction at %06X", address)
+				}
+			}
+			for _, address := range tt.data {
+				instruction, exists := Disassembly[units.IntToInt24(int(address))]
+				if !exists || instruction.Directive != proc.DirectiveBYTE {
+					t.Errorf("no data at %06X", address)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidateDisassembly(t *testing.T) {
+	source := `prog  START 0
+loop  J     loop
+halt  J     halt
+val   WORD  5
+      END   prog
+`
+	tests := []struct {
+		name     string
+		address  uint32
+		value    units.Int24
+		modified bool
+		target   units.Int24
+	}{
+		{
+			name:     "Patched jump target",
+			address:  0x000000,
+			value:    units.BytesToInt24(0x3F, 0x20, 0x00),
+			modified: true,
+			target:   units.BytesToInt24(0x00, 0x00, 0x03),
+		},
+		{
+			name:    "Data write",
+			address: 0x000006,
+			value:   units.BytesToInt24(0x00, 0x00, 0x07),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembleSource(t, source, assembly.Options{})
+			var objFile bytes.Buffer
+			WriteObjFile(&objFile)
+			base.ResetMemory()
+			ResetDissasembly()
+			_, _, Disassembly, Segments, _, _ = bytecode.LoadProgram(&objFile)
+			UpdateDisassemblyInstructionAddressOperands()
+
+			address := units.IntToInt24(int(tt.address))
+			base.SetWord(address, tt.value)
+			storeAddress := units.BytesToInt24(0x00, 0x00, 0x09)
+			if modified := InvalidateDisassembly(address, units.WORD_SIZE, storeAddress); modified != tt.modified {
+				t.Fatalf("modified = %v, want %v", modified, tt.modified)
+			}
+
+			instruction := Disassembly[address]
+			value := tt.value.Bytes()
+			if !bytes.Equal(instruction.Bytes, value[:]) {
+				t.Errorf("bytes = %X, want %X", instruction.Bytes, value[:])
+			}
+			if !tt.modified {
+				if _, exists := ModifiedCode[address]; exists {
+					t.Errorf("data write at %06X flagged as modified code", tt.address)
+				}
+				return
+			}
+			if ModifiedCode[address] != storeAddress {
+				t.Errorf("store address = %s, want %s", ModifiedCode[address].StringHex(), storeAddress.StringHex())
+			}
+			if instruction.Address != tt.target {
+				t.Errorf("jump target = %s, want %s", instruction.Address.StringHex(), tt.target.StringHex())
+			}
+		})
+	}
+}
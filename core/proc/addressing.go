@@ -76,6 +76,11 @@ func (instruction Instruction) GetNIXBPEBits() (n, i, x, b, p, e bool) {
 		b = instruction.Bytes[1]&0b01000000 > 0
 		p = instruction.Bytes[1]&0b00100000 > 0
 		e = instruction.Bytes[1]&0b00010000 > 0
+
+		// SIC format address bits in place of b, p and e
+		if instruction.Format == InstructionFormatSIC {
+			b, p, e = false, false, false
+		}
 	} else {
 		n = false
 		i = false
@@ -143,19 +148,7 @@ func (instruction Instruction) GetOperandAddress(pc units.Int24) (units.Int24, u
 	indexAddressingMode := GetIndexAdressingModes(x)
 
 	// Get instruction operand address
-	switch instruction.Format {
-	case InstructionFormatSIC:
-		address = units.Int24{0x00, instruction.Bytes[1] & 0b01111111, instruction.Bytes[2]}
-	case InstructionFormat3:
-		// Sign-extend operand
-		if (instruction.Bytes[1] & 0b00001000) > 0 {
-			address = units.Int24{0xFF, (instruction.Bytes[1] & 0b00001111) | 0b11110000, instruction.Bytes[2]}
-		} else {
-			address = units.Int24{0x00, instruction.Bytes[1] & 0b00001111, instruction.Bytes[2]}
-		}
-	case InstructionFormat4:
-		address = units.Int24{instruction.Bytes[1] & 0b00001111, instruction.Bytes[2], instruction.Bytes[3]}
-	}
+	address = instruction.getAddressField(relativeAddressingMode)
 
 	if debugGetOperandAddress {
 		fmt.Println("    Instruction:", instruction.Opcode.String())
@@ -207,11 +200,31 @@ func (instruction Instruction) GetOperandAddress(pc units.Int24) (units.Int24, u
 			fmt.Println("    Operand:", operand.StringHex())
 			fmt.Println()
 		}
+	} else if absoluteAddressingMode == IndirectAbsoluteAddressing {
+		// Destination is stored at the address
+		address = base.GetWord(address)
 	}
 
 	return operand, address, relativeAddressingMode, indexAddressingMode, absoluteAddressingMode
 }
 
+// Address or displacement encoded in the instruction
+func (instruction Instruction) getAddressField(relativeAddressingMode RelativeAddressingMode) units.Int24 {
+	switch instruction.Format {
+	case InstructionFormatSIC:
+		return units.BytesToInt24(0x00, instruction.Bytes[1]&0b01111111, instruction.Bytes[2])
+	case InstructionFormat3:
+		// Sign-extend operand, base-relative displacement is unsigned
+		if (instruction.Bytes[1]&0b00001000) > 0 && relativeAddressingMode != BaseRelativeAddressing {
+			return units.BytesToInt24(0xFF, (instruction.Bytes[1]&0b00001111)|0b11110000, instruction.Bytes[2])
+		}
+		return units.BytesToInt24(0x00, instruction.Bytes[1]&0b00001111, instruction.Bytes[2])
+	case InstructionFormat4:
+		return units.BytesToInt24(instruction.Bytes[1]&0b00001111, instruction.Bytes[2], instruction.Bytes[3])
+	}
+	return units.Int24{}
+}
+
 func GetR1R2FromByte(byte2 byte) (base.RegisterId, base.RegisterId) {
 	r1Id := base.RegisterId(byte2 & 0xF0 >> 4)
 	r2Id := base.RegisterId(byte2 & 0x0F)
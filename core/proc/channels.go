@@ -0,0 +1,242 @@
+package proc
+
+import (
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type ChannelStatus int
+type ChannelCommand byte
+
+// Channel commands are 3 words long: command code and device code
+// in the first word, byte count in the second and memory address in the third
+type Channel struct {
+	Status  ChannelStatus
+	Program units.Int24 // Address of the next channel command
+	Command ChannelCommand
+	Device  base.Device
+	Count   int
+	Address units.Int24
+}
+
+const (
+	ChannelIdle ChannelStatus = iota
+	ChannelBusy
+	ChannelError
+)
+
+const (
+	ChannelHalt  ChannelCommand = 0x00
+	ChannelRead  ChannelCommand = 0x01
+	ChannelWrite ChannelCommand = 0x02
+)
+
+const CHANNEL_COUNT int = 16
+const channelCommandSize int = 3 * units.WORD_SIZE
+
+/*
+IMPLEMENTATION
+*/
+var Channels [CHANNEL_COUNT]Channel
+
+// Number of busy channels, statuses are changed through setStatus
+var busyChannels int
+
+// Called after a channel stores a byte read from its device with the address of
+// its current command, code loaded over decoded instructions is decoded again
+var ChannelStore func(address units.Int24, commandAddress units.Int24)
+
+/*
+OPERATIONS
+*/
+// Channel number is taken from A
+func getChannel() *Channel {
+	number := int(base.GetRegisterA().Bytes()[2])
+	if number >= CHANNEL_COUNT {
+		return nil
+	}
+	return &Channels[number]
+}
+
+// SIO starts the channel program at S on the channel in A,
+// CC is set to = when the channel is already busy
+func StartIO() {
+	channel := getChannel()
+	if channel == nil {
+		base.SetConditionCode(base.ConditionGreater)
+		return
+	}
+	if channel.Status == ChannelBusy {
+		base.SetConditionCode(base.ConditionEqual)
+		return
+	}
+
+	*channel = Channel{Program: base.GetRegisterS()}
+	channel.setStatus(ChannelBusy)
+	channel.fetchCommand()
+	base.SetConditionCode(base.ConditionLess)
+}
+
+// TIO sets CC to < for an idle channel, = for a busy one and > when the last channel program failed
+func TestIO() {
+	channel := getChannel()
+	if channel == nil {
+		base.SetConditionCode(base.ConditionGreater)
+		return
+	}
+	switch channel.Status {
+	case ChannelIdle:
+		base.SetConditionCode(base.ConditionLess)
+	case ChannelBusy:
+		base.SetConditionCode(base.ConditionEqual)
+	case ChannelError:
+		base.SetConditionCode(base.ConditionGreater)
+	}
+}
+
+// HIO stops the channel without an I/O interrupt
+func HaltIO() {
+	channel := getChannel()
+	if channel == nil {
+		base.SetConditionCode(base.ConditionGreater)
+		return
+	}
+	channel.setStatus(ChannelIdle)
+	base.SetConditionCode(base.ConditionLess)
+}
+
+func IsChannelBusy() bool {
+	return busyChannels > 0
+}
+
+// Busy channels transfer a byte each cycle, an I/O interrupt with the channel number
+// as ICODE is raised when a channel program halts or fails. Returns transferred bytes.
+func TickChannels(cycles int) int {
+	transfers := 0
+	for cycle := 0; cycle < cycles && IsChannelBusy(); cycle++ {
+		transfers += tickChannels()
+	}
+	return transfers
+}
+func tickChannels() int {
+	transfers := 0
+	for number := range Channels {
+		channel := &Channels[number]
+		if channel.Status != ChannelBusy {
+			continue
+		}
+		if channel.Count == 0 && channel.Command != ChannelHalt {
+			channel.fetchCommand()
+		}
+
+		var err error
+		switch channel.Command {
+		case ChannelHalt:
+			channel.setStatus(ChannelIdle)
+		case ChannelRead:
+			var readByte byte
+			if readByte, err = base.Read(channel.Device); err == nil {
+				base.SetByte(channel.Address, readByte)
+				if ChannelStore != nil {
+					ChannelStore(channel.Address, units.IntToInt24(int(channel.Program.ToUint32())-channelCommandSize))
+				}
+			}
+		case ChannelWrite:
+			err = base.Write(channel.Device, base.GetByte(channel.Address))
+		default:
+			channel.setStatus(ChannelError)
+		}
+		if err != nil {
+			channel.setStatus(ChannelError)
+		}
+		if channel.Status != ChannelBusy {
+			RaiseInterrupt(InterruptIO, InterruptCode(number))
+			continue
+		}
+
+		channel.Address = units.IntToInt24(int(channel.Address.ToUint32()) + 1)
+		channel.Count--
+		transfers++
+	}
+	return transfers
+}
+
+func (channel *Channel) setStatus(status ChannelStatus) {
+	if channel.Status == ChannelBusy {
+		busyChannels--
+	}
+	if status == ChannelBusy {
+		busyChannels++
+	}
+	channel.Status = status
+}
+
+// Transfers without bytes are skipped
+func (channel *Channel) fetchCommand() {
+	for {
+		command := base.GetWord(channel.Program)
+		channel.Command = ChannelCommand(command.Bytes()[0])
+		channel.Device = base.Device(command.Bytes()[2])
+		channel.Count = int(base.GetWord(units.IntToInt24(int(channel.Program.ToUint32()) + units.WORD_SIZE)).ToUint32())
+		channel.Address = base.GetWord(units.IntToInt24(int(channel.Program.ToUint32()) + 2*units.WORD_SIZE))
+		channel.Program = units.IntToInt24(int(channel.Program.ToUint32()) + channelCommandSize)
+		isTransfer := channel.Command == ChannelRead || channel.Command == ChannelWrite
+		if !isTransfer || channel.Count > 0 {
+			return
+		}
+	}
+}
+
+func ResetChannels() {
+	Channels = [CHANNEL_COUNT]Channel{}
+	busyChannels = 0
+}
+
+func RestoreChannels(channels [CHANNEL_COUNT]Channel) {
+	ResetChannels()
+	for number, channel := range channels {
+		status := channel.Status
+		channel.Status = ChannelIdle
+		Channels[number] = channel
+		Channels[number].setStatus(status)
+	}
+}
+
+func ParseChannelStatus(name string) (ChannelStatus, error) {
+	for _, status := range []ChannelStatus{ChannelIdle, ChannelBusy, ChannelError} {
+		if name == status.String() {
+			return status, nil
+		}
+	}
+	return ChannelIdle, ErrUnknownChannelStatus(name)
+}
+
+/*
+STRINGS
+*/
+func (channelStatus ChannelStatus) String() string {
+	switch channelStatus {
+	case ChannelIdle:
+		return "Idle"
+	case ChannelBusy:
+		return "Busy"
+	case ChannelError:
+		return "Error"
+	}
+	return "Not implemented"
+}
+
+func (channelCommand ChannelCommand) String() string {
+	switch channelCommand {
+	case ChannelHalt:
+		return "Halt"
+	case ChannelRead:
+		return "Read"
+	case ChannelWrite:
+		return "Write"
+	}
+	return "Not implemented"
+}
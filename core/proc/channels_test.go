@@ -0,0 +1,109 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+func TestChannels(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDirectory)
+
+	program := units.BytesToInt24(0x00, 0x01, 0x00)
+	buffer := units.BytesToInt24(0x00, 0x02, 0x00)
+
+	tests := []struct {
+		name    string
+		channel byte
+		command ChannelCommand
+		device  byte
+		input   string
+		memory  string
+		output  string
+	}{
+		{
+			name:    "Write",
+			channel: 0x01,
+			command: ChannelWrite,
+			device:  0xF1,
+			memory:  "HI",
+			output:  "HI",
+		},
+		{
+			name:    "Read",
+			channel: 0x02,
+			command: ChannelRead,
+			device:  0xF2,
+			input:   "AB",
+			memory:  "AB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base.ResetMemory()
+			base.ResetRegisters()
+			base.ResetDevices()
+			ResetInterrupts()
+			ResetChannels()
+
+			deviceFile := fmt.Sprintf("%02X.dev", tt.device)
+			if tt.input != "" {
+				if err := os.WriteFile(deviceFile, []byte(tt.input), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.command == ChannelWrite {
+				for i := 0; i < len(tt.memory); i++ {
+					base.SetByte(units.IntToInt24(int(buffer.ToUint32())+i), tt.memory[i])
+				}
+			}
+
+			// Transfer followed by a halt command
+			base.SetWord(program, units.BytesToInt24(byte(tt.command), 0x00, tt.device))
+			base.SetWord(units.IntToInt24(int(program.ToUint32())+3), units.IntToInt24(len(tt.memory)))
+			base.SetWord(units.IntToInt24(int(program.ToUint32())+6), buffer)
+
+			base.SetRegisterA(units.BytesToInt24(0x00, 0x00, tt.channel))
+			base.SetRegisterS(program)
+			StartIO()
+			StartIO()
+			if base.GetConditionCode() != base.ConditionEqual {
+				t.Errorf("second SIO on a busy channel, CC %s", base.GetConditionCode().String())
+			}
+
+			for i := 0; i < len(tt.memory)+1; i++ {
+				TickChannels(1)
+			}
+			TestIO()
+			if base.GetConditionCode() != base.ConditionLess {
+				t.Errorf("channel not idle after transfer, status %s", Channels[tt.channel].Status.String())
+			}
+			if code := pendingInterrupts[InterruptIO]; len(code) != 1 || code[0] != InterruptCode(tt.channel) {
+				t.Errorf("pending I/O interrupts %v, want channel %d", code, tt.channel)
+			}
+
+			memory := base.GetSlice(buffer, units.IntToInt24(int(buffer.ToUint32())+len(tt.memory)))
+			if string(memory) != tt.memory {
+				t.Errorf("memory = %q, want %q", memory, tt.memory)
+			}
+			if tt.output != "" {
+				base.ResetDevices()
+				output, err := os.ReadFile(deviceFile)
+				if err != nil || string(output) != tt.output {
+					t.Errorf("device output = %q (%v), want %q", output, err, tt.output)
+				}
+			}
+		})
+	}
+}
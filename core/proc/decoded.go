@@ -0,0 +1,113 @@
+package proc
+
+import (
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Instruction decoded once for execution, executing it only reads registers and memory.
+// Addressing modes of the embedded instruction are set from its bytes.
+type DecodedInstruction struct {
+	Instruction
+
+	// PC is advanced by length before execution, unknown instructions don't advance it
+	Length uint32
+	Cycles int
+	Reads  int
+	Writes int
+
+	// Address field of the instruction, sign-extended for PC-relative addressing
+	Displacement uint32
+
+	Store           bool
+	StoreLength     int
+	Jump            bool
+	ConditionalJump bool
+	Device          bool
+}
+
+/*
+OPERATIONS
+*/
+func Decode(instruction Instruction, cycleCosts CycleCosts) DecodedInstruction {
+	decoded := DecodedInstruction{
+		Instruction:     instruction,
+		Cycles:          instruction.GetCycles(cycleCosts),
+		Store:           instruction.IsStoreInstruction(),
+		StoreLength:     instruction.GetStoreLength(),
+		Jump:            instruction.IsJumpInstruction(),
+		ConditionalJump: instruction.IsConditionalJump(),
+		Device:          instruction.IsDeviceInstruction(),
+	}
+	decoded.Reads, decoded.Writes = instruction.GetMemoryAccesses()
+
+	switch instruction.Format {
+	case InstructionFormat1, InstructionFormat2, InstructionFormatSIC, InstructionFormat3, InstructionFormat4:
+		decoded.Length = uint32(len(instruction.Bytes))
+	}
+
+	if instruction.IsFormatSIC34() {
+		n, i, x, b, p, _ := instruction.GetNIXBPEBits()
+		relativeAddressingMode, err := GetRelativeAdressingModes(b, p)
+		if err != nil {
+			relativeAddressingMode = UnkownRelativeAddressing
+		}
+		decoded.RelativeAddressingMode = relativeAddressingMode
+		decoded.IndexAddressingMode = GetIndexAdressingModes(x)
+		decoded.AbsoluteAddressingMode = GetAbsoluteAdressingModes(n, i)
+		decoded.Displacement = instruction.getAddressField(relativeAddressingMode).ToUint32()
+	}
+
+	return decoded
+}
+
+// Same as GetOperandAddress of the instruction, without decoding it again
+func (decoded *DecodedInstruction) GetOperandAddress(pc units.Int24) (units.Int24, units.Int24) {
+	if !decoded.IsFormatSIC34() || decoded.RelativeAddressingMode == UnkownRelativeAddressing {
+		return units.Int24{}, units.Int24{}
+	}
+
+	address := decoded.GetAddress(pc)
+	if decoded.Jump {
+		if decoded.AbsoluteAddressingMode == IndirectAbsoluteAddressing {
+			address = base.GetWord(address)
+		}
+		return units.Int24{}, address
+	}
+
+	switch decoded.AbsoluteAddressingMode {
+	case ImmediateAbsoluteAddressing:
+		return address, address
+	case IndirectAbsoluteAddressing:
+		return base.GetWord(base.GetWord(address)), address
+	}
+	return base.GetWord(address), address
+}
+
+// Address after relative and index addressing, before indirection
+func (decoded *DecodedInstruction) GetAddress(pc units.Int24) units.Int24 {
+	address := decoded.Displacement
+	if decoded.RelativeAddressingMode == DirectRelativeAddressing && !decoded.IndexAddressingMode {
+		return units.Uint32ToInt24(address)
+	}
+	switch decoded.RelativeAddressingMode {
+	case PCRelativeAddressing:
+		address += pc.ToUint32()
+	case BaseRelativeAddressing:
+		address += base.GetRegisterB().ToUint32()
+	}
+	if decoded.IndexAddressingMode {
+		address += base.GetRegisterX().ToUint32()
+	}
+	// Masked as by Int24 addition
+	return units.Uint32ToInt24(address & units.INT24_ADD_MASK)
+}
+
+// Executes with PC already advanced past the instruction
+func (decoded *DecodedInstruction) Execute() error {
+	operand, address := decoded.GetOperandAddress(base.GetRegisterPC())
+	return decoded.execute(operand, address)
+}
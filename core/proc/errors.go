@@ -11,3 +11,19 @@ func ErrInvalidOpcode(opcode Opcode) error {
 func ErrInvalidAddressing() error {
 	return fmt.Errorf("Invalid addressing with b=1 and p=1")
 }
+
+func ErrInvalidCycleCost(name string) error {
+	return fmt.Errorf("Invalid cycle cost of %s", name)
+}
+
+func ErrUnknownInterruptClass(name string) error {
+	return fmt.Errorf("Unknown interrupt class: %s", name)
+}
+
+func ErrUnknownChannelStatus(name string) error {
+	return fmt.Errorf("Unknown channel status: %s", name)
+}
+
+func ErrNoInterruptHandler(interruptClass InterruptClass, interruptCode InterruptCode) error {
+	return fmt.Errorf("%s interrupt %02X has no handler", interruptClass.String(), byte(interruptCode))
+}
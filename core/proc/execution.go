@@ -19,23 +19,30 @@ OPERATIONS
 func compareOperation(r1, r2 units.Int24) {
 	compareRes := r1.Compare(r2)
 	if compareRes == -1 {
-		base.SetRegisterSW(units.Int24{0x00, 0x00, 0x00})
+		base.SetConditionCode(base.ConditionLess)
 	} else if compareRes == 0 {
-		base.SetRegisterSW(units.Int24{0x40, 0x00, 0x00})
+		base.SetConditionCode(base.ConditionEqual)
 	} else {
-		base.SetRegisterSW(units.Int24{0x80, 0x00, 0x00})
+		base.SetConditionCode(base.ConditionGreater)
 	}
 }
-func getCompareFromSW(sw units.Int24) int {
-	return sw.Compare(units.Int24{0x00, 0x00, 0x00})
-}
 
 func (instruction Instruction) Execute() error {
+	operand, address, _, _, _ := instruction.GetOperandAddress(base.GetRegisterPC())
+	return instruction.execute(operand, address)
+}
 
+// Operand and address are used by format SIC, 3 and 4
+func (instruction *Instruction) execute(operand units.Int24, address units.Int24) error {
 	if debugExecuteInstruction {
 		fmt.Printf("Execute Instruction: Opcode %02X - Format %d\n", instruction.Opcode, instruction.Format)
 	}
 
+	if instruction.IsPrivilegedInstruction() && !base.IsSupervisorMode() {
+		RaiseInterrupt(InterruptProgram, InterruptCodePrivilegedInstruction)
+		return nil
+	}
+
 	switch instruction.Format {
 	case InstructionFormat1:
 		if debugExecuteInstruction {
@@ -51,38 +58,47 @@ func (instruction Instruction) Execute() error {
 		if debugExecuteInstruction {
 			fmt.Printf("Instruction: Opcode %02X - Format %d - Bytes [%02X %02X %02X]\n", instruction.Opcode, instruction.Format, instruction.Bytes[0], instruction.Bytes[1], instruction.Bytes[2])
 		}
-		return executeFormatSIC34(instruction)
+		return executeFormatSIC34(instruction, operand, address)
 	case InstructionFormat3:
 		if debugExecuteInstruction {
 			fmt.Printf("Instruction: Opcode %02X - Format %d - Bytes [%02X %02X %02X]\n", instruction.Opcode, instruction.Format, instruction.Bytes[0], instruction.Bytes[1], instruction.Bytes[2])
 		}
-		return executeFormatSIC34(instruction)
+		return executeFormatSIC34(instruction, operand, address)
 	case InstructionFormat4:
 		if debugExecuteInstruction {
 			fmt.Printf("Instruction: Opcode %02X - Format %d - Bytes [%02X %02X %02X %02X]\n", instruction.Opcode, instruction.Format, instruction.Bytes[0], instruction.Bytes[1], instruction.Bytes[2], instruction.Bytes[3])
 		}
-		return executeFormatSIC34(instruction)
+		return executeFormatSIC34(instruction, operand, address)
 	default:
+		RaiseInterrupt(InterruptProgram, InterruptCodeIllegalInstruction)
 		return errors.New("Invalid instruction format")
 	}
 }
 
-func executeFormat1(instruction Instruction) error {
+func executeFormat1(instruction *Instruction) error {
 	switch instruction.Opcode {
 	// TODO: FLOAT
 	case FIX:
 	case FLOAT:
-	// TODO: SYSCALL
 	case HIO:
+		HaltIO()
 	case NORM:
 	case SIO:
+		StartIO()
 	case TIO:
+		TestIO()
 	}
 
 	return nil
 }
 
-func executeFormat2(instruction Instruction) error {
+func executeFormat2(instruction *Instruction) error {
+	// Operand of SVC is a number, not a register
+	if instruction.Opcode == SVC {
+		n, _ := GetR1R2FromByte(instruction.Bytes[1])
+		RaiseInterrupt(InterruptSVC, InterruptCode(n))
+		return nil
+	}
 
 	r1Id, r2Id := GetR1R2FromByte(instruction.Bytes[1])
 	r1, err := r1Id.GetRegister()
@@ -106,7 +122,7 @@ func executeFormat2(instruction Instruction) error {
 	case ADDR:
 		r2Id.SetRegister(r2.Add(r1))
 	case CLEAR:
-		r2Id.SetRegister(units.Int24{0x00, 0x00, 0x00})
+		r2Id.SetRegister(units.BytesToInt24(0x00, 0x00, 0x00))
 	case COMPR:
 		compareOperation(r1, r2)
 	case DIVR:
@@ -127,7 +143,7 @@ func executeFormat2(instruction Instruction) error {
 		i := units.Int24{}
 		for i.Compare(shiftNum) < 0 {
 			shift = shift.ShiftL()
-			i = i.Add(units.Int24{0x00, 0x00, 0x01})
+			i = i.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 		}
 	case SHIFTR:
 		shift, err := r1Id.GetRegister()
@@ -141,22 +157,24 @@ func executeFormat2(instruction Instruction) error {
 		i := units.Int24{}
 		for i.Compare(shiftNum) < 0 {
 			shift = shift.ShiftR()
-			i = i.Add(units.Int24{0x00, 0x00, 0x01})
+			i = i.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 		}
 	case SUBR:
 		r2Id.SetRegister(r2.Sub(r1))
-	// TODO: SYSCALL
-	case SVC:
 	case TIXR:
-		base.SetRegisterX(base.GetRegisterX().Add(units.Int24{0x00, 0x00, 0x01}))
+		base.SetRegisterX(base.GetRegisterX().Add(units.BytesToInt24(0x00, 0x00, 0x01)))
 		compareOperation(base.GetRegisterX(), r1)
 	}
 
 	return nil
 }
 
-func executeFormatSIC34(instruction Instruction) error {
-	operand, address, _, _, _ := instruction.GetOperandAddress(base.GetRegisterPC())
+func executeFormatSIC34(instruction *Instruction, operand units.Int24, address units.Int24) error {
+	// Stores from user mode are checked against storage keys
+	if instruction.IsStoreInstruction() && !base.IsWriteAllowed(address, instruction.GetStoreLength()) {
+		RaiseInterrupt(InterruptProgram, InterruptCodeProtectionViolation)
+		return nil
+	}
 
 	switch instruction.Opcode {
 	case ADD:
@@ -176,15 +194,15 @@ func executeFormatSIC34(instruction Instruction) error {
 	case J:
 		base.SetRegisterPC(address)
 	case JEQ:
-		if getCompareFromSW(base.GetRegisterSW()) == 0 {
+		if base.GetConditionCode() == base.ConditionEqual {
 			base.SetRegisterPC(address)
 		}
 	case JGT:
-		if getCompareFromSW(base.GetRegisterSW()) == 1 {
+		if base.GetConditionCode() == base.ConditionGreater {
 			base.SetRegisterPC(address)
 		}
 	case JLT:
-		if getCompareFromSW(base.GetRegisterSW()) == -1 {
+		if base.GetConditionCode() == base.ConditionLess {
 			base.SetRegisterPC(address)
 		}
 	case JSUB:
@@ -195,7 +213,7 @@ func executeFormatSIC34(instruction Instruction) error {
 	case LDB:
 		base.SetRegisterB(operand)
 	case LDCH:
-		base.SetRegisterA(units.Int24{0x00, 0x00, operand[2]})
+		base.SetRegisterA(units.BytesToInt24(0x00, 0x00, operand.Bytes()[2]))
 	// TODO: FLOAT
 	case LDF:
 	case LDL:
@@ -206,8 +224,8 @@ func executeFormatSIC34(instruction Instruction) error {
 		base.SetRegisterT(operand)
 	case LDX:
 		base.SetRegisterX(operand)
-	// TODO: SYSCALL
 	case LPS:
+		LoadProcessorStatus(address)
 	case MUL:
 		base.SetRegisterA(base.GetRegisterA().Mul(operand))
 	// TODO: FLOAT
@@ -215,23 +233,23 @@ func executeFormatSIC34(instruction Instruction) error {
 	case OR:
 		base.SetRegisterA(base.GetRegisterA().Or(operand))
 	case RD:
-		if readByte, err := base.Read(base.Device(operand[0])); err == nil {
-			base.SetRegisterA(units.Int24{0x00, 0x00, readByte})
+		if readByte, err := base.Read(base.Device(operand.Bytes()[0])); err == nil {
+			base.SetRegisterA(units.BytesToInt24(0x00, 0x00, readByte))
 		}
 	case RSUB:
 		base.SetRegisterPC(base.GetRegisterL())
-	// TODO: SYSCALL
 	case SSK:
+		base.SetStorageKey(address, base.GetRegisterA().Bytes()[2])
 	case STA:
 		base.SetWord(address, base.GetRegisterA())
 	case STB:
 		base.SetWord(address, base.GetRegisterB())
 	case STCH:
-		base.SetByte(address, base.GetRegisterA()[2])
+		base.SetByte(address, base.GetRegisterA().Bytes()[2])
 	// TODO: FLOAT
 	case STF:
-	// TODO: SYSCALL
 	case STI:
+		base.SetRegisterI(base.GetWord(address))
 	case STL:
 		base.SetWord(address, base.GetRegisterL())
 	case STS:
@@ -248,12 +266,17 @@ func executeFormatSIC34(instruction Instruction) error {
 	case SUBF:
 	// TODO: SYSCALL
 	case TD:
-		base.Test()
+		// CC < means the device is ready
+		if base.Test(base.Device(operand.Bytes()[2])) {
+			base.SetConditionCode(base.ConditionLess)
+		} else {
+			base.SetConditionCode(base.ConditionEqual)
+		}
 	case TIX:
-		base.SetRegisterX(base.GetRegisterX().Add(units.Int24{0x00, 0x00, 0x01}))
+		base.SetRegisterX(base.GetRegisterX().Add(units.BytesToInt24(0x00, 0x00, 0x01)))
 		compareOperation(base.GetRegisterX(), operand)
 	case WD:
-		if err := base.Write(base.Device(operand[len(operand)-1]), base.GetRegisterA()[2]); err != nil {
+		if err := base.Write(base.Device(operand.Bytes()[2]), base.GetRegisterA().Bytes()[2]); err != nil {
 			panic(err)
 		}
 	}
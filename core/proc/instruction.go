@@ -229,14 +229,43 @@ func (instruction Instruction) IsJumpInstruction() bool {
 	return false
 }
 
+// Jumps which depend on the condition code
+func (instruction Instruction) IsConditionalJump() bool {
+	switch instruction.Opcode {
+	case JEQ, JGT, JLT:
+		return true
+	}
+	return false
+}
+
 func (instruction Instruction) IsStoreInstruction() bool {
 	switch instruction.Opcode {
-	case STCH, STA, STB, STF, STSW, STT, STX:
+	case STCH, STA, STB, STF, STL, STS, STSW, STT, STX:
 		return true
 	}
 	return false
 }
 
+// Instructions only allowed in supervisor mode
+func (instruction Instruction) IsPrivilegedInstruction() bool {
+	switch instruction.Opcode {
+	case HIO, LPS, RD, SIO, SSK, STI, TD, TIO, WD:
+		return true
+	}
+	return false
+}
+
+// Number of bytes written by a store instruction
+func (instruction Instruction) GetStoreLength() int {
+	switch instruction.Opcode {
+	case STCH:
+		return 1
+	case STF:
+		return 6
+	}
+	return units.WORD_SIZE
+}
+
 func (instruction Instruction) IsFormatSIC34() bool {
 	return instruction.Format == InstructionFormatSIC || instruction.Format == InstructionFormat3 || instruction.Format == InstructionFormat4
 }
@@ -250,6 +279,7 @@ func (instruction Instruction) GetInstructionBytes() []byte {
 			return 0
 		}
 	}
+	address := instruction.Address.Bytes()
 
 	switch instruction.Format {
 	case InstructionFormat1:
@@ -259,18 +289,25 @@ func (instruction Instruction) GetInstructionBytes() []byte {
 		byte1 := byte(instruction.Opcode)
 		byte2 := byte((instruction.R1&0x0F)<<4 | (instruction.R2 & 0x0F))
 		return []byte{byte1, byte2}
+	case InstructionFormatSIC:
+		// n=i=0, 15-bit address follows x bit
+		_, _, x, _, _, _ := instruction.GenerateNIXBPEBits()
+		byte1 := byte(instruction.Opcode)
+		byte2 := byte((toInt(x) << 7) | (address[1] & 0x7F))
+		byte3 := byte(address[2])
+		return []byte{byte1, byte2, byte3}
 	case InstructionFormat3:
 		n, i, x, b, p, e := instruction.GenerateNIXBPEBits()
 		byte1 := byte(instruction.Opcode) | (byte(toInt(n)) << 1) | byte(toInt(i))
-		byte2 := byte((toInt(x) << 7) | (toInt(b) << 6) | (toInt(p) << 5) | (toInt(e) << 4) | (instruction.Address[1] & 0x0F))
-		byte3 := byte(instruction.Address[2])
+		byte2 := byte((toInt(x) << 7) | (toInt(b) << 6) | (toInt(p) << 5) | (toInt(e) << 4) | (address[1] & 0x0F))
+		byte3 := byte(address[2])
 		return []byte{byte1, byte2, byte3}
 	case InstructionFormat4:
 		n, i, x, b, p, e := instruction.GenerateNIXBPEBits()
 		byte1 := byte(instruction.Opcode) | (byte(toInt(n)) << 1) | byte(toInt(i))
-		byte2 := byte((toInt(x) << 7) | (toInt(b) << 6) | (toInt(p) << 5) | (toInt(e) << 4) | (instruction.Address[0] & 0x0F))
-		byte3 := byte(instruction.Address[1])
-		byte4 := byte(instruction.Address[2])
+		byte2 := byte((toInt(x) << 7) | (toInt(b) << 6) | (toInt(p) << 5) | (toInt(e) << 4) | (address[0] & 0x0F))
+		byte3 := byte(address[1])
+		byte4 := byte(address[2])
 		return []byte{byte1, byte2, byte3, byte4}
 	}
 
@@ -0,0 +1,219 @@
+package proc
+
+import (
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+type InterruptClass int
+type InterruptCode byte
+
+// Interrupt classes in order of priority
+const (
+	InterruptSVC InterruptClass = iota
+	InterruptProgram
+	InterruptTimer
+	InterruptIO
+)
+
+// Program interrupt codes
+const (
+	InterruptCodeIllegalInstruction    InterruptCode = 0x00
+	InterruptCodePrivilegedInstruction InterruptCode = 0x01
+	InterruptCodeAddressOutOfRange     InterruptCode = 0x02
+	InterruptCodeProtectionViolation   InterruptCode = 0x03
+	InterruptCodeOverflow              InterruptCode = 0x04
+)
+
+// Work area of each class holds the handler's SW and PC, followed by
+// the save area for the interrupted status, laid out as LPS expects it
+const (
+	WORK_AREA_START  uint32 = 0x100
+	WORK_AREA_SIZE   uint32 = 0x30
+	workAreaNewSW    uint32 = 0x00
+	workAreaNewPC    uint32 = 0x03
+	workAreaSaveArea uint32 = 0x06
+)
+
+/*
+IMPLEMENTATION
+*/
+// Interrupts of the same class are queued, channels may finish one after another
+var pendingInterrupts [InterruptIO + 1][]InterruptCode
+var pendingInterruptCount int
+
+/*
+OPERATIONS
+*/
+func (interruptClass InterruptClass) GetWorkArea() units.Int24 {
+	return units.IntToInt24(int(WORK_AREA_START + uint32(interruptClass)*WORK_AREA_SIZE))
+}
+
+// Timer and I/O interrupts are held pending while masked, SVC and program
+// interrupts are caused by the executed instruction and are always taken
+func (interruptClass InterruptClass) IsMasked() bool {
+	switch interruptClass {
+	case InterruptTimer, InterruptIO:
+		return base.GetInterruptMask()&(0b1000>>interruptClass) == 0
+	}
+	return false
+}
+
+func RaiseInterrupt(interruptClass InterruptClass, interruptCode InterruptCode) {
+	pendingInterrupts[interruptClass] = append(pendingInterrupts[interruptClass], interruptCode)
+	pendingInterruptCount++
+}
+
+func IsInterruptPending(interruptClass InterruptClass) bool {
+	return len(pendingInterrupts[interruptClass]) > 0
+}
+
+// Takes the pending interrupt with the highest priority that isn't masked:
+// the status is saved into the class work area and its handler is started.
+// Work areas without a new SW and PC have no handler, their interrupts are errors.
+func HandleInterrupts() (bool, error) {
+	if pendingInterruptCount == 0 {
+		return false, nil
+	}
+	for interruptClass := InterruptSVC; interruptClass <= InterruptIO; interruptClass++ {
+		if !IsInterruptPending(interruptClass) || interruptClass.IsMasked() {
+			continue
+		}
+		interruptCode := pendingInterrupts[interruptClass][0]
+		pendingInterrupts[interruptClass] = pendingInterrupts[interruptClass][1:]
+		pendingInterruptCount--
+
+		workArea := interruptClass.GetWorkArea().ToUint32()
+		newSW := base.GetWord(units.IntToInt24(int(workArea + workAreaNewSW)))
+		newPC := base.GetWord(units.IntToInt24(int(workArea + workAreaNewPC)))
+		if newSW == (units.Int24{}) && newPC == (units.Int24{}) {
+			return false, ErrNoInterruptHandler(interruptClass, interruptCode)
+		}
+
+		StoreProcessorStatus(units.IntToInt24(int(workArea + workAreaSaveArea)))
+		base.SetRegisterSW(newSW)
+		base.SetRegisterPC(newPC)
+		base.SetInterruptCode(byte(interruptCode))
+		return true, nil
+	}
+	return false, nil
+}
+
+// Idle processor can only be woken up by the timer or a channel
+func IsInterruptExpected() bool {
+	if base.GetRegisterI().ToUint32() != 0 && !InterruptTimer.IsMasked() {
+		return true
+	}
+	return IsChannelBusy() && !InterruptIO.IsMasked()
+}
+
+func IsTimerRunning() bool {
+	return base.GetRegisterI() != units.Int24{}
+}
+
+// Interval timer counts cycles down to zero, when it runs out a timer interrupt is raised
+func TickTimer(cycles int) {
+	timer := int(base.GetRegisterI().ToUint32())
+	if timer == 0 {
+		return
+	}
+	timer = max(timer-cycles, 0)
+	base.SetRegisterI(units.IntToInt24(timer))
+	if timer == 0 {
+		RaiseInterrupt(InterruptTimer, 0x00)
+	}
+}
+
+// Stores SW, PC, A, X, L, B, S, T and F from address on
+func StoreProcessorStatus(address units.Int24) {
+	for _, register := range []units.Int24{
+		base.GetRegisterSW(),
+		base.GetRegisterPC(),
+		base.GetRegisterA(),
+		base.GetRegisterX(),
+		base.GetRegisterL(),
+		base.GetRegisterB(),
+		base.GetRegisterS(),
+		base.GetRegisterT(),
+	} {
+		base.SetWord(address, register)
+		address = units.IntToInt24(int(address.ToUint32()) + units.WORD_SIZE)
+	}
+	f := base.GetRegisterF()
+	for i := 0; i < len(f); i++ {
+		base.SetByte(units.IntToInt24(int(address.ToUint32())+i), f[i])
+	}
+}
+
+// Loads the status stored by StoreProcessorStatus (LPS)
+func LoadProcessorStatus(address units.Int24) {
+	for _, setRegister := range []func(units.Int24){
+		base.SetRegisterSW,
+		base.SetRegisterPC,
+		base.SetRegisterA,
+		base.SetRegisterX,
+		base.SetRegisterL,
+		base.SetRegisterB,
+		base.SetRegisterS,
+		base.SetRegisterT,
+	} {
+		setRegister(base.GetWord(address))
+		address = units.IntToInt24(int(address.ToUint32()) + units.WORD_SIZE)
+	}
+	var f units.Float48
+	for i := 0; i < len(f); i++ {
+		f[i] = base.GetByte(units.IntToInt24(int(address.ToUint32()) + i))
+	}
+	base.SetRegisterF(f)
+}
+
+// Copy of the queued interrupts of each class
+func GetPendingInterrupts() [InterruptIO + 1][]InterruptCode {
+	var pending [InterruptIO + 1][]InterruptCode
+	for interruptClass := range pendingInterrupts {
+		pending[interruptClass] = append([]InterruptCode{}, pendingInterrupts[interruptClass]...)
+	}
+	return pending
+}
+
+func RestoreInterrupts(pending [InterruptIO + 1][]InterruptCode) {
+	pendingInterruptCount = 0
+	for interruptClass := range pending {
+		pendingInterrupts[interruptClass] = append([]InterruptCode{}, pending[interruptClass]...)
+		pendingInterruptCount += len(pending[interruptClass])
+	}
+}
+
+func ResetInterrupts() {
+	pendingInterrupts = [InterruptIO + 1][]InterruptCode{}
+	pendingInterruptCount = 0
+}
+
+func ParseInterruptClass(name string) (InterruptClass, error) {
+	for interruptClass := InterruptSVC; interruptClass <= InterruptIO; interruptClass++ {
+		if name == interruptClass.String() {
+			return interruptClass, nil
+		}
+	}
+	return InterruptSVC, ErrUnknownInterruptClass(name)
+}
+
+/*
+STRINGS
+*/
+func (interruptClass InterruptClass) String() string {
+	switch interruptClass {
+	case InterruptSVC:
+		return "SVC"
+	case InterruptProgram:
+		return "Program"
+	case InterruptTimer:
+		return "Timer"
+	case InterruptIO:
+		return "I/O"
+	}
+	return "Not implemented"
+}
@@ -0,0 +1,117 @@
+package proc
+
+import (
+	"testing"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+func TestHandleInterrupts(t *testing.T) {
+	handlerPC := units.BytesToInt24(0x00, 0x20, 0x00)
+	userPC := units.BytesToInt24(0x00, 0x10, 0x03)
+
+	tests := []struct {
+		name          string
+		sw            units.Int24
+		timer         units.Int24
+		instruction   Instruction
+		taken         bool
+		interruptCode byte
+		class         InterruptClass
+		noHandler     bool
+	}{
+		{
+			name:          "SVC",
+			instruction:   Instruction{Opcode: SVC, Format: InstructionFormat2, Bytes: []byte{0xB0, 0x50}},
+			taken:         true,
+			interruptCode: 0x05,
+			class:         InterruptSVC,
+		},
+		{
+			name:          "Privileged instruction in user mode",
+			instruction:   Instruction{Opcode: TD, Format: InstructionFormat3, Bytes: []byte{0xE1, 0x00, 0x00}},
+			taken:         true,
+			interruptCode: byte(InterruptCodePrivilegedInstruction),
+			class:         InterruptProgram,
+		},
+		{
+			name:          "Store to a block of another process",
+			sw:            units.BytesToInt24(0x04, 0x00, 0x00),
+			instruction:   Instruction{Opcode: STA, Format: InstructionFormatSIC, Bytes: []byte{0x0C, 0x30, 0x00}},
+			taken:         true,
+			interruptCode: byte(InterruptCodeProtectionViolation),
+			class:         InterruptProgram,
+		},
+		{
+			name:        "Illegal instruction without a handler",
+			instruction: Instruction{Format: InstructionUnknown, Bytes: []byte{0xFF}},
+			class:       InterruptProgram,
+			noHandler:   true,
+		},
+		{
+			name:  "Masked timer",
+			timer: units.BytesToInt24(0x00, 0x00, 0x01),
+			class: InterruptTimer,
+		},
+		{
+			name:  "Timer",
+			sw:    units.BytesToInt24(0x00, 0x20, 0x00),
+			timer: units.BytesToInt24(0x00, 0x00, 0x01),
+			taken: true,
+			class: InterruptTimer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base.ResetMemory()
+			base.ResetRegisters()
+			ResetInterrupts()
+
+			workArea := tt.class.GetWorkArea()
+			if !tt.noHandler {
+				base.SetWord(workArea, units.BytesToInt24(0x80, 0x00, 0x00))
+				base.SetWord(units.IntToInt24(int(workArea.ToUint32()+workAreaNewPC)), handlerPC)
+			}
+			base.SetRegisterSW(tt.sw)
+			base.SetRegisterPC(userPC)
+			base.SetRegisterI(tt.timer)
+
+			if tt.instruction.Bytes != nil {
+				tt.instruction.Execute()
+			}
+			TickTimer(1)
+
+			taken, err := HandleInterrupts()
+			if taken != tt.taken || (err != nil) != tt.noHandler {
+				t.Fatalf("HandleInterrupts() = %v, %v, want %v", taken, err, tt.taken)
+			}
+			if tt.noHandler {
+				if base.GetRegisterPC() != userPC || base.GetRegisterSW() != tt.sw {
+					t.Errorf("status changed, PC = %s, SW = %s", base.GetRegisterPC().StringHex(), base.GetRegisterSW().StringHex())
+				}
+				return
+			}
+			if !tt.taken {
+				if !IsInterruptPending(tt.class) {
+					t.Errorf("%s interrupt isn't pending", tt.class.String())
+				}
+				return
+			}
+
+			if base.GetRegisterPC() != handlerPC || !base.IsSupervisorMode() {
+				t.Errorf("handler not started, PC = %s, SW = %s", base.GetRegisterPC().StringHex(), base.StatusString())
+			}
+			if base.GetInterruptCode() != tt.interruptCode {
+				t.Errorf("ICODE = %02X, want %02X", base.GetInterruptCode(), tt.interruptCode)
+			}
+
+			// Returning from the handler restores the interrupted status
+			LoadProcessorStatus(units.IntToInt24(int(workArea.ToUint32() + workAreaSaveArea)))
+			if base.GetRegisterPC() != userPC || base.GetRegisterSW() != tt.sw {
+				t.Errorf("status not restored, PC = %s, SW = %s", base.GetRegisterPC().StringHex(), base.GetRegisterSW().StringHex())
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package proc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+/*
+DEFINITIONS
+*/
+// Cycles of an instruction are the sum of its format, opcode and addressing mode costs
+// and of its memory accesses. Files with costs are JSON, missing entries keep their default.
+type CycleCosts struct {
+	Format1   int `json:"format1"`
+	Format2   int `json:"format2"`
+	FormatSIC int `json:"sic"`
+	Format3   int `json:"format3"`
+	Format4   int `json:"format4"`
+
+	// Added to the format cost, by mnemonic
+	Opcodes map[string]int `json:"opcodes"`
+
+	MemoryAccess int `json:"memory"`
+	Immediate    int `json:"immediate"`
+	Indirect     int `json:"indirect"`
+	Indexed      int `json:"indexed"`
+	PCRelative   int `json:"pcRelative"`
+	BaseRelative int `json:"baseRelative"`
+
+	// Cycles a device needs after a read or write, before TD reports it ready
+	DeviceBusy int `json:"deviceBusy"`
+}
+
+/*
+OPERATIONS
+*/
+func GetDefaultCycleCosts() CycleCosts {
+	return CycleCosts{
+		Format1:   1,
+		Format2:   2,
+		FormatSIC: 3,
+		Format3:   3,
+		Format4:   4,
+		Opcodes: map[string]int{
+			MUL.String():   4,
+			DIV.String():   8,
+			MULR.String():  3,
+			DIVR.String():  7,
+			ADDF.String():  4,
+			SUBF.String():  4,
+			COMPF.String(): 4,
+			MULF.String():  8,
+			DIVF.String():  12,
+			FIX.String():   2,
+			FLOAT.String(): 2,
+			NORM.String():  2,
+			RD.String():    4,
+			WD.String():    4,
+			SIO.String():   4,
+			LPS.String():   8,
+			SVC.String():   4,
+		},
+		MemoryAccess: 2,
+		Immediate:    0,
+		Indirect:     1,
+		Indexed:      1,
+		PCRelative:   1,
+		BaseRelative: 1,
+		DeviceBusy:   0,
+	}
+}
+
+// Loads costs over the defaults
+func LoadCycleCosts(file io.Reader) (CycleCosts, error) {
+	cycleCosts := GetDefaultCycleCosts()
+	if err := json.NewDecoder(file).Decode(&cycleCosts); err != nil {
+		return CycleCosts{}, err
+	}
+
+	fields := []struct {
+		name   string
+		cycles int
+	}{
+		{"format1", cycleCosts.Format1},
+		{"format2", cycleCosts.Format2},
+		{"sic", cycleCosts.FormatSIC},
+		{"format3", cycleCosts.Format3},
+		{"format4", cycleCosts.Format4},
+		{"memory", cycleCosts.MemoryAccess},
+		{"immediate", cycleCosts.Immediate},
+		{"indirect", cycleCosts.Indirect},
+		{"indexed", cycleCosts.Indexed},
+		{"pcRelative", cycleCosts.PCRelative},
+		{"baseRelative", cycleCosts.BaseRelative},
+		{"deviceBusy", cycleCosts.DeviceBusy},
+	}
+	for _, field := range fields {
+		if field.cycles < 0 {
+			return CycleCosts{}, ErrInvalidCycleCost(field.name)
+		}
+	}
+
+	opcodes := make(map[string]bool)
+	for opcode := range opcodesFormat1 {
+		opcodes[opcode.String()] = true
+	}
+	for opcode := range opcodesFormat2 {
+		opcodes[opcode.String()] = true
+	}
+	for opcode := range opcodesFormat34 {
+		opcodes[opcode.String()] = true
+	}
+	for mnemonic, cycles := range cycleCosts.Opcodes {
+		if !opcodes[mnemonic] {
+			return CycleCosts{}, ErrInvalidCycleCost(mnemonic)
+		}
+		if cycles < 0 {
+			return CycleCosts{}, ErrInvalidCycleCost(mnemonic)
+		}
+	}
+
+	return cycleCosts, nil
+}
+
+func (instruction Instruction) GetCycles(cycleCosts CycleCosts) int {
+	var cycles int
+	switch instruction.Format {
+	case InstructionFormat1:
+		cycles = cycleCosts.Format1
+	case InstructionFormat2:
+		cycles = cycleCosts.Format2
+	case InstructionFormatSIC:
+		cycles = cycleCosts.FormatSIC
+	case InstructionFormat3:
+		cycles = cycleCosts.Format3
+	case InstructionFormat4:
+		cycles = cycleCosts.Format4
+	default:
+		return 1
+	}
+	cycles += cycleCosts.Opcodes[instruction.Opcode.String()]
+
+	if instruction.IsFormatSIC34() && instruction.Opcode != RSUB {
+		n, i, x, b, p, _ := instruction.GetNIXBPEBits()
+		switch GetAbsoluteAdressingModes(n, i) {
+		case ImmediateAbsoluteAddressing:
+			cycles += cycleCosts.Immediate
+		case IndirectAbsoluteAddressing:
+			cycles += cycleCosts.Indirect
+		}
+		if x {
+			cycles += cycleCosts.Indexed
+		}
+		// SIC format has no b and p bits
+		if instruction.Format == InstructionFormat3 && p {
+			cycles += cycleCosts.PCRelative
+		} else if instruction.Format == InstructionFormat3 && b {
+			cycles += cycleCosts.BaseRelative
+		}
+	}
+
+	reads, writes := instruction.GetMemoryAccesses()
+	return cycles + (reads+writes)*cycleCosts.MemoryAccess
+}
+
+// Operand accesses, instruction fetch is part of the format cost
+func (instruction Instruction) GetMemoryAccesses() (int, int) {
+	if !instruction.IsFormatSIC34() || instruction.Opcode == RSUB {
+		return 0, 0
+	}
+
+	reads, writes := 0, 0
+	n, i, _, _, _, _ := instruction.GetNIXBPEBits()
+	switch GetAbsoluteAdressingModes(n, i) {
+	case ImmediateAbsoluteAddressing:
+		return 0, 0
+	case IndirectAbsoluteAddressing:
+		reads++
+	}
+
+	switch {
+	case instruction.IsStoreInstruction():
+		writes++
+	case instruction.IsJumpInstruction(), instruction.Opcode == SSK:
+		// Only the address is used
+	default:
+		reads++
+	}
+	return reads, writes
+}
+
+func (instruction Instruction) IsDeviceInstruction() bool {
+	switch instruction.Opcode {
+	case HIO, RD, SIO, TD, TIO, WD:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package proc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetCycles(t *testing.T) {
+	tests := []struct {
+		name        string
+		costs       string
+		instruction Instruction
+		cycles      int
+		reads       int
+		writes      int
+	}{
+		{
+			name:        "Format 2",
+			instruction: Instruction{Opcode: TIXR, Format: InstructionFormat2, Bytes: []byte{0xB8, 0x50}},
+			cycles:      2,
+		},
+		{
+			name:        "Immediate",
+			instruction: Instruction{Opcode: TIX, Format: InstructionFormat3, Bytes: []byte{0x2D, 0x00, 0x64}},
+			cycles:      3,
+		},
+		{
+			name:        "PC-relative load",
+			instruction: Instruction{Opcode: LDA, Format: InstructionFormat3, Bytes: []byte{0x03, 0x20, 0x10}},
+			cycles:      6,
+			reads:       1,
+		},
+		{
+			name:        "Indexed indirect store",
+			instruction: Instruction{Opcode: STA, Format: InstructionFormat4, Bytes: []byte{0x0E, 0x90, 0x10, 0x00}},
+			cycles:      10,
+			reads:       1,
+			writes:      1,
+		},
+		{
+			name:        "Costs from file",
+			costs:       `{"format2": 1, "opcodes": {"TIXR": 3}}`,
+			instruction: Instruction{Opcode: TIXR, Format: InstructionFormat2, Bytes: []byte{0xB8, 0x50}},
+			cycles:      4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycleCosts := GetDefaultCycleCosts()
+			if tt.costs != "" {
+				var err error
+				if cycleCosts, err = LoadCycleCosts(strings.NewReader(tt.costs)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if cycles := tt.instruction.GetCycles(cycleCosts); cycles != tt.cycles {
+				t.Errorf("GetCycles() = %d, want %d", cycles, tt.cycles)
+			}
+			if reads, writes := tt.instruction.GetMemoryAccesses(); reads != tt.reads || writes != tt.writes {
+				t.Errorf("GetMemoryAccesses() = %d, %d, want %d, %d", reads, writes, tt.reads, tt.writes)
+			}
+		})
+	}
+}
+
+func TestLoadCycleCosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		costs string
+		valid bool
+	}{
+		{
+			name:  "Valid",
+			costs: `{"format3": 2, "memory": 0, "opcodes": {"MUL": 2}}`,
+			valid: true,
+		},
+		{
+			name:  "Unknown opcode",
+			costs: `{"opcodes": {"NOSUCH": 2}}`,
+		},
+		{
+			name:  "Negative opcode cost",
+			costs: `{"opcodes": {"MUL": -1}}`,
+		},
+		{
+			name:  "Negative format cost",
+			costs: `{"format4": -1}`,
+		},
+		{
+			name:  "Negative addressing cost",
+			costs: `{"pcRelative": -2}`,
+		},
+		{
+			name:  "Negative memory cost",
+			costs: `{"memory": -1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCycleCosts(strings.NewReader(tt.costs))
+			if tt.valid && err != nil {
+				t.Errorf("LoadCycleCosts() = %v, want no error", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("LoadCycleCosts() = nil, want an error")
+			}
+		})
+	}
+}
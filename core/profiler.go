@@ -0,0 +1,220 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Executions of a subroutine are its calls, cycles are spent in the subroutine itself
+type Profile struct {
+	Executions uint64
+	Cycles     uint64
+}
+
+// Name is the label of the address when known
+type Hotspot struct {
+	Address units.Int24
+	Name    string
+	Profile
+}
+
+type HotspotOrder int
+
+const (
+	HotspotsByCycles HotspotOrder = iota
+	HotspotsByExecutions
+	HotspotsByAddress
+)
+
+var HotspotOrders = []HotspotOrder{HotspotsByCycles, HotspotsByExecutions, HotspotsByAddress}
+
+// Profile and branch coverage of an executed instruction
+type instructionRecord struct {
+	Address units.Int24
+	Profile
+	BranchCoverage
+}
+
+/*
+IMPLEMENTATION
+*/
+// Headless runs only profile when they write hotspots or coverage
+var ProfilerEnabled bool = true
+
+// Records of executed instructions in order of their first execution,
+// indexes of the records by address are one based, zero for instructions never executed
+var instructionRecords []instructionRecord
+var instructionRecordIndexes []int32 = make([]int32, base.MEMORY_SIZE)
+var maxInstructionExecutions uint64
+
+// Code outside of subroutines is profiled under the program start
+var subroutineProfiles map[units.Int24]*Profile = make(map[units.Int24]*Profile)
+
+// Profile of the subroutine on top of the call stack, nil after it may have changed
+var currentSubroutineProfile *Profile
+
+/*
+OPERATIONS
+*/
+func getInstructionRecord(address units.Int24) *instructionRecord {
+	index := instructionRecordIndexes[address.ToUint32()]
+	if index == 0 {
+		instructionRecords = append(instructionRecords, instructionRecord{Address: address})
+		index = int32(len(instructionRecords))
+		instructionRecordIndexes[address.ToUint32()] = index
+	}
+	return &instructionRecords[index-1]
+}
+
+// Nil when the instruction was never executed
+func findInstructionRecord(address units.Int24) *instructionRecord {
+	if address.ToUint32() >= uint32(len(instructionRecordIndexes)) || instructionRecordIndexes[address.ToUint32()] == 0 {
+		return nil
+	}
+	return &instructionRecords[instructionRecordIndexes[address.ToUint32()]-1]
+}
+
+// Called after the instruction was executed and before the call stack is updated,
+// JSUB has already set PC to the subroutine
+func profileInstruction(instruction *proc.DecodedInstruction, record *instructionRecord) {
+	record.Executions++
+	record.Cycles += uint64(instruction.Cycles)
+	maxInstructionExecutions = max(maxInstructionExecutions, record.Executions)
+
+	if currentSubroutineProfile == nil {
+		currentSubroutineProfile = getSubroutineProfile(getCurrentSubroutine())
+	}
+	currentSubroutineProfile.Cycles += uint64(instruction.Cycles)
+
+	switch instruction.Opcode {
+	case proc.JSUB:
+		getSubroutineProfile(base.GetRegisterPC()).Executions++
+		currentSubroutineProfile = nil
+	case proc.RSUB:
+		currentSubroutineProfile = nil
+	}
+}
+
+func getSubroutineProfile(subroutine units.Int24) *Profile {
+	profile, exists := subroutineProfiles[subroutine]
+	if !exists {
+		profile = &Profile{}
+		subroutineProfiles[subroutine] = profile
+	}
+	return profile
+}
+
+func GetInstructionProfile(address units.Int24) Profile {
+	if record := findInstructionRecord(address); record != nil {
+		return record.Profile
+	}
+	return Profile{}
+}
+
+func GetInstructionHotspots(order HotspotOrder) []Hotspot {
+	hotspots := make([]Hotspot, 0, len(instructionRecords))
+	for _, record := range instructionRecords {
+		hotspots = append(hotspots, Hotspot{Address: record.Address, Name: loader.StringAddressName(record.Address), Profile: record.Profile})
+	}
+	SortHotspots(hotspots, order)
+	return hotspots
+}
+
+func GetSubroutineHotspots(order HotspotOrder) []Hotspot {
+	hotspots := make([]Hotspot, 0, len(subroutineProfiles))
+	for address, profile := range subroutineProfiles {
+		hotspots = append(hotspots, Hotspot{Address: address, Name: loader.StringAddressName(address), Profile: *profile})
+	}
+	SortHotspots(hotspots, order)
+	return hotspots
+}
+
+func SortHotspots(hotspots []Hotspot, order HotspotOrder) {
+	sort.Slice(hotspots, func(i, j int) bool {
+		a, b := hotspots[i], hotspots[j]
+		switch {
+		case order == HotspotsByCycles && a.Cycles != b.Cycles:
+			return a.Cycles > b.Cycles
+		case order == HotspotsByExecutions && a.Executions != b.Executions:
+			return a.Executions > b.Executions
+		}
+		return a.Address.Compare(b.Address) < 0
+	})
+}
+
+// Share of all cycles spent in the hotspot
+func (hotspot Hotspot) StringShare(cycles uint64) string {
+	if cycles == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(hotspot.Cycles)/float64(cycles))
+}
+
+// Writes subroutines and at most count instructions, 0 writes all of them
+func WriteHotspots(file io.Writer, order HotspotOrder, count int) {
+	fmt.Fprintf(file, "SUBROUTINES\n")
+	fmt.Fprintf(file, "%-12s  %-6s  %10s  %12s  %6s\n", "NAME", "ADDR", "CALLS", "CYCLES", "SHARE")
+	for _, hotspot := range GetSubroutineHotspots(order) {
+		fmt.Fprintf(file, "%-12s  %06X  %10d  %12d  %6s\n", hotspot.Name, hotspot.Address.ToUint32(), hotspot.Executions, hotspot.Cycles, hotspot.StringShare(PerformanceCounters.Cycles))
+	}
+
+	fmt.Fprintf(file, "\nINSTRUCTIONS\n")
+	fmt.Fprintf(file, "%-6s  %-12s  %5s  %10s  %12s  %6s\n", "ADDR", "NAME", "LINE", "EXECUTIONS", "CYCLES", "SHARE")
+	hotspots := GetInstructionHotspots(order)
+	if count > 0 {
+		hotspots = hotspots[:min(count, len(hotspots))]
+	}
+	for _, hotspot := range hotspots {
+		line := "-"
+		if sourceLocation, exists := loader.SourceMap[hotspot.Address]; exists {
+			line = fmt.Sprintf("%d", sourceLocation.Line)
+		}
+		name, _ := loader.GetAddressName(hotspot.Address)
+		fmt.Fprintf(file, "%06X  %-12s  %5s  %10d  %12d  %6s\n", hotspot.Address.ToUint32(), name, line, hotspot.Executions, hotspot.Cycles, hotspot.StringShare(PerformanceCounters.Cycles))
+	}
+}
+
+// Branch coverage is reset with the records
+func ResetProfile() {
+	for _, record := range instructionRecords {
+		instructionRecordIndexes[record.Address.ToUint32()] = 0
+	}
+	instructionRecords = nil
+	maxInstructionExecutions = 0
+	subroutineProfiles = make(map[units.Int24]*Profile)
+	currentSubroutineProfile = nil
+}
+
+func ParseHotspotOrder(name string) (HotspotOrder, error) {
+	for _, order := range HotspotOrders {
+		if strings.EqualFold(order.String(), name) {
+			return order, nil
+		}
+	}
+	return HotspotsByCycles, ErrUnknownHotspotOrder(name)
+}
+
+/*
+STRINGS
+*/
+func (order HotspotOrder) String() string {
+	switch order {
+	case HotspotsByCycles:
+		return "cycles"
+	case HotspotsByExecutions:
+		return "executions"
+	case HotspotsByAddress:
+		return "address"
+	}
+	return "Not implemented"
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sicsimgo/core/units"
+)
+
+func loadTestProgram(t testing.TB, source string) {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "prog.asm")
+	if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProgram(fileName); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileInstruction(t *testing.T) {
+	source := `prog  START 0
+      LDS   #3
+loop  JSUB  work
+      LDA   #1
+      SUBR  A,S
+      COMPR S,A
+      JGT   loop
+halt  J     halt
+work  LDT   #2
+wl    TIXR  T
+      JLT   wl
+      LDX   #0
+      RSUB
+      END   prog
+`
+	loadTestProgram(t, source)
+	if !RunProgram(1000) {
+		t.Fatal("program did not halt")
+	}
+
+	tests := []struct {
+		name       string
+		address    units.Int24
+		executions uint64
+	}{
+		{name: "Main program once", address: units.IntToInt24(0x00), executions: 1},
+		{name: "Loop body", address: units.IntToInt24(0x03), executions: 2},
+		{name: "Subroutine loop", address: units.IntToInt24(0x16), executions: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if profile := GetInstructionProfile(tt.address); profile.Executions != tt.executions {
+				t.Errorf("GetInstructionProfile(%s).Executions = %d, want %d", tt.address.StringHex(), profile.Executions, tt.executions)
+			}
+		})
+	}
+
+	t.Run("Cycles by subroutine", func(t *testing.T) {
+		var cycles uint64
+		for _, hotspot := range GetSubroutineHotspots(HotspotsByAddress) {
+			cycles += hotspot.Cycles
+			if hotspot.Name == "work" && hotspot.Executions != 2 {
+				t.Errorf("calls of work = %d, want 2", hotspot.Executions)
+			}
+		}
+		if cycles != PerformanceCounters.Cycles {
+			t.Errorf("subroutine cycles = %d, want %d", cycles, PerformanceCounters.Cycles)
+		}
+	})
+}
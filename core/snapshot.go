@@ -0,0 +1,585 @@
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/loader/bytecode"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Snapshot file is JSON, addresses and registers are hex strings as in debug files.
+// Memory is zlib compressed. Version is raised when the format changes.
+type snapshotFile struct {
+	Format      string              `json:"format"`
+	Version     int                 `json:"version"`
+	Program     snapshotProgram     `json:"program"`
+	Registers   snapshotRegisters   `json:"registers"`
+	Memory      []byte              `json:"memory"`
+	StorageKeys []byte              `json:"storageKeys"`
+	Devices     []snapshotDevice    `json:"devices"`
+	Channels    []snapshotChannel   `json:"channels"`
+	Interrupts  []snapshotInterrupt `json:"interrupts"`
+	Breakpoints []string            `json:"breakpoints"`
+	Counters    Counters            `json:"counters"`
+}
+
+// Disassembly is stored as the layout of instructions and data, their bytes are in memory
+type snapshotProgram struct {
+	Name        string                `json:"name"`
+	Start       string                `json:"start"`
+	Source      string                `json:"source"`
+	Segments    []snapshotSegment     `json:"segments"`
+	Bounds      snapshotSegment       `json:"bounds"`
+	Disassembly []snapshotInstruction `json:"disassembly"`
+	Lines       []snapshotLine        `json:"lines"`
+	Symbols     []snapshotSymbol      `json:"symbols"`
+}
+
+type snapshotSegment struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type snapshotInstruction struct {
+	Address string `json:"address"`
+	Size    int    `json:"size"`
+	Data    bool   `json:"data,omitempty"`
+}
+
+type snapshotLine struct {
+	Address string `json:"address"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+type snapshotSymbol struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Data    bool   `json:"data,omitempty"`
+	Size    int    `json:"size,omitempty"`
+}
+
+type snapshotRegisters struct {
+	A  string `json:"A"`
+	X  string `json:"X"`
+	L  string `json:"L"`
+	B  string `json:"B"`
+	S  string `json:"S"`
+	T  string `json:"T"`
+	F  string `json:"F"`
+	PC string `json:"PC"`
+	SW string `json:"SW"`
+	I  string `json:"I"`
+}
+
+type snapshotDevice struct {
+	Device string `json:"device"`
+	Cursor int64  `json:"cursor"`
+	Busy   int    `json:"busy,omitempty"`
+}
+
+// Only channels which aren't idle are stored
+type snapshotChannel struct {
+	Number  int    `json:"number"`
+	Status  string `json:"status"`
+	Program string `json:"program"`
+	Command string `json:"command"`
+	Device  string `json:"device"`
+	Count   int    `json:"count"`
+	Address string `json:"address"`
+}
+
+// Pending interrupts in the order they are taken within their class
+type snapshotInterrupt struct {
+	Class string `json:"class"`
+	Code  string `json:"code"`
+}
+
+const (
+	SNAPSHOT_FORMAT    string = "sicsimgo-snapshot"
+	SNAPSHOT_VERSION   int    = 1
+	SNAPSHOT_EXTENSION string = ".snap"
+)
+
+/*
+OPERATIONS
+*/
+// Writes the machine with the loaded program, profile, coverage and the call stack aren't stored
+func WriteSnapshot(file io.Writer) error {
+	memory := base.CopyMemory()
+	compressedMemory, err := compressSnapshotData(memory.Data)
+	if err != nil {
+		return err
+	}
+
+	snapshot := snapshotFile{
+		Format:      SNAPSHOT_FORMAT,
+		Version:     SNAPSHOT_VERSION,
+		Program:     getSnapshotProgram(),
+		Registers:   getSnapshotRegisters(base.GetRegisters()),
+		Memory:      compressedMemory,
+		StorageKeys: memory.Keys,
+		Devices:     []snapshotDevice{},
+		Channels:    []snapshotChannel{},
+		Interrupts:  []snapshotInterrupt{},
+		Breakpoints: []string{},
+		Counters:    PerformanceCounters,
+	}
+
+	deviceStates := base.GetDeviceStates()
+	devices := make([]base.Device, 0, len(deviceStates))
+	for device := range deviceStates {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i] < devices[j]
+	})
+	for _, device := range devices {
+		snapshot.Devices = append(snapshot.Devices, snapshotDevice{
+			Device: fmt.Sprintf("%02X", byte(device)),
+			Cursor: deviceStates[device].Cursor,
+			Busy:   deviceStates[device].Busy,
+		})
+	}
+
+	for number, channel := range proc.Channels {
+		if channel.Status == proc.ChannelIdle {
+			continue
+		}
+		snapshot.Channels = append(snapshot.Channels, snapshotChannel{
+			Number:  number,
+			Status:  channel.Status.String(),
+			Program: stringSnapshotAddress(channel.Program),
+			Command: fmt.Sprintf("%02X", byte(channel.Command)),
+			Device:  fmt.Sprintf("%02X", byte(channel.Device)),
+			Count:   channel.Count,
+			Address: stringSnapshotAddress(channel.Address),
+		})
+	}
+
+	for interruptClass, interruptCodes := range proc.GetPendingInterrupts() {
+		for _, interruptCode := range interruptCodes {
+			snapshot.Interrupts = append(snapshot.Interrupts, snapshotInterrupt{
+				Class: proc.InterruptClass(interruptClass).String(),
+				Code:  fmt.Sprintf("%02X", byte(interruptCode)),
+			})
+		}
+	}
+
+	for _, address := range GetBreakpoints() {
+		snapshot.Breakpoints = append(snapshot.Breakpoints, stringSnapshotAddress(address))
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+func getSnapshotProgram() snapshotProgram {
+	program := snapshotProgram{
+		Name:        loader.ProgramName,
+		Start:       stringSnapshotAddress(loader.StartPC),
+		Source:      loader.SourceFileName,
+		Segments:    []snapshotSegment{},
+		Disassembly: []snapshotInstruction{},
+		Lines:       []snapshotLine{},
+		Symbols:     []snapshotSymbol{},
+	}
+
+	for _, segment := range loader.Segments {
+		program.Segments = append(program.Segments, snapshotSegment{
+			Start: stringSnapshotAddress(segment.Start),
+			End:   stringSnapshotAddress(segment.End),
+		})
+	}
+
+	program.Bounds = snapshotSegment{
+		Start: stringSnapshotAddress(loader.ProgramBounds.Start),
+		End:   stringSnapshotAddress(loader.ProgramBounds.End),
+	}
+
+	for _, instruction := range loader.InstructionList {
+		program.Disassembly = append(program.Disassembly, snapshotInstruction{
+			Address: stringSnapshotAddress(instruction.InstructionAddress),
+			Size:    len(instruction.Bytes),
+			Data:    instruction.Directive == proc.DirectiveBYTE,
+		})
+	}
+
+	lineAddresses := make(units.Int24Slice, 0, len(loader.SourceMap))
+	for address := range loader.SourceMap {
+		lineAddresses = append(lineAddresses, address)
+	}
+	sort.Sort(lineAddresses)
+	for _, address := range lineAddresses {
+		location := loader.SourceMap[address]
+		program.Lines = append(program.Lines, snapshotLine{
+			Address: stringSnapshotAddress(address),
+			File:    location.File,
+			Line:    location.Line,
+		})
+	}
+
+	symbolNames := make([]string, 0, len(loader.SymbolTable))
+	for name := range loader.SymbolTable {
+		symbolNames = append(symbolNames, name)
+	}
+	sort.Strings(symbolNames)
+	for _, name := range symbolNames {
+		symbol := loader.SymbolTable[name]
+		program.Symbols = append(program.Symbols, snapshotSymbol{
+			Name:    symbol.Name,
+			Address: stringSnapshotAddress(symbol.Address),
+			Type:    symbol.Type.String(),
+			Data:    symbol.Data,
+			Size:    symbol.DataLength,
+		})
+	}
+
+	return program
+}
+
+func getSnapshotRegisters(registers base.Registers) snapshotRegisters {
+	return snapshotRegisters{
+		A:  stringSnapshotAddress(registers.A),
+		X:  stringSnapshotAddress(registers.X),
+		L:  stringSnapshotAddress(registers.L),
+		B:  stringSnapshotAddress(registers.B),
+		S:  stringSnapshotAddress(registers.S),
+		T:  stringSnapshotAddress(registers.T),
+		F:  fmt.Sprintf("%X", registers.F[:]),
+		PC: stringSnapshotAddress(registers.PC),
+		SW: stringSnapshotAddress(registers.SW),
+		I:  stringSnapshotAddress(registers.I),
+	}
+}
+
+// Replaces the machine with the snapshot, its program is loaded as bytecode.
+// Nothing is changed when the snapshot is invalid.
+func LoadSnapshot(file io.Reader) (string, error) {
+	var snapshot snapshotFile
+	if err := json.NewDecoder(file).Decode(&snapshot); err != nil {
+		return "", err
+	}
+	if snapshot.Format != SNAPSHOT_FORMAT {
+		return "", ErrNotSnapshot()
+	}
+	if snapshot.Version != SNAPSHOT_VERSION {
+		return "", ErrUnsupportedSnapshotVersion(snapshot.Version)
+	}
+
+	data, err := decompressSnapshotData(snapshot.Memory)
+	if err != nil {
+		return "", err
+	}
+	memory := base.Memory{Data: data, Keys: snapshot.StorageKeys}
+	if err := memory.Validate(); err != nil {
+		return "", err
+	}
+	disassembly, err := getSnapshotDisassembly(memory, snapshot.Program.Disassembly)
+	if err != nil {
+		return "", err
+	}
+	registers, err := parseSnapshotRegisters(snapshot.Registers)
+	if err != nil {
+		return "", err
+	}
+	startPC, err := parseSnapshotAddress(snapshot.Program.Start)
+	if err != nil {
+		return "", err
+	}
+
+	var segments []bytecode.Segment
+	for _, segment := range snapshot.Program.Segments {
+		start, err := parseSnapshotAddress(segment.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := parseSnapshotAddress(segment.End)
+		if err != nil {
+			return "", err
+		}
+		segments = append(segments, bytecode.Segment{Start: start, End: end})
+	}
+
+	var bounds bytecode.Segment
+	if bounds.Start, err = parseSnapshotAddress(snapshot.Program.Bounds.Start); err != nil {
+		return "", err
+	}
+	if bounds.End, err = parseSnapshotAddress(snapshot.Program.Bounds.End); err != nil {
+		return "", err
+	}
+
+	sourceMap := make(map[units.Int24]loader.SourceLocation)
+	for _, line := range snapshot.Program.Lines {
+		address, err := parseSnapshotAddress(line.Address)
+		if err != nil {
+			return "", err
+		}
+		sourceMap[address] = loader.SourceLocation{File: line.File, Line: line.Line}
+	}
+
+	symbolTable := make(assembly.SymbolTable)
+	for _, symbol := range snapshot.Program.Symbols {
+		address, err := parseSnapshotAddress(symbol.Address)
+		if err != nil {
+			return "", err
+		}
+		symbolType, err := assembly.ParseSymbolType(symbol.Type)
+		if err != nil {
+			return "", err
+		}
+		symbolTable[symbol.Name] = assembly.Symbol{
+			Name:       symbol.Name,
+			Address:    address,
+			Type:       symbolType,
+			Data:       symbol.Data,
+			DataLength: symbol.Size,
+		}
+	}
+
+	deviceStates := make(map[base.Device]base.DeviceState)
+	for _, device := range snapshot.Devices {
+		number, err := strconv.ParseUint(device.Device, 16, 8)
+		if err != nil {
+			return "", err
+		}
+		deviceStates[base.Device(number)] = base.DeviceState{Cursor: device.Cursor, Busy: device.Busy}
+	}
+
+	var channels [proc.CHANNEL_COUNT]proc.Channel
+	for _, channel := range snapshot.Channels {
+		if channel.Number < 0 || channel.Number >= proc.CHANNEL_COUNT {
+			return "", ErrInvalidSnapshotChannel(channel.Number)
+		}
+		status, err := proc.ParseChannelStatus(channel.Status)
+		if err != nil {
+			return "", err
+		}
+		program, err := parseSnapshotAddress(channel.Program)
+		if err != nil {
+			return "", err
+		}
+		command, err := strconv.ParseUint(channel.Command, 16, 8)
+		if err != nil {
+			return "", err
+		}
+		device, err := strconv.ParseUint(channel.Device, 16, 8)
+		if err != nil {
+			return "", err
+		}
+		address, err := parseSnapshotAddress(channel.Address)
+		if err != nil {
+			return "", err
+		}
+		channels[channel.Number] = proc.Channel{
+			Status:  status,
+			Program: program,
+			Command: proc.ChannelCommand(command),
+			Device:  base.Device(device),
+			Count:   channel.Count,
+			Address: address,
+		}
+	}
+
+	var interrupts [proc.InterruptIO + 1][]proc.InterruptCode
+	for _, interrupt := range snapshot.Interrupts {
+		interruptClass, err := proc.ParseInterruptClass(interrupt.Class)
+		if err != nil {
+			return "", err
+		}
+		interruptCode, err := strconv.ParseUint(interrupt.Code, 16, 8)
+		if err != nil {
+			return "", err
+		}
+		interrupts[interruptClass] = append(interrupts[interruptClass], proc.InterruptCode(interruptCode))
+	}
+
+	var breakpointAddresses []units.Int24
+	for _, breakpoint := range snapshot.Breakpoints {
+		address, err := parseSnapshotAddress(breakpoint)
+		if err != nil {
+			return "", err
+		}
+		breakpointAddresses = append(breakpointAddresses, address)
+	}
+
+	ResetSim()
+	base.RestoreMemory(memory)
+	base.SetRegisters(registers)
+	base.RestoreDevices(deviceStates)
+	proc.RestoreChannels(channels)
+	proc.RestoreInterrupts(interrupts)
+	for _, address := range breakpointAddresses {
+		SetBreakpoint(address)
+	}
+	PerformanceCounters = snapshot.Counters
+
+	loader.ProgramName = snapshot.Program.Name
+	loader.StartPC = startPC
+	loader.SourceFileName = snapshot.Program.Source
+	loader.SourceMap = sourceMap
+	loader.Segments = segments
+	loader.ProgramBounds = bounds
+	loader.SymbolTable = symbolTable
+	loader.Disassembly = disassembly
+	loader.UpdateDisassemblyInstructionAddressOperands()
+	loader.UpdateInstructionList()
+	loader.UpdateLabels()
+	loader.UpdateSymbolTableList()
+	loader.UpdateCrossReferences()
+
+	LoadedProgramTypeState = loader.Bytecode
+	UpdateProcState(base.GetRegisterPC())
+	return loader.ProgramName, nil
+}
+
+// Instructions are decoded again from the restored memory
+func getSnapshotDisassembly(memory base.Memory, layout []snapshotInstruction) (map[units.Int24]proc.Instruction, error) {
+	disassembly := make(map[units.Int24]proc.Instruction)
+	for _, entry := range layout {
+		address, err := parseSnapshotAddress(entry.Address)
+		if err != nil {
+			return nil, err
+		}
+		end := address.ToUint32() + uint32(entry.Size)
+		if entry.Size <= 0 || end > base.MEMORY_SIZE {
+			return nil, ErrInvalidSnapshotInstruction(entry.Address)
+		}
+		code := append([]byte{}, memory.Data[address.ToUint32():end]...)
+
+		if entry.Data {
+			disassembly[address] = proc.Instruction{
+				InstructionAddress: address,
+				Format:             proc.InstructionUnknown,
+				Directive:          proc.DirectiveBYTE,
+				Bytes:              code,
+			}
+			continue
+		}
+		instructions, _ := bytecode.GetInstructionsFromBinary(address, code)
+		instruction, exists := instructions[address]
+		if !exists || len(instruction.Bytes) != entry.Size {
+			return nil, ErrInvalidSnapshotInstruction(entry.Address)
+		}
+		disassembly[address] = instruction
+	}
+	return disassembly, nil
+}
+
+func SaveSnapshotFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteSnapshot(file)
+}
+
+func LoadSnapshotFile(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	programName, err := LoadSnapshot(file)
+	if err != nil {
+		return "", ErrInvalidSnapshot(fileName, err)
+	}
+	return programName, nil
+}
+
+func IsSnapshotFile(fileName string) bool {
+	return filepath.Ext(fileName) == SNAPSHOT_EXTENSION
+}
+
+func compressSnapshotData(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func decompressSnapshotData(compressed []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(io.LimitReader(reader, int64(base.MEMORY_SIZE)+1))
+}
+
+func parseSnapshotRegisters(snapshotRegisters snapshotRegisters) (base.Registers, error) {
+	var registers base.Registers
+	for _, register := range []struct {
+		value string
+		to    *units.Int24
+	}{
+		{snapshotRegisters.A, &registers.A},
+		{snapshotRegisters.X, &registers.X},
+		{snapshotRegisters.L, &registers.L},
+		{snapshotRegisters.B, &registers.B},
+		{snapshotRegisters.S, &registers.S},
+		{snapshotRegisters.T, &registers.T},
+		{snapshotRegisters.PC, &registers.PC},
+		{snapshotRegisters.SW, &registers.SW},
+		{snapshotRegisters.I, &registers.I},
+	} {
+		value, err := parseSnapshotAddress(register.value)
+		if err != nil {
+			return base.Registers{}, err
+		}
+		*register.to = value
+	}
+
+	f, err := hex.DecodeString(snapshotRegisters.F)
+	if err != nil {
+		return base.Registers{}, err
+	}
+	if len(f) != len(registers.F) {
+		return base.Registers{}, ErrInvalidSnapshotRegister("F", snapshotRegisters.F)
+	}
+	copy(registers.F[:], f)
+
+	return registers, nil
+}
+
+func parseSnapshotAddress(address string) (units.Int24, error) {
+	value, err := strconv.ParseUint(address, 16, 24)
+	if err != nil {
+		return units.Int24{}, err
+	}
+	return units.Uint32ToInt24(uint32(value)), nil
+}
+
+/*
+STRINGS
+*/
+func stringSnapshotAddress(address units.Int24) string {
+	return fmt.Sprintf("%06X", address.ToUint32())
+}
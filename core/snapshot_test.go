@@ -0,0 +1,83 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/units"
+)
+
+func TestSnapshot(t *testing.T) {
+	source := `prog  START 0
+      LDA   #5
+      STA   count
+loop  LDA   count
+      SUB   #1
+      STA   count
+      COMP  #0
+      JGT   loop
+halt  J     halt
+count RESW  1
+      END   prog
+`
+	loadTestProgram(t, source)
+	SetBreakpoint(units.IntToInt24(0x12))
+	RunProgram(10)
+	registers := base.GetRegisters()
+	counters := PerformanceCounters
+	instructionCount := len(loader.InstructionList)
+
+	var snapshot bytes.Buffer
+	if err := WriteSnapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		snapshot string
+		valid    bool
+	}{
+		{name: "Saved snapshot", snapshot: snapshot.String(), valid: true},
+		{name: "Other version", snapshot: strings.Replace(snapshot.String(), `"version": 1`, `"version": 99`, 1), valid: false},
+		{name: "Not a snapshot", snapshot: `{"program": {}}`, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetSim()
+			programName, err := LoadSnapshot(strings.NewReader(tt.snapshot))
+			if !tt.valid {
+				if err == nil {
+					t.Error("invalid snapshot loaded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if programName != "prog" {
+				t.Errorf("program name = %q, want %q", programName, "prog")
+			}
+			if base.GetRegisters() != registers {
+				t.Errorf("registers = %+v, want %+v", base.GetRegisters(), registers)
+			}
+			if PerformanceCounters != counters {
+				t.Errorf("counters = %+v, want %+v", PerformanceCounters, counters)
+			}
+			if len(loader.InstructionList) != instructionCount {
+				t.Errorf("%d instructions, want %d", len(loader.InstructionList), instructionCount)
+			}
+			if !IsBreakpoint(units.IntToInt24(0x12)) {
+				t.Error("breakpoint not restored")
+			}
+
+			// Restored program continues to the same final state
+			if !RunProgram(100) || base.GetWord(loader.SymbolTable["count"].Address) != (units.Int24{}) {
+				t.Error("restored program did not count down")
+			}
+		})
+	}
+}
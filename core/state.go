@@ -30,6 +30,9 @@ var LoadedProgramTypeState loader.LoadedProgramType = loader.None
 var SimExecuteState ExecuteState = ExecuteStopState
 var CurrentProcState ProcState = ProcState{}
 
+// Interrupt without a handler which stopped the program, until the next instruction is executed
+var SimFault error
+
 /*
 DEBUG
 */
@@ -41,6 +44,12 @@ OPERATIONS
 func UpdateProcState(pc units.Int24) {
 	var instruction proc.Instruction
 
+	// Nothing to fetch before a program is loaded
+	if len(loader.Disassembly) == 0 {
+		CurrentProcState = ProcState{}
+		return
+	}
+
 	if debugUpdateProcState {
 		fmt.Println("PC: ", pc.StringHex())
 	}
@@ -72,14 +81,48 @@ func UpdateProcState(pc units.Int24) {
 	}
 }
 
+// Loads a program and prepares it to run from its start address,
+// snapshots continue from the state they were saved in
+func LoadProgram(fileName string) (string, error) {
+	if IsSnapshotFile(fileName) {
+		return LoadSnapshotFile(fileName)
+	}
+	ResetSim()
+	programName, startPC, loadedProgramType, err := loader.LoadProgramFile(fileName)
+	if err != nil || loadedProgramType == loader.None {
+		ResetSim()
+		LoadedProgramTypeState = loader.None
+		return "", err
+	}
+	base.SetRegisterPC(startPC)
+	LoadedProgramTypeState = loadedProgramType
+	UpdateProcState(base.GetRegisterPC())
+	return programName, nil
+}
+
 func StopSim() {
 	SimExecuteState = ExecuteStopState
 }
 
+// Program continuing without the handler would run whatever is at address 0
+func stopByFault(address units.Int24, err error) {
+	SimFault = ErrFault(address, err)
+	StopSim()
+}
+
 func ResetSim() {
 	SimExecuteState = ExecuteStopState
+	SimFault = nil
 	CurrentProcState = ProcState{}
 	loader.ResetDissasembly()
 	base.ResetRegisters()
 	base.ResetMemory()
+	base.ResetDevices()
+	proc.ResetInterrupts()
+	proc.ResetChannels()
+	ResetCounters()
+	ResetProfile()
+	ResetCoverage()
+	ResetCallStack()
+	ResetBreakpoints()
 }
@@ -1,14 +1,79 @@
 package units
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
 
+// 1 sign bit, 11-bit exponent with bias 1024 and 36-bit fraction 0.1xxx...
 type Float48 [6]byte
 
+const (
+	float48ExponentBias  int = 1024
+	float48ExponentMax   int = 2047
+	float48FractionBits  int = 36
+	float48ExponentShift int = 36
+	float48SignShift     int = 47
+)
+
+func (f Float48) toUint64() uint64 {
+	return uint64(f[0])<<40 | uint64(f[1])<<32 | uint64(f[2])<<24 | uint64(f[3])<<16 | uint64(f[4])<<8 | uint64(f[5])
+}
+
+func (f Float48) ToFloat64() float64 {
+	bits := f.toUint64()
+	fraction := bits & (1<<float48FractionBits - 1)
+	exponent := int(bits>>float48ExponentShift) & float48ExponentMax
+	if fraction == 0 {
+		return 0
+	}
+
+	value := math.Ldexp(float64(fraction), exponent-float48ExponentBias-float48FractionBits)
+	if bits>>float48SignShift != 0 {
+		value = -value
+	}
+	return value
+}
+
+// Values too small for the exponent become zero, too large ones the largest Float48
+func Float64ToFloat48(value float64) Float48 {
+	var bits uint64
+	if math.Signbit(value) {
+		bits = 1 << float48SignShift
+		value = -value
+	}
+	if value == 0 || math.IsNaN(value) {
+		return Float48{}
+	}
+
+	fraction, exponent := math.Frexp(value)
+	mantissa := uint64(math.Round(fraction * (1 << float48FractionBits)))
+	if mantissa == 1<<float48FractionBits {
+		// Rounded up to 1.0
+		mantissa >>= 1
+		exponent++
+	}
+
+	exponent += float48ExponentBias
+	if exponent < 0 {
+		return Float48{}
+	}
+	if exponent > float48ExponentMax || math.IsInf(value, 0) {
+		exponent = float48ExponentMax
+		mantissa = 1<<float48FractionBits - 1
+	}
+
+	bits |= uint64(exponent)<<float48ExponentShift | mantissa
+	return Float48{byte(bits >> 40), byte(bits >> 32), byte(bits >> 24), byte(bits >> 16), byte(bits >> 8), byte(bits)}
+}
+
 /*
 STRING
 */
 func (f Float48) StringDec() string {
-	return fmt.Sprintf("TODO: FLOAT") // TODO: FLOAT
+	// 36-bit fraction holds about 11 significant decimal digits
+	return strconv.FormatFloat(f.ToFloat64(), 'g', 11, 64)
 }
 func (f Float48) StringHex() string {
 	return fmt.Sprintf("%02X %02X %02X %02X %02X %02X", f[0], f[1], f[2], f[3], f[4], f[5])
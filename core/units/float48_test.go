@@ -0,0 +1,74 @@
+package units
+
+import "testing"
+
+func TestFloat64ToFloat48(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    float64
+		expected Float48
+	}{
+		{
+			name:     "Zero",
+			input:    0,
+			expected: Float48{0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "One",
+			input:    1,
+			expected: Float48{0x40, 0x18, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "Negative fraction",
+			input:    -0.75,
+			expected: Float48{0xC0, 0x0C, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "Large value",
+			input:    -2.5e3,
+			expected: Float48{0xC0, 0xC9, 0xC4, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Float64ToFloat48(tt.input)
+			if result != tt.expected {
+				t.Errorf("Float64ToFloat48() = %s, want %s", result.StringHex(), tt.expected.StringHex())
+			}
+		})
+	}
+}
+
+func TestFloat48ToFloat64(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    float64
+		expected string
+	}{
+		{
+			name:     "Exact value",
+			input:    -2.5e3,
+			expected: "-2500",
+		},
+		{
+			name:     "Rounded value",
+			input:    3.14,
+			expected: "3.14",
+		},
+		{
+			name:     "Inexact fraction",
+			input:    0.1,
+			expected: "0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Float64ToFloat48(tt.input).StringDec()
+			if result != tt.expected {
+				t.Errorf("StringDec() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
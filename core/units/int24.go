@@ -7,25 +7,31 @@ import (
 
 const (
 	WORD_SIZE int = 3
+
+	INT24_ADD_MASK uint32 = 0x7FFFFF
 )
 
-type Int24 [3]byte
+// Value is kept in the lower 24 bits, the upper bits are always zero
+type Int24 struct {
+	value uint32
+}
 type Int24Slice []Int24
 
 func (i Int24) ToUint32() uint32 {
-	return uint32(i[0])<<16 | uint32(i[1])<<8 | uint32(i[2])
+	return i.value
 }
 
 func (i Int24) IsNegative() bool {
-	return i[0]&0b10000000 != 0
+	return i.value&0x800000 != 0
 }
 func (i Int24) ToInt32() int32 {
-	value := int32(i[0])<<16 | int32(i[1])<<8 | int32(i[2])
 	// Sign-extend the 24-bit value to 32-bit
-	if i.IsNegative() {
-		value |= ^0xFFFFFF // Set the higher bits to 1 for negative numbers
-	}
-	return value
+	return int32(i.value<<8) >> 8
+}
+
+// Bytes of the value, most significant first
+func (i Int24) Bytes() [3]byte {
+	return [3]byte{byte(i.value >> 16), byte(i.value >> 8), byte(i.value)}
 }
 
 func StringToInt24(s string) Int24 {
@@ -33,7 +39,7 @@ func StringToInt24(s string) Int24 {
 		return Int24{}
 	}
 
-	var result Int24
+	var result [3]byte
 	for i := 0; i < 3; i++ {
 		hexByte := s[i*2 : i*2+2]
 		parsedByte, err := strconv.ParseUint(hexByte, 16, 8)
@@ -43,63 +49,32 @@ func StringToInt24(s string) Int24 {
 		result[i] = byte(parsedByte)
 	}
 
-	return result
+	return BytesToInt24(result[0], result[1], result[2])
+}
+
+// Lower 24 bits of value
+func Uint32ToInt24(value uint32) Int24 {
+	return Int24{value: value & 0xFFFFFF}
+}
+
+func BytesToInt24(high byte, middle byte, low byte) Int24 {
+	return Int24{value: uint32(high)<<16 | uint32(middle)<<8 | uint32(low)}
 }
 
 func IntToInt24(i int) Int24 {
-	var result Int24
-	result[0] = byte(i >> 16)
-	if i < 0 {
-		result[0] = result[0] & 0x7F
-	}
-	result[1] = byte(i >> 8)
-	result[2] = byte(i)
-	return result
+	return Uint32ToInt24(uint32(i))
 }
 
 /*
 ARITHMETIC OPERATORS
 */
+// Adds and subtracts as uint32, the most significant bit of the result is cleared
 func (i Int24) Add(other Int24) Int24 {
-	var result Int24
-	var carry byte
-
-	// Add first byte (LSB)
-	sum := i[2] + other[2]
-	carry = (i[2]&other[2] | (i[2]|other[2])&^sum) >> 0x7
-	result[2] = sum
-
-	// Add second byte with carry
-	sum = i[1] + other[1] + carry
-	carry = (i[1]&other[1] | (i[1]|other[1])&^sum) >> 7
-	result[1] = sum
-
-	// Add third byte (MSB) with carry and mask
-	sum = i[0] + other[0] + carry
-	result[0] = sum & 0x7F // Mask to 24 bits
-
-	return result
+	return Int24{value: (i.value + other.value) & INT24_ADD_MASK}
 }
 
 func (i Int24) Sub(other Int24) Int24 {
-	var result Int24
-	var borrow byte
-
-	// Subtract first byte (LSB)
-	diff := i[2] - other[2]
-	borrow = (^i[2]&other[2] | (^i[2]|other[2])&diff) >> 7
-	result[2] = diff
-
-	// Subtract second byte with borrow
-	diff = i[1] - other[1] - borrow
-	borrow = (^i[1]&other[1] | (^i[1]|other[1])&diff) >> 7
-	result[1] = diff
-
-	// Subtract third byte (MSB) with borrow and mask
-	diff = i[0] - other[0] - borrow
-	result[0] = diff & 0x7F // Mask to 24 bits
-
-	return result
+	return Int24{value: (i.value - other.value) & INT24_ADD_MASK}
 }
 
 // Does signed multiplication, masks the result to 24 bits
@@ -114,13 +89,7 @@ func (i Int24) Mul(other Int24) Int24 {
 	// Mask to fit into 24 bits, preserving two's complement
 	product &= 0xFFFFFF // Keep only the lower 24 bits
 
-	// Create new Int24 result
-	var result Int24
-	result[0] = byte((product >> 16) & 0xFF)
-	result[1] = byte((product >> 8) & 0xFF)
-	result[2] = byte(product & 0xFF)
-
-	return result
+	return Uint32ToInt24(uint32(product))
 }
 
 // Does signed division, masks the result to 24 bits
@@ -135,18 +104,12 @@ func (i Int24) Div(other Int24) Int24 {
 	// Mask to fit into 24 bits, preserving two's complement
 	quotient &= 0xFFFFFF // Keep only the lower 24 bits
 
-	// Create new Int24 result
-	var result Int24
-	result[0] = byte((quotient >> 16) & 0xFF)
-	result[1] = byte((quotient >> 8) & 0xFF)
-	result[2] = byte(quotient & 0xFF)
-
-	return result
+	return Uint32ToInt24(uint32(quotient))
 }
 
 func (i Int24) Abs() Int24 {
 	if i.IsNegative() {
-		return i.Mul(Int24{0xFF, 0xFF, 0xFF})
+		return i.Mul(Uint32ToInt24(0xFFFFFF))
 	}
 	return i
 }
@@ -155,67 +118,45 @@ func (i Int24) Abs() Int24 {
 BITWISE LOGICAL OPERATORS
 */
 func (i Int24) And(other Int24) Int24 {
-	var result Int24
-	result[0] = i[0] & other[0]
-	result[1] = i[1] & other[1]
-	result[2] = i[2] & other[2]
-	return result
+	return Int24{value: i.value & other.value}
 }
 
 func (i Int24) Or(other Int24) Int24 {
-	var result Int24
-	result[0] = i[0] | other[0]
-	result[1] = i[1] | other[1]
-	result[2] = i[2] | other[2]
-	return result
+	return Int24{value: i.value | other.value}
 }
 
 func (i Int24) Xor(other Int24) Int24 {
-	var result Int24
-	result[0] = i[0] ^ other[0]
-	result[1] = i[1] ^ other[1]
-	result[2] = i[2] ^ other[2]
-	return result
+	return Int24{value: i.value ^ other.value}
 }
 
 func (i Int24) Not() Int24 {
-	var result Int24
-	result[0] = ^i[0]
-	result[1] = ^i[1]
-	result[2] = ^i[2]
-	return result
+	return Uint32ToInt24(^i.value)
 }
 
 /*
 BITWISE OPERATORS
 */
 func (i Int24) ShiftL() Int24 {
-	var result Int24
-	result[0] = (i[0] << 1) | (i[2] >> 7)
-	result[1] = (i[1] << 1) | (i[0] >> 7)
-	result[2] = (i[2] << 1) | (i[1] >> 7)
-	return result
+	b := i.Bytes()
+	return BytesToInt24((b[0]<<1)|(b[2]>>7), (b[1]<<1)|(b[0]>>7), (b[2]<<1)|(b[1]>>7))
 }
 
 func (i Int24) ShiftR() Int24 {
-	var result Int24
-	result[0] = (i[0] >> 1) | (i[2] << 7)
-	result[1] = (i[1] >> 1) | (i[0] << 7)
-	result[2] = (i[2] >> 1) | (i[1] << 7)
-	return result
+	b := i.Bytes()
+	return BytesToInt24((b[0]>>1)|(b[2]<<7), (b[1]>>1)|(b[0]<<7), (b[2]>>1)|(b[1]<<7))
 }
 
 /*
 LOGICAL OPERATORS
 */
+// Orders values as unsigned, as addresses are sorted
 func (i Int24) Compare(other Int24) int {
-	for idx := 2; idx >= 0; idx-- {
-		if i[idx] < other[idx] {
-			return -1 // i < other
-		}
-		if i[idx] > other[idx] {
-			return 1 // i > other
-		}
+	a, b := i.ToUint32(), other.ToUint32()
+	if a < b {
+		return -1 // i < other
+	}
+	if a > b {
+		return 1 // i > other
 	}
 	return 0 // i == other
 }
@@ -239,8 +180,10 @@ func (i Int24) StringDecSigned() string {
 	return fmt.Sprintf("%d", i.ToInt32())
 }
 func (i Int24) StringHex() string {
-	return fmt.Sprintf("%02X %02X %02X", i[0], i[1], i[2])
+	b := i.Bytes()
+	return fmt.Sprintf("%02X %02X %02X", b[0], b[1], b[2])
 }
 func (i Int24) StringBin() string {
-	return fmt.Sprintf("%08b %08b %08b", i[0], i[1], i[2])
+	b := i.Bytes()
+	return fmt.Sprintf("%08b %08b %08b", b[0], b[1], b[2])
 }
@@ -1,6 +1,9 @@
 package units
 
-import "testing"
+import (
+	"sort"
+	"testing"
+)
 
 func TestToUint32(t *testing.T) {
 	tests := []struct {
@@ -10,17 +13,17 @@ func TestToUint32(t *testing.T) {
 	}{
 		{
 			name:     "Zero value",
-			input:    Int24{0, 0, 0},
+			input:    BytesToInt24(0, 0, 0),
 			expected: 0,
 		},
 		{
 			name:     "Max value",
-			input:    Int24{0x7F, 0xFF, 0xFF},
+			input:    BytesToInt24(0x7F, 0xFF, 0xFF),
 			expected: 0x7FFFFF,
 		},
 		{
 			name:     "Random value",
-			input:    Int24{0x12, 0x34, 0x56},
+			input:    BytesToInt24(0x12, 0x34, 0x56),
 			expected: 0x123456,
 		},
 	}
@@ -43,17 +46,17 @@ func TestIsNegative(t *testing.T) {
 	}{
 		{
 			name:     "Positive number",
-			input:    Int24{0x00, 0x00, 0x01},
+			input:    BytesToInt24(0x00, 0x00, 0x01),
 			expected: false,
 		},
 		{
 			name:     "Negative number",
-			input:    Int24{0x80, 0x00, 0x00},
+			input:    BytesToInt24(0x80, 0x00, 0x00),
 			expected: true,
 		},
 		{
 			name:     "Zero",
-			input:    Int24{0x00, 0x00, 0x00},
+			input:    BytesToInt24(0x00, 0x00, 0x00),
 			expected: false,
 		},
 	}
@@ -76,32 +79,32 @@ func TestToInt32(t *testing.T) {
 	}{
 		{
 			name:     "Positive number",
-			input:    Int24{0x00, 0x00, 0x01},
+			input:    BytesToInt24(0x00, 0x00, 0x01),
 			expected: 1,
 		},
 		{
 			name:     "Negative number",
-			input:    Int24{0x80, 0x00, 0x00},
+			input:    BytesToInt24(0x80, 0x00, 0x00),
 			expected: -8388608, // MinInt24
 		},
 		{
 			name:     "Zero",
-			input:    Int24{0x00, 0x00, 0x00},
+			input:    BytesToInt24(0x00, 0x00, 0x00),
 			expected: 0,
 		},
 		{
 			name:     "Max positive",
-			input:    Int24{0x7F, 0xFF, 0xFF},
+			input:    BytesToInt24(0x7F, 0xFF, 0xFF),
 			expected: 8388607, // MaxInt24
 		},
 		{
 			name:     "Random positive",
-			input:    Int24{0x12, 0x34, 0x56},
+			input:    BytesToInt24(0x12, 0x34, 0x56),
 			expected: 1193046,
 		},
 		{
 			name:     "Random negative",
-			input:    Int24{0xFF, 0xFF, 0xFF},
+			input:    BytesToInt24(0xFF, 0xFF, 0xFF),
 			expected: -1,
 		},
 	}
@@ -125,21 +128,21 @@ func TestAdd(t *testing.T) {
 	}{
 		{
 			name:     "Simple addition",
-			a:        Int24{0x00, 0x00, 0x01},
-			b:        Int24{0x00, 0x00, 0x02},
-			expected: Int24{0x00, 0x00, 0x03},
+			a:        BytesToInt24(0x00, 0x00, 0x01),
+			b:        BytesToInt24(0x00, 0x00, 0x02),
+			expected: BytesToInt24(0x00, 0x00, 0x03),
 		},
 		{
 			name:     "Addition with carry",
-			a:        Int24{0x00, 0xFF, 0xFF},
-			b:        Int24{0x00, 0x00, 0x01},
-			expected: Int24{0x01, 0x00, 0x00},
+			a:        BytesToInt24(0x00, 0xFF, 0xFF),
+			b:        BytesToInt24(0x00, 0x00, 0x01),
+			expected: BytesToInt24(0x01, 0x00, 0x00),
 		},
 		{
 			name:     "Addition with overflow",
-			a:        Int24{0x7F, 0xFF, 0xFF},
-			b:        Int24{0x00, 0x00, 0x01},
-			expected: Int24{0x00, 0x00, 0x00},
+			a:        BytesToInt24(0x7F, 0xFF, 0xFF),
+			b:        BytesToInt24(0x00, 0x00, 0x01),
+			expected: BytesToInt24(0x00, 0x00, 0x00),
 		},
 	}
 
@@ -147,7 +150,7 @@ func TestAdd(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := tt.a.Add(tt.b)
 			if result != tt.expected {
-				t.Errorf("Add() = %v, want %v", result, tt.expected)
+				t.Errorf("Add() = %s, want %s", result.StringHex(), tt.expected.StringHex())
 			}
 		})
 	}
@@ -162,15 +165,15 @@ func TestSub(t *testing.T) {
 	}{
 		{
 			name:     "Simple subtraction",
-			a:        Int24{0x00, 0x00, 0x03},
-			b:        Int24{0x00, 0x00, 0x01},
-			expected: Int24{0x00, 0x00, 0x02},
+			a:        BytesToInt24(0x00, 0x00, 0x03),
+			b:        BytesToInt24(0x00, 0x00, 0x01),
+			expected: BytesToInt24(0x00, 0x00, 0x02),
 		},
 		{
 			name:     "Subtraction with borrow",
-			a:        Int24{0x01, 0x00, 0x00},
-			b:        Int24{0x00, 0x00, 0x01},
-			expected: Int24{0x00, 0xFF, 0xFF},
+			a:        BytesToInt24(0x01, 0x00, 0x00),
+			b:        BytesToInt24(0x00, 0x00, 0x01),
+			expected: BytesToInt24(0x00, 0xFF, 0xFF),
 		},
 	}
 
@@ -178,7 +181,7 @@ func TestSub(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := tt.a.Sub(tt.b)
 			if result != tt.expected {
-				t.Errorf("Sub() = %v, want %v", result, tt.expected)
+				t.Errorf("Sub() = %s, want %s", result.StringHex(), tt.expected.StringHex())
 			}
 		})
 	}
@@ -193,33 +196,33 @@ func TestMul(t *testing.T) {
 	}{
 		{
 			name:     "Simple multiplication",
-			a:        Int24{0x00, 0x00, 0x02},
-			b:        Int24{0x00, 0x00, 0x03},
-			expected: Int24{0x00, 0x00, 0x06},
+			a:        BytesToInt24(0x00, 0x00, 0x02),
+			b:        BytesToInt24(0x00, 0x00, 0x03),
+			expected: BytesToInt24(0x00, 0x00, 0x06),
 		},
 		{
 			name:     "Multiplication with carry",
-			a:        Int24{0x00, 0x01, 0x00},
-			b:        Int24{0x00, 0x00, 0x02},
-			expected: Int24{0x00, 0x02, 0x00},
+			a:        BytesToInt24(0x00, 0x01, 0x00),
+			b:        BytesToInt24(0x00, 0x00, 0x02),
+			expected: BytesToInt24(0x00, 0x02, 0x00),
 		},
 		{
 			name:     "Large multiplication within bounds",
-			a:        Int24{0x00, 0xFF, 0xFF},
-			b:        Int24{0x00, 0x00, 0x02},
-			expected: Int24{0x01, 0xFF, 0xFE},
+			a:        BytesToInt24(0x00, 0xFF, 0xFF),
+			b:        BytesToInt24(0x00, 0x00, 0x02),
+			expected: BytesToInt24(0x01, 0xFF, 0xFE),
 		},
 		{
 			name:     "Multiplication with overflow",
-			a:        Int24{0x7F, 0xFF, 0xFF},
-			b:        Int24{0x00, 0x00, 0x02},
-			expected: Int24{0xFF, 0xFF, 0xFE},
+			a:        BytesToInt24(0x7F, 0xFF, 0xFF),
+			b:        BytesToInt24(0x00, 0x00, 0x02),
+			expected: BytesToInt24(0xFF, 0xFF, 0xFE),
 		},
 		{
 			name:     "Multiplication by zero",
-			a:        Int24{0x12, 0x34, 0x56},
-			b:        Int24{0x00, 0x00, 0x00},
-			expected: Int24{0x00, 0x00, 0x00},
+			a:        BytesToInt24(0x12, 0x34, 0x56),
+			b:        BytesToInt24(0x00, 0x00, 0x00),
+			expected: BytesToInt24(0x00, 0x00, 0x00),
 		},
 	}
 
@@ -227,49 +230,72 @@ func TestMul(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := tt.a.Mul(tt.b)
 			if result != tt.expected {
-				t.Errorf("Mul() = %v, want %v", result, tt.expected)
+				t.Errorf("Mul() = %s, want %s", result.StringHex(), tt.expected.StringHex())
 			}
 		})
 	}
 }
 
 func TestBitwiseOperations(t *testing.T) {
-	a := Int24{0x12, 0x34, 0x56}
-	b := Int24{0x0F, 0x0F, 0x0F}
+	a := BytesToInt24(0x12, 0x34, 0x56)
+	b := BytesToInt24(0x0F, 0x0F, 0x0F)
 
 	t.Run("AND", func(t *testing.T) {
-		expected := Int24{0x02, 0x04, 0x06}
+		expected := BytesToInt24(0x02, 0x04, 0x06)
 		result := a.And(b)
 		if result != expected {
-			t.Errorf("And() = %v, want %v", result, expected)
+			t.Errorf("And() = %s, want %s", result.StringHex(), expected.StringHex())
 		}
 	})
 
 	t.Run("OR", func(t *testing.T) {
-		expected := Int24{0x1F, 0x3F, 0x5F}
+		expected := BytesToInt24(0x1F, 0x3F, 0x5F)
 		result := a.Or(b)
 		if result != expected {
-			t.Errorf("Or() = %v, want %v", result, expected)
+			t.Errorf("Or() = %s, want %s", result.StringHex(), expected.StringHex())
 		}
 	})
 
 	t.Run("XOR", func(t *testing.T) {
-		expected := Int24{0x1D, 0x3B, 0x59}
+		expected := BytesToInt24(0x1D, 0x3B, 0x59)
 		result := a.Xor(b)
 		if result != expected {
-			t.Errorf("Xor() = %v, want %v", result, expected)
+			t.Errorf("Xor() = %s, want %s", result.StringHex(), expected.StringHex())
 		}
 	})
 
 	t.Run("NOT", func(t *testing.T) {
-		expected := Int24{0xED, 0xCB, 0xA9}
+		expected := BytesToInt24(0xED, 0xCB, 0xA9)
 		result := a.Not()
 		if result != expected {
-			t.Errorf("Not() = %v, want %v", result, expected)
+			t.Errorf("Not() = %s, want %s", result.StringHex(), expected.StringHex())
 		}
 	})
 }
 
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Int24
+		bytes [3]byte
+	}{
+		{name: "Positive number", input: IntToInt24(0x123456), bytes: [3]byte{0x12, 0x34, 0x56}},
+		{name: "Negative number", input: IntToInt24(-1), bytes: [3]byte{0xFF, 0xFF, 0xFF}},
+		{name: "Upper bits are dropped", input: Uint32ToInt24(0xAB000001), bytes: [3]byte{0x00, 0x00, 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.input.Bytes(); result != tt.bytes {
+				t.Errorf("Bytes() = %X, want %X", result, tt.bytes)
+			}
+			if result := BytesToInt24(tt.bytes[0], tt.bytes[1], tt.bytes[2]); result != tt.input {
+				t.Errorf("BytesToInt24() = %s, want %s", result.StringHex(), tt.input.StringHex())
+			}
+		})
+	}
+}
+
 func TestCompare(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -279,22 +305,40 @@ func TestCompare(t *testing.T) {
 	}{
 		{
 			name:     "Equal values",
-			a:        Int24{0x12, 0x34, 0x56},
-			b:        Int24{0x12, 0x34, 0x56},
+			a:        BytesToInt24(0x12, 0x34, 0x56),
+			b:        BytesToInt24(0x12, 0x34, 0x56),
 			expected: 0,
 		},
 		{
 			name:     "First greater",
-			a:        Int24{0x12, 0x34, 0x57},
-			b:        Int24{0x12, 0x34, 0x56},
+			a:        BytesToInt24(0x12, 0x34, 0x57),
+			b:        BytesToInt24(0x12, 0x34, 0x56),
 			expected: 1,
 		},
 		{
 			name:     "Second greater",
-			a:        Int24{0x12, 0x34, 0x56},
-			b:        Int24{0x12, 0x34, 0x57},
+			a:        BytesToInt24(0x12, 0x34, 0x56),
+			b:        BytesToInt24(0x12, 0x34, 0x57),
 			expected: -1,
 		},
+		{
+			name:     "Most significant byte decides",
+			a:        BytesToInt24(0x00, 0x10, 0x00),
+			b:        BytesToInt24(0x00, 0x0F, 0xFF),
+			expected: 1,
+		},
+		{
+			name:     "Least significant byte doesn't decide",
+			a:        BytesToInt24(0x01, 0x00, 0x00),
+			b:        BytesToInt24(0x00, 0xFF, 0x01),
+			expected: 1,
+		},
+		{
+			name:     "Unsigned order",
+			a:        BytesToInt24(0x80, 0x00, 0x00),
+			b:        BytesToInt24(0x7F, 0xFF, 0xFF),
+			expected: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -307,6 +351,17 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestSortInt24Slice(t *testing.T) {
+	addresses := Int24Slice{BytesToInt24(0x00, 0x01, 0x00), BytesToInt24(0x10, 0x00, 0x00), BytesToInt24(0x00, 0x00, 0xFF), BytesToInt24(0x01, 0x00, 0x00)}
+	expected := Int24Slice{BytesToInt24(0x00, 0x00, 0xFF), BytesToInt24(0x00, 0x01, 0x00), BytesToInt24(0x01, 0x00, 0x00), BytesToInt24(0x10, 0x00, 0x00)}
+	sort.Sort(addresses)
+	for i := range addresses {
+		if addresses[i] != expected[i] {
+			t.Errorf("sorted[%d] = %s, want %s", i, addresses[i].StringHex(), expected[i].StringHex())
+		}
+	}
+}
+
 func TestString(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -318,7 +373,7 @@ func TestString(t *testing.T) {
 	}{
 		{
 			name:            "Positive number",
-			input:           Int24{0x12, 0x34, 0x56},
+			input:           BytesToInt24(0x12, 0x34, 0x56),
 			wantDecUnsigned: "1193046",
 			wantDecSigned:   "1193046",
 			wantHex:         "12 34 56",
@@ -326,7 +381,7 @@ func TestString(t *testing.T) {
 		},
 		{
 			name:            "Negative number",
-			input:           Int24{0x80, 0x00, 0x01},
+			input:           BytesToInt24(0x80, 0x00, 0x01),
 			wantDecUnsigned: "8388609",
 			wantDecSigned:   "-8388607",
 			wantHex:         "80 00 01",
@@ -334,7 +389,7 @@ func TestString(t *testing.T) {
 		},
 		{
 			name:            "Zero",
-			input:           Int24{0x00, 0x00, 0x00},
+			input:           BytesToInt24(0x00, 0x00, 0x00),
 			wantDecUnsigned: "0",
 			wantDecSigned:   "0",
 			wantHex:         "00 00 00",
@@ -359,3 +414,13 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkAdd(b *testing.B) {
+	sum := Int24{}
+	for i := 0; i < b.N; i++ {
+		sum = sum.Add(BytesToInt24(0x00, 0x01, 0x23))
+	}
+	if sum.Compare(Int24{}) < 0 {
+		b.Fatal("negative sum")
+	}
+}
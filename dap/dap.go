@@ -0,0 +1,470 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"sicsimgo/core"
+	"sicsimgo/core/base"
+	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Session with an editor, the machine is changed only by commands to the controller
+type Server struct {
+	reader     *bufio.Reader
+	writer     io.Writer
+	writeLock  sync.Mutex
+	sequence   int
+	controller *core.Controller
+	launch     launchArguments
+
+	// Used on the controller's goroutine only
+	pauseRequested    bool
+	sourceBreakpoints map[string][]units.Int24
+	output            *outputWriter
+	errorOutput       *outputWriter
+}
+
+// Device output is collected and sent as output events whenever a view is published
+type outputWriter struct {
+	server   *Server
+	category string
+	buffer   []byte
+}
+
+// The machine is the only thread, its stack frames are numbered from the innermost one
+const (
+	THREAD_ID           int = 1
+	REGISTERS_REFERENCE int = 1
+	SYMBOLS_REFERENCE   int = 2
+
+	// Longer data symbols are shown shortened
+	MAX_SYMBOL_BYTES int = 16
+)
+
+/*
+DEBUG
+*/
+const debugMessages bool = false
+
+/*
+OPERATIONS
+*/
+// Serves a session over input and output until the editor disconnects
+func Serve(input io.Reader, output io.Writer) error {
+	server := &Server{
+		reader:            bufio.NewReader(input),
+		writer:            output,
+		sourceBreakpoints: make(map[string][]units.Int24),
+	}
+	server.output = &outputWriter{server: server, category: "stdout"}
+	server.errorOutput = &outputWriter{server: server, category: "stderr"}
+
+	// Standard streams may carry the protocol, programs read nothing from them
+	base.DeviceInput = strings.NewReader("")
+	base.DeviceOutput = server.output
+	base.DeviceErrorOutput = server.errorOutput
+
+	server.controller = core.NewController(server.published)
+	defer server.controller.Quit()
+
+	for {
+		message, err := readMessage(server.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if debugMessages {
+			fmt.Println("<-", message.Command, string(message.Arguments))
+		}
+		if message.Type == "request" && server.handle(message) {
+			return nil
+		}
+	}
+}
+
+// Returns true when the session ends
+func (server *Server) handle(message request) bool {
+	switch message.Command {
+	case "initialize":
+		server.respond(message, capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsTerminateRequest:         true,
+		})
+	case "launch":
+		server.handleLaunch(message)
+	case "setBreakpoints":
+		var arguments setBreakpointsArguments
+		if err := json.Unmarshal(message.Arguments, &arguments); err != nil {
+			server.respondError(message, err)
+			return false
+		}
+		var breakpoints []breakpoint
+		server.call(func() {
+			breakpoints = server.setBreakpoints(arguments)
+		})
+		server.respond(message, map[string][]breakpoint{"breakpoints": breakpoints})
+	case "setExceptionBreakpoints":
+		server.respond(message, nil)
+	case "configurationDone":
+		server.respond(message, nil)
+		if server.launch.StopOnEntry {
+			server.sendEvent("stopped", stoppedEvent{Reason: "entry", ThreadId: THREAD_ID, AllThreadsStopped: true})
+		} else {
+			server.controller.Send(core.Command{Type: core.CommandRun, Stopped: server.stopped("pause", true)})
+		}
+	case "threads":
+		var programName string
+		server.call(func() {
+			programName = loader.ProgramName
+		})
+		server.respond(message, map[string][]thread{"threads": {{Id: THREAD_ID, Name: programName}}})
+	case "stackTrace":
+		var frames []stackFrame
+		server.call(func() {
+			frames = getStackFrames()
+		})
+		server.respond(message, map[string]any{"stackFrames": frames, "totalFrames": len(frames)})
+	case "scopes":
+		server.respond(message, map[string][]scope{"scopes": {
+			{Name: "Registers", VariablesReference: REGISTERS_REFERENCE},
+			{Name: "Symbols", VariablesReference: SYMBOLS_REFERENCE},
+		}})
+	case "variables":
+		var arguments variablesArguments
+		if err := json.Unmarshal(message.Arguments, &arguments); err != nil {
+			server.respondError(message, err)
+			return false
+		}
+		variables := []variable{}
+		server.call(func() {
+			switch arguments.VariablesReference {
+			case REGISTERS_REFERENCE:
+				variables = getRegisterVariables()
+			case SYMBOLS_REFERENCE:
+				variables = getSymbolVariables()
+			}
+		})
+		server.respond(message, map[string][]variable{"variables": variables})
+	case "continue":
+		server.respond(message, map[string]bool{"allThreadsContinued": true})
+		server.controller.Send(core.Command{Type: core.CommandRun, Stopped: server.stopped("pause", true)})
+	case "next":
+		server.respond(message, nil)
+		server.controller.Send(core.Command{Type: core.CommandStepOver, Stopped: server.stopped("step", true)})
+	case "stepIn":
+		server.respond(message, nil)
+		server.controller.Send(core.Command{Type: core.CommandStep, Stopped: server.stopped("step", false)})
+	case "stepOut":
+		server.respond(message, nil)
+		server.controller.Send(core.Command{Type: core.CommandStepOut, Stopped: server.stopped("step", true)})
+	case "pause":
+		server.respond(message, nil)
+		server.controller.Send(core.Command{Type: core.CommandCall, Function: func() {
+			if core.SimExecuteState == core.ExecuteStartState {
+				server.pauseRequested = true
+				core.StopSim()
+			}
+		}})
+	case "disconnect", "terminate":
+		server.controller.Send(core.Command{Type: core.CommandStop})
+		server.call(server.flushOutput)
+		server.respond(message, nil)
+		if message.Command == "terminate" {
+			server.sendEvent("terminated", nil)
+		}
+		return true
+	default:
+		server.respondError(message, ErrUnsupportedRequest(message.Command))
+	}
+	return false
+}
+
+// Editor is told to send breakpoints once the program is loaded
+func (server *Server) handleLaunch(message request) {
+	if err := json.Unmarshal(message.Arguments, &server.launch); err != nil {
+		server.respondError(message, err)
+		return
+	}
+	options := loader.AssemblerOptions
+	if server.launch.Dialect != "" {
+		dialect, err := assembly.ParseDialect(server.launch.Dialect)
+		if err != nil {
+			server.respondError(message, err)
+			return
+		}
+		options.Dialect = dialect
+	}
+	options.AutoExtend = server.launch.AutoExtend
+	options.SIC = server.launch.SIC
+
+	server.controller.Send(core.Command{
+		Type:     core.CommandLoad,
+		FileName: server.launch.Program,
+		Options:  options,
+		Loaded: func(programName string, err error) {
+			if err == nil && core.LoadedProgramTypeState == loader.Assembly {
+				assemblyErrors := assembly.GetErrors(loader.SyntaxNodes)
+				for _, assemblyError := range assemblyErrors {
+					fmt.Fprintf(server.errorOutput, "%s:%d:%d: %s\n", server.launch.Program, assemblyError.LineNumber, assemblyError.Column, assemblyError.Message)
+				}
+				server.flushOutput()
+				if len(assemblyErrors) > 0 {
+					err = ErrAssemblyErrors(len(assemblyErrors))
+				}
+			}
+			if err != nil {
+				server.respondError(message, err)
+				return
+			}
+			server.respond(message, nil)
+			server.sendEvent("initialized", nil)
+		},
+	})
+}
+
+// Returns the function called when the command's step or run ends. Runs report
+// the breakpoint they reached, continued programs which halt have exited.
+func (server *Server) stopped(reason string, run bool) func() {
+	return func() {
+		server.flushOutput()
+		stopped := stoppedEvent{Reason: reason, ThreadId: THREAD_ID, AllThreadsStopped: true}
+		switch {
+		case core.SimFault != nil:
+			stopped.Reason = "exception"
+			stopped.Description = core.SimFault.Error()
+		case server.pauseRequested:
+			stopped.Reason = "pause"
+		case run && core.IsBreakpoint(base.GetRegisterPC()):
+			stopped.Reason = "breakpoint"
+		case reason == "pause":
+			// Continued program only stops by itself when it halts
+			server.sendEvent("exited", exitedEvent{ExitCode: 0})
+			server.sendEvent("terminated", nil)
+			return
+		}
+		server.pauseRequested = false
+		server.sendEvent("stopped", stopped)
+	}
+}
+
+// Output is flushed with each published view
+func (server *Server) published() {
+	server.flushOutput()
+}
+
+func (server *Server) flushOutput() {
+	server.output.flush()
+	server.errorOutput.flush()
+}
+
+// Runs function on the controller and waits for it
+func (server *Server) call(function func()) {
+	done := make(chan struct{})
+	server.controller.Send(core.Command{Type: core.CommandCall, Function: func() {
+		function()
+		close(done)
+	}})
+	<-done
+}
+
+// Breakpoints of a source replace its previous ones, lines without code
+// are moved to the next line with code
+func (server *Server) setBreakpoints(arguments setBreakpointsArguments) []breakpoint {
+	path := getSourcePath(arguments.Source.Path)
+	for _, address := range server.sourceBreakpoints[path] {
+		core.ClearBreakpoint(address)
+	}
+
+	codeLines := getCodeLines(path)
+	lineNumbers := make([]int, 0, len(codeLines))
+	for line := range codeLines {
+		lineNumbers = append(lineNumbers, line)
+	}
+	sort.Ints(lineNumbers)
+
+	breakpoints := []breakpoint{}
+	var addresses []units.Int24
+	for _, sourceBreakpoint := range arguments.Breakpoints {
+		index := sort.SearchInts(lineNumbers, sourceBreakpoint.Line)
+		if index == len(lineNumbers) {
+			breakpoints = append(breakpoints, breakpoint{Verified: false, Message: "No code on or after this line"})
+			continue
+		}
+		line := lineNumbers[index]
+		core.SetBreakpoint(codeLines[line])
+		addresses = append(addresses, codeLines[line])
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: line})
+	}
+	server.sourceBreakpoints[path] = addresses
+	return breakpoints
+}
+
+// Lines of the source which produced instructions, from the assembler's source map
+func getCodeLines(path string) map[int]units.Int24 {
+	codeLines := make(map[int]units.Int24)
+	for address, location := range loader.SourceMap {
+		instruction, exists := loader.Disassembly[address]
+		if !exists || instruction.Directive == proc.DirectiveBYTE || getSourcePath(location.File) != path {
+			continue
+		}
+		codeLines[location.Line] = address
+	}
+	return codeLines
+}
+
+func getSourcePath(fileName string) string {
+	path, err := filepath.Abs(fileName)
+	if err != nil {
+		return filepath.Clean(fileName)
+	}
+	return path
+}
+
+// Innermost frame is at PC, the others at the JSUB which called the frame before them
+func getStackFrames() []stackFrame {
+	callStack := core.GetCallStack()
+	frames := []stackFrame{}
+	address := base.GetRegisterPC()
+	for depth := len(callStack); depth >= 0; depth-- {
+		name := loader.ProgramName
+		if depth > 0 {
+			name = callStack[depth-1].SubroutineName
+		}
+		frame := stackFrame{
+			Id:                          len(frames),
+			Name:                        name,
+			Column:                      1,
+			InstructionPointerReference: fmt.Sprintf("0x%06X", address.ToUint32()),
+		}
+		if location, exists := loader.SourceMap[address]; exists {
+			frame.Source = &source{Name: filepath.Base(location.File), Path: getSourcePath(location.File)}
+			frame.Line = location.Line
+		}
+		frames = append(frames, frame)
+
+		if depth > 0 {
+			address = callStack[depth-1].CallAddress
+		}
+	}
+	return frames
+}
+
+func getRegisterVariables() []variable {
+	registers := base.GetRegisters()
+	variables := []variable{}
+	for _, register := range []struct {
+		name  string
+		value units.Int24
+	}{
+		{"A", registers.A},
+		{"X", registers.X},
+		{"L", registers.L},
+		{"B", registers.B},
+		{"S", registers.S},
+		{"T", registers.T},
+	} {
+		variables = append(variables, variable{Name: register.name, Value: stringWord(register.value), Type: "word"})
+	}
+	variables = append(variables,
+		variable{Name: "F", Value: registers.F.StringDec(), Type: "float"},
+		variable{Name: "PC", Value: fmt.Sprintf("0x%06X %s", registers.PC.ToUint32(), loader.StringAddressName(registers.PC)), Type: "address"},
+		variable{Name: "SW", Value: fmt.Sprintf("0x%06X %s", registers.SW.ToUint32(), base.StringStatus(registers.SW)), Type: "status"},
+	)
+	return variables
+}
+
+// Data symbols show their value in memory, labels and constants their address
+func getSymbolVariables() []variable {
+	names := make([]string, 0, len(loader.SymbolTable))
+	for name := range loader.SymbolTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variables := []variable{}
+	for _, name := range names {
+		symbol := loader.SymbolTable[name]
+		start := int(symbol.Address.ToUint32())
+		length := symbol.DataLength
+		switch {
+		case !symbol.Data:
+			variables = append(variables, variable{Name: name, Value: fmt.Sprintf("0x%06X", start), Type: "label"})
+		case (length == 0 || length == units.WORD_SIZE) && start+units.WORD_SIZE <= int(base.MEMORY_SIZE):
+			variables = append(variables, variable{Name: name, Value: stringWord(base.GetWord(symbol.Address)), Type: "word"})
+		case start < int(base.MEMORY_SIZE):
+			end := min(start+min(length, MAX_SYMBOL_BYTES), int(base.MEMORY_SIZE))
+			value := fmt.Sprintf("%X", base.GetSlice(symbol.Address, units.IntToInt24(end)))
+			if end-start < length {
+				value += "..."
+			}
+			variables = append(variables, variable{Name: name, Value: value, Type: fmt.Sprintf("bytes[%d]", length)})
+		}
+	}
+	return variables
+}
+
+func (server *Server) respond(message request, body any) {
+	server.send(response{Type: "response", RequestSeq: message.Seq, Success: true, Command: message.Command, Body: body})
+}
+
+func (server *Server) respondError(message request, err error) {
+	server.send(response{Type: "response", RequestSeq: message.Seq, Success: false, Command: message.Command, Message: err.Error()})
+}
+
+func (server *Server) sendEvent(name string, body any) {
+	server.send(event{Type: "event", Event: name, Body: body})
+}
+
+// Messages are sent from the reading goroutine and from the controller
+func (server *Server) send(message any) {
+	server.writeLock.Lock()
+	defer server.writeLock.Unlock()
+
+	server.sequence++
+	switch message := message.(type) {
+	case response:
+		message.Seq = server.sequence
+		writeMessage(server.writer, message)
+	case event:
+		message.Seq = server.sequence
+		writeMessage(server.writer, message)
+	}
+	if debugMessages {
+		fmt.Printf("-> %+v\n", message)
+	}
+}
+
+func (writer *outputWriter) Write(data []byte) (int, error) {
+	writer.buffer = append(writer.buffer, data...)
+	return len(data), nil
+}
+
+func (writer *outputWriter) flush() {
+	if len(writer.buffer) == 0 {
+		return
+	}
+	writer.server.sendEvent("output", outputEvent{Category: writer.category, Output: string(writer.buffer)})
+	writer.buffer = nil
+}
+
+/*
+STRINGS
+*/
+func stringWord(value units.Int24) string {
+	return fmt.Sprintf("0x%06X (%s)", value.ToUint32(), value.StringDecSigned())
+}
@@ -0,0 +1,123 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Reads messages until one with the event or command name, returns them encoded
+func receive(t *testing.T, reader *bufio.Reader, name string) string {
+	t.Helper()
+	var received strings.Builder
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := make([]byte, length)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			t.Fatal(err)
+		}
+		var message struct {
+			Event   string `json:"event"`
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal(content, &message); err != nil {
+			t.Fatal(err)
+		}
+		received.Write(content)
+		if message.Event == name || message.Command == name {
+			return received.String()
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	// Subroutine at line 7 writes A to standard output
+	program := `prog  START 0
+      LDA   #72
+      JSUB  out
+
+      LDA   #105
+halt  J     halt
+out   WD    dev
+      RSUB
+dev   BYTE  X'01'
+      END   prog
+`
+	fileName := filepath.Join(t.TempDir(), "prog.asm")
+	if err := os.WriteFile(fileName, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	served := make(chan error)
+	go func() {
+		served <- Serve(serverReader, serverWriter)
+		serverWriter.Close()
+	}()
+	reader := bufio.NewReader(clientReader)
+
+	tests := []struct {
+		name      string
+		command   string
+		arguments any
+		receive   string
+		expected  []string
+		absent    []string
+	}{
+		{"Initialize", "initialize", map[string]string{"adapterID": "sicsimgo"}, "initialize", []string{`"success":true`}, nil},
+		{"Launch", "launch", launchArguments{Program: fileName}, "initialized", nil, nil},
+		{"Breakpoints by line", "setBreakpoints", setBreakpointsArguments{Source: source{Path: fileName}, Breakpoints: []sourceBreakpoint{{Line: 4}, {Line: 7}, {Line: 20}}}, "setBreakpoints", []string{`{"verified":true,"line":5}`, `{"verified":true,"line":7}`, `{"verified":false`}, nil},
+		{"Run to breakpoint", "configurationDone", nil, "stopped", []string{`"reason":"breakpoint"`}, nil},
+		{"Stack trace", "stackTrace", map[string]int{"threadId": THREAD_ID}, "stackTrace", []string{`"name":"out"`, `"line":7`, `"name":"prog"`, `"line":3`, `"totalFrames":2`}, nil},
+		{"Registers", "variables", variablesArguments{VariablesReference: REGISTERS_REFERENCE}, "variables", []string{`{"name":"A","value":"0x000048 (72)"`}, nil},
+		{"Symbols", "variables", variablesArguments{VariablesReference: SYMBOLS_REFERENCE}, "variables", []string{`{"name":"dev","value":"01"`, `{"name":"out","value":"0x00000C"`}, nil},
+		{"Output", "stepOut", map[string]int{"threadId": THREAD_ID}, "stopped", []string{`"category":"stdout","output":"H"`}, nil},
+		{"Step over", "next", map[string]int{"threadId": THREAD_ID}, "stopped", []string{`"reason":"step"`}, nil},
+		{"Halt", "continue", map[string]int{"threadId": THREAD_ID}, "terminated", []string{`"event":"exited","body":{"exitCode":0}`}, []string{`"event":"stopped"`}},
+		{"Unsupported", "evaluate", nil, "evaluate", []string{`"success":false`}, nil},
+		{"Disconnect", "disconnect", nil, "disconnect", []string{`"success":true`}, nil},
+	}
+
+	for sequence, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message := map[string]any{"seq": sequence + 1, "type": "request", "command": test.command}
+			if test.arguments != nil {
+				message["arguments"] = test.arguments
+			}
+			if err := writeMessage(clientWriter, message); err != nil {
+				t.Fatal(err)
+			}
+			received := receive(t, reader, test.receive)
+			for _, expected := range test.expected {
+				if !strings.Contains(received, expected) {
+					t.Errorf("Expected %s in %s", expected, received)
+				}
+			}
+			for _, absent := range test.absent {
+				if strings.Contains(received, absent) {
+					t.Errorf("Unexpected %s in %s", absent, received)
+				}
+			}
+		})
+	}
+
+	clientWriter.Close()
+	go io.Copy(io.Discard, clientReader)
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,17 @@
+package dap
+
+import (
+	"fmt"
+)
+
+func ErrInvalidHeader(contentLength string) error {
+	return fmt.Errorf("Invalid Content-Length: %q", contentLength)
+}
+
+func ErrUnsupportedRequest(command string) error {
+	return fmt.Errorf("Unsupported request: %s", command)
+}
+
+func ErrAssemblyErrors(count int) error {
+	return fmt.Errorf("Program has %d assembly error(s)", count)
+}
@@ -0,0 +1,435 @@
+package gdb
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"sicsimgo/core"
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Connection to a GDB client, the server owns the machine while the client is connected
+type Server struct {
+	conn       net.Conn
+	events     chan event
+	interrupts chan struct{}
+	closed     chan struct{}
+	done       chan struct{}
+
+	noAck       bool
+	lastPacket  string
+	breakpoints map[breakpoint]bool
+}
+
+// Packet received from the client, or a request to send the last packet again
+type event struct {
+	packet string
+	valid  bool
+	resend bool
+}
+
+// Z0 and Z1 breakpoints are both set as core breakpoints
+type breakpoint struct {
+	Type    byte
+	Address units.Int24
+}
+
+type Signal byte
+
+const (
+	SIGINT  Signal = 0x02
+	SIGILL  Signal = 0x04
+	SIGTRAP Signal = 0x05
+)
+
+const (
+	SOFTWARE_BREAKPOINT byte = '0'
+	HARDWARE_BREAKPOINT byte = '1'
+)
+
+const (
+	PACKET_SIZE int  = 0x1000
+	INTERRUPT   byte = 0x03
+
+	// Instructions executed between checks for an interrupt from the client
+	RUN_BATCH_SIZE int = 4096
+)
+
+// Errors of replies, GDB shows only their numbers
+const (
+	replyOK            string = "OK"
+	replyInvalidPacket string = "E01"
+	replyInvalidMemory string = "E02"
+)
+
+/*
+DEBUG
+*/
+const debugPackets bool = false
+
+/*
+OPERATIONS
+*/
+// Serves the first client which connects, until it detaches, kills the program or disconnects
+func Serve(listener net.Listener) error {
+	conn, err := listener.Accept()
+	listener.Close()
+	if err != nil {
+		return err
+	}
+	return ServeConn(conn)
+}
+
+func ServeConn(conn net.Conn) error {
+	defer conn.Close()
+	server := &Server{
+		conn:        conn,
+		events:      make(chan event),
+		interrupts:  make(chan struct{}, 1),
+		closed:      make(chan struct{}),
+		done:        make(chan struct{}),
+		breakpoints: make(map[breakpoint]bool),
+	}
+	defer close(server.done)
+	go server.read(bufio.NewReader(conn))
+	return server.serve()
+}
+
+// Reads packets on its own goroutine, so interrupts arrive while the program runs
+func (server *Server) read(reader *bufio.Reader) {
+	defer close(server.closed)
+	for {
+		received, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		var receivedEvent event
+		switch received {
+		case INTERRUPT:
+			select {
+			case server.interrupts <- struct{}{}:
+			default:
+			}
+			continue
+		case '-':
+			receivedEvent = event{resend: true}
+		case '$':
+			data, err := reader.ReadString('#')
+			if err != nil {
+				return
+			}
+			checksum := make([]byte, 2)
+			if _, err := io.ReadFull(reader, checksum); err != nil {
+				return
+			}
+			data = strings.TrimSuffix(data, "#")
+			expected, err := strconv.ParseUint(string(checksum), 16, 8)
+			receivedEvent = event{packet: data, valid: err == nil && byte(expected) == getChecksum(data)}
+		default:
+			// Acknowledgements need no action
+			continue
+		}
+
+		select {
+		case server.events <- receivedEvent:
+		case <-server.done:
+			return
+		}
+	}
+}
+
+func (server *Server) serve() error {
+	for {
+		var received event
+		select {
+		case received = <-server.events:
+		case <-server.closed:
+			return nil
+		}
+
+		if received.resend {
+			if err := server.write(server.lastPacket); err != nil {
+				return err
+			}
+			continue
+		}
+		if !server.noAck {
+			acknowledgement := "+"
+			if !received.valid {
+				acknowledgement = "-"
+			}
+			if _, err := server.conn.Write([]byte(acknowledgement)); err != nil {
+				return err
+			}
+			if !received.valid {
+				continue
+			}
+		}
+		if debugPackets {
+			fmt.Println("<-", received.packet)
+		}
+
+		// Killed program gets no reply
+		if strings.HasPrefix(received.packet, "k") {
+			return nil
+		}
+		reply := server.handle(received.packet)
+		if err := server.send(reply); err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(received.packet, "D"):
+			return nil
+		case received.packet == "QStartNoAckMode":
+			server.noAck = true
+		}
+	}
+}
+
+func (server *Server) handle(packet string) string {
+	if len(packet) == 0 {
+		return ""
+	}
+	arguments := packet[1:]
+	switch packet[0] {
+	case '?':
+		return getStopReply(SIGTRAP, "")
+	case 'g':
+		return hex.EncodeToString(getRegisters())
+	case 'G':
+		return setRegisters(arguments)
+	case 'p':
+		return readRegister(arguments)
+	case 'P':
+		return writeRegister(arguments)
+	case 'm':
+		return readMemory(arguments)
+	case 'M':
+		return writeMemory(arguments)
+	case 'c':
+		if !setResumeAddress(arguments) {
+			return replyInvalidPacket
+		}
+		return server.resume()
+	case 's':
+		if !setResumeAddress(arguments) {
+			return replyInvalidPacket
+		}
+		core.ExecuteNextInstruction()
+		return getStopReply(getStopSignal(), "")
+	case 'Z', 'z':
+		return server.updateBreakpoint(packet[0] == 'Z', arguments)
+	case 'H', 'T':
+		// Only thread of the machine
+		return replyOK
+	case 'D':
+		return replyOK
+	case 'q', 'Q':
+		return handleQuery(packet)
+	}
+	return ""
+}
+
+func handleQuery(packet string) string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return fmt.Sprintf("PacketSize=%X;QStartNoAckMode+;qXfer:features:read+;swbreak+;hwbreak+", PACKET_SIZE)
+	case packet == "QStartNoAckMode":
+		return replyOK
+	case packet == "qAttached":
+		return "1"
+	case packet == "qfThreadInfo":
+		return "m1"
+	case packet == "qsThreadInfo":
+		return "l"
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return readTargetDescription(strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+	}
+	return ""
+}
+
+// Runs until a breakpoint, the program halts or faults, or the client interrupts it
+func (server *Server) resume() string {
+	// Interrupt sent while the program was stopped isn't meant for this run
+	select {
+	case <-server.interrupts:
+	default:
+	}
+
+	core.SimExecuteState = core.ExecuteStartState
+	for core.SimExecuteState == core.ExecuteStartState {
+		for i := 0; i < RUN_BATCH_SIZE && core.SimExecuteState == core.ExecuteStartState; i++ {
+			core.ExecuteNextInstruction()
+			if core.IsBreakpoint(base.GetRegisterPC()) {
+				core.StopSim()
+				return getStopReply(SIGTRAP, server.getBreakpointReason(base.GetRegisterPC()))
+			}
+		}
+		select {
+		case <-server.interrupts:
+			core.StopSim()
+			return getStopReply(SIGINT, "")
+		case <-server.closed:
+			core.StopSim()
+		default:
+		}
+	}
+	if core.SimFault == nil {
+		return getExitReply(0)
+	}
+	return getStopReply(SIGILL, "")
+}
+
+// Interrupts without a handler are reported as illegal instructions
+func getStopSignal() Signal {
+	if core.SimFault != nil {
+		return SIGILL
+	}
+	return SIGTRAP
+}
+
+// Optional address of c and s packets
+func setResumeAddress(arguments string) bool {
+	if arguments == "" {
+		return true
+	}
+	address, err := parseAddress(arguments)
+	if err != nil {
+		return false
+	}
+	base.SetRegisterPC(address)
+	return true
+}
+
+// Z and z packets are type,address,kind, kind is ignored as instructions aren't patched
+func (server *Server) updateBreakpoint(insert bool, arguments string) string {
+	fields := strings.Split(arguments, ",")
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return replyInvalidPacket
+	}
+	breakpointType := fields[0][0]
+	if breakpointType != SOFTWARE_BREAKPOINT && breakpointType != HARDWARE_BREAKPOINT {
+		// Watchpoints aren't supported
+		return ""
+	}
+	address, err := parseAddress(fields[1])
+	if err != nil {
+		return replyInvalidPacket
+	}
+
+	if insert {
+		server.breakpoints[breakpoint{Type: breakpointType, Address: address}] = true
+		core.SetBreakpoint(address)
+		return replyOK
+	}
+	delete(server.breakpoints, breakpoint{Type: breakpointType, Address: address})
+	if !server.breakpoints[breakpoint{Type: SOFTWARE_BREAKPOINT, Address: address}] && !server.breakpoints[breakpoint{Type: HARDWARE_BREAKPOINT, Address: address}] {
+		core.ClearBreakpoint(address)
+	}
+	return replyOK
+}
+
+// Breakpoints not set by the client, such as from a snapshot, are reported as software breakpoints
+func (server *Server) getBreakpointReason(address units.Int24) string {
+	if server.breakpoints[breakpoint{Type: HARDWARE_BREAKPOINT, Address: address}] && !server.breakpoints[breakpoint{Type: SOFTWARE_BREAKPOINT, Address: address}] {
+		return "hwbreak:;"
+	}
+	return "swbreak:;"
+}
+
+func readMemory(arguments string) string {
+	address, length, ok := parseAddressLength(arguments)
+	if !ok {
+		return replyInvalidPacket
+	}
+	start := address.ToUint32()
+	if start >= base.MEMORY_SIZE {
+		return replyInvalidMemory
+	}
+	end := min(start+uint32(length), base.MEMORY_SIZE)
+	return hex.EncodeToString(base.GetSlice(address, units.Uint32ToInt24(end)))
+}
+
+func writeMemory(arguments string) string {
+	location, encoded, found := strings.Cut(arguments, ":")
+	if !found {
+		return replyInvalidPacket
+	}
+	address, length, ok := parseAddressLength(location)
+	if !ok {
+		return replyInvalidPacket
+	}
+	data, err := hex.DecodeString(encoded)
+	if err != nil || len(data) != length {
+		return replyInvalidPacket
+	}
+	if address.ToUint32()+uint32(length) > base.MEMORY_SIZE {
+		return replyInvalidMemory
+	}
+	core.WriteMemory(address, data)
+	return replyOK
+}
+
+func getStopReply(signal Signal, reason string) string {
+	return fmt.Sprintf("T%02X%s", byte(signal), reason)
+}
+
+func getExitReply(status byte) string {
+	return fmt.Sprintf("W%02X", status)
+}
+
+// Sends a packet, it is kept until the client acknowledges it
+func (server *Server) send(data string) error {
+	if debugPackets {
+		fmt.Println("->", data)
+	}
+	server.lastPacket = fmt.Sprintf("$%s#%02x", data, getChecksum(data))
+	return server.write(server.lastPacket)
+}
+
+func (server *Server) write(packet string) error {
+	_, err := server.conn.Write([]byte(packet))
+	return err
+}
+
+func getChecksum(data string) byte {
+	var checksum byte
+	for i := 0; i < len(data); i++ {
+		checksum += data[i]
+	}
+	return checksum
+}
+
+func parseAddressLength(arguments string) (units.Int24, int, bool) {
+	addressText, lengthText, found := strings.Cut(arguments, ",")
+	if !found {
+		return units.Int24{}, 0, false
+	}
+	address, err := parseAddress(addressText)
+	if err != nil {
+		return units.Int24{}, 0, false
+	}
+	length, err := strconv.ParseUint(lengthText, 16, 24)
+	if err != nil {
+		return units.Int24{}, 0, false
+	}
+	return address, int(length), true
+}
+
+func parseAddress(address string) (units.Int24, error) {
+	value, err := strconv.ParseUint(address, 16, 24)
+	if err != nil {
+		return units.Int24{}, err
+	}
+	return units.Uint32ToInt24(uint32(value)), nil
+}
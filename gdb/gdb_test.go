@@ -0,0 +1,112 @@
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sicsimgo/core"
+)
+
+// Sends a packet and returns the reply after the acknowledgement
+func exchange(t *testing.T, conn net.Conn, reader *bufio.Reader, packet string) string {
+	t.Helper()
+	fmt.Fprintf(conn, "$%s#%02x", packet, getChecksum(packet))
+	acknowledgement, err := reader.ReadByte()
+	if err != nil || acknowledgement != '+' {
+		t.Fatalf("packet %s not acknowledged", packet)
+	}
+	if _, err := reader.ReadString('$'); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := reader.ReadString('#')
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Discard(2)
+	conn.Write([]byte("+"))
+	return strings.TrimSuffix(reply, "#")
+}
+
+func TestServer(t *testing.T) {
+	// Loop at 0x06 counts A up to 3, COMP is at 0x08 and halt at 0x0E
+	source := `prog  START 0
+      LDA   #0
+      LDS   #1
+loop  ADDR  S,A
+      COMP  #3
+      JLT   loop
+halt  J     halt
+      END   prog
+`
+	fileName := filepath.Join(t.TempDir(), "prog.asm")
+	if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.LoadProgram(fileName); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() {
+		served <- Serve(listener)
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	tests := []struct {
+		name      string
+		packet    string
+		reply     string
+		interrupt bool
+	}{
+		{name: "Stop reason", packet: "?", reply: "T05"},
+		{name: "Read PC", packet: "p7", reply: "000000"},
+		{name: "Step", packet: "s", reply: "T05"},
+		{name: "Read registers after step", packet: "g", reply: "000000" + strings.Repeat("000000", 5) + "000000000000" + "000003" + "800000"},
+		{name: "Set breakpoint", packet: "Z0,8,3", reply: "OK"},
+		{name: "Continue to breakpoint", packet: "c", reply: "T05swbreak:;"},
+		{name: "Read A at breakpoint", packet: "p0", reply: "000001"},
+		{name: "Remove breakpoint", packet: "z0,8,3", reply: "OK"},
+		{name: "Write A", packet: "P0=000002", reply: "OK"},
+		{name: "Hardware breakpoint", packet: "Z1,e,3", reply: "OK"},
+		{name: "Continue to hardware breakpoint", packet: "c", reply: "T05hwbreak:;"},
+		{name: "Read A at halt", packet: "p0", reply: "000003"},
+		{name: "Read memory", packet: "m6,2", reply: "9040"},
+		{name: "Write memory", packet: "M20,3:abcdef", reply: "OK"},
+		{name: "Read written memory", packet: "m20,3", reply: "abcdef"},
+		{name: "Remove hardware breakpoint", packet: "z1,e,3", reply: "OK"},
+		{name: "Continue to exit after a stale interrupt", packet: "c", reply: "W00", interrupt: true},
+		{name: "Read outside of memory", packet: "m100000,1", reply: "E02"},
+		{name: "Invalid register", packet: "p9", reply: "E01"},
+		{name: "Unsupported packet", packet: "vMustReplyEmpty", reply: ""},
+		{name: "Detach", packet: "D", reply: "OK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.interrupt {
+				conn.Write([]byte{INTERRUPT})
+			}
+			if reply := exchange(t, conn, reader, tt.packet); reply != tt.reply {
+				t.Errorf("reply to %s = %q, want %q", tt.packet, reply, tt.reply)
+			}
+		})
+	}
+
+	if err := <-served; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,163 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sicsimgo/core/base"
+	"sicsimgo/core/units"
+)
+
+/*
+DEFINITIONS
+*/
+// Registers are numbered by their order in the g packet, values are big-endian as in memory
+type register struct {
+	Name string
+	Size int
+	Get  func() []byte
+	Set  func(value []byte)
+}
+
+/*
+IMPLEMENTATION
+*/
+var registers []register = []register{
+	int24Register("A", base.GetRegisterA, base.SetRegisterA),
+	int24Register("X", base.GetRegisterX, base.SetRegisterX),
+	int24Register("L", base.GetRegisterL, base.SetRegisterL),
+	int24Register("B", base.GetRegisterB, base.SetRegisterB),
+	int24Register("S", base.GetRegisterS, base.SetRegisterS),
+	int24Register("T", base.GetRegisterT, base.SetRegisterT),
+	{
+		Name: "F",
+		Size: len(units.Float48{}),
+		Get: func() []byte {
+			f := base.GetRegisterF()
+			return f[:]
+		},
+		Set: func(value []byte) {
+			base.SetRegisterF(units.Float48(value))
+		},
+	},
+	int24Register("PC", base.GetRegisterPC, base.SetRegisterPC),
+	int24Register("SW", base.GetRegisterSW, base.SetRegisterSW),
+}
+
+/*
+OPERATIONS
+*/
+func int24Register(name string, get func() units.Int24, set func(units.Int24)) register {
+	return register{
+		Name: name,
+		Size: units.WORD_SIZE,
+		Get: func() []byte {
+			value := get().Bytes()
+			return value[:]
+		},
+		Set: func(value []byte) {
+			set(units.BytesToInt24(value[0], value[1], value[2]))
+		},
+	}
+}
+
+func getRegisters() []byte {
+	var values []byte
+	for _, register := range registers {
+		values = append(values, register.Get()...)
+	}
+	return values
+}
+
+// G packet holds all registers
+func setRegisters(arguments string) string {
+	values, err := hex.DecodeString(arguments)
+	if err != nil || len(values) != len(getRegisters()) {
+		return replyInvalidPacket
+	}
+	for _, register := range registers {
+		register.Set(values[:register.Size])
+		values = values[register.Size:]
+	}
+	return replyOK
+}
+
+func readRegister(arguments string) string {
+	register, ok := getRegister(arguments)
+	if !ok {
+		return replyInvalidPacket
+	}
+	return hex.EncodeToString(register.Get())
+}
+
+// P packet is number=value
+func writeRegister(arguments string) string {
+	number, encoded, found := strings.Cut(arguments, "=")
+	if !found {
+		return replyInvalidPacket
+	}
+	register, ok := getRegister(number)
+	if !ok {
+		return replyInvalidPacket
+	}
+	value, err := hex.DecodeString(encoded)
+	if err != nil || len(value) != register.Size {
+		return replyInvalidPacket
+	}
+	register.Set(value)
+	return replyOK
+}
+
+func getRegister(number string) (register, bool) {
+	index, err := strconv.ParseUint(number, 16, 8)
+	if err != nil || int(index) >= len(registers) {
+		return register{}, false
+	}
+	return registers[index], true
+}
+
+// Target description names the registers for the client, qXfer arguments are offset,length
+func readTargetDescription(arguments string) string {
+	offsetText, lengthText, found := strings.Cut(arguments, ",")
+	if !found {
+		return replyInvalidPacket
+	}
+	offset, err := strconv.ParseUint(offsetText, 16, 32)
+	if err != nil {
+		return replyInvalidPacket
+	}
+	length, err := strconv.ParseUint(lengthText, 16, 32)
+	if err != nil {
+		return replyInvalidPacket
+	}
+
+	description := getTargetDescription()
+	if offset >= uint64(len(description)) {
+		return "l"
+	}
+	end := min(offset+length, uint64(len(description)))
+	if end == uint64(len(description)) {
+		return "l" + description[offset:end]
+	}
+	return "m" + description[offset:end]
+}
+
+func getTargetDescription() string {
+	var description strings.Builder
+	description.WriteString(`<?xml version="1.0"?>` + "\n")
+	description.WriteString(`<!DOCTYPE target SYSTEM "gdb-target.dtd">` + "\n")
+	description.WriteString(`<target version="1.0">` + "\n")
+	description.WriteString(`  <feature name="org.sicsimgo.sicxe">` + "\n")
+	for number, register := range registers {
+		registerType := "int"
+		if register.Name == "PC" {
+			registerType = "code_ptr"
+		}
+		fmt.Fprintf(&description, `    <reg name="%s" bitsize="%d" type="%s" regnum="%d"/>`+"\n", register.Name, register.Size*8, registerType, number)
+	}
+	description.WriteString("  </feature>\n")
+	description.WriteString("</target>\n")
+	return description.String()
+}
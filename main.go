@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"sicsimgo/cli"
 	"sicsimgo/internal"
 	"sicsimgo/ui"
 
@@ -11,6 +12,11 @@ import (
 )
 
 func main() {
+	// Command-line mode, no window is opened
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	go func() {
 
 		// Create a window
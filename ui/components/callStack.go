@@ -0,0 +1,85 @@
+package components
+
+import (
+	"fmt"
+	"image/color"
+
+	"sicsimgo/core"
+	"sicsimgo/core/base"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"golang.org/x/image/colornames"
+)
+
+func callStackLine(gtx layout.Context, theme *material.Theme, values []string) D {
+	return layout.Flex{
+		Axis: layout.Horizontal,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-12s", values[0])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-12s", values[1])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-7s", values[2])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(theme, values[3]).Layout(gtx)
+		}),
+	)
+}
+
+// Innermost call first, followed by warnings about the linkage register
+func CallStack(gtx *layout.Context, theme *material.Theme, callStackList *widget.List, view *core.View) layout.Dimensions {
+	callStack := view.CallStack
+	warnings := view.CallStackWarnings
+
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Middle,
+	}.Layout(*gtx,
+		layout.Rigid(func(gtx C) D {
+			return callStackLine(gtx, theme, []string{
+				"SUBROUTINE",
+				"CALLED FROM",
+				"RETURN",
+				"L SAVED",
+			})
+		}),
+
+		layout.Flexed(1, func(gtx C) D {
+			return material.List(theme, callStackList).Layout(gtx, len(callStack)+len(warnings), func(gtx C, index int) D {
+				if index < len(callStack) {
+					frame := callStack[len(callStack)-1-index]
+					linkSaved := "no"
+					if frame.LinkSaved {
+						linkSaved = "yes"
+					}
+					return callStackLine(gtx, theme, []string{
+						frame.SubroutineName,
+						frame.CallName,
+						base.StringAddress(frame.ReturnAddress),
+						linkSaved,
+					})
+				}
+
+				warning := warnings[index-len(callStack)]
+				label := material.Body1(theme, fmt.Sprintf("%s: %s", warning.Type.String(), warning.Message))
+				label.Color = color.NRGBA(colornames.Red)
+				return label.Layout(gtx)
+			})
+		}),
+	)
+}
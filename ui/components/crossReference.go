@@ -0,0 +1,69 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"sicsimgo/core"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+func crossReferenceLine(gtx layout.Context, theme *material.Theme, values []string) D {
+	return layout.Flex{
+		Axis: layout.Horizontal,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-12s", values[0])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-7s", values[1])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(theme, values[2]).Layout(gtx)
+		}),
+	)
+}
+
+// Lists symbols with their uses, clicking a symbol jumps to its next use
+func CrossReference(gtx *layout.Context, theme *material.Theme, crossReferenceList *widget.List, symbolButtons []widget.Clickable, view *core.View) layout.Dimensions {
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Middle,
+	}.Layout(*gtx,
+		layout.Rigid(func(gtx C) D {
+			return crossReferenceLine(gtx, theme, []string{
+				"NAME",
+				"DEFINED",
+				"REFERENCES",
+			})
+		}),
+
+		layout.Flexed(1, func(gtx C) D {
+			count := min(len(view.CrossReferences), len(symbolButtons))
+			return material.List(theme, crossReferenceList).Layout(gtx, count, func(gtx C, index int) D {
+				crossReference := view.CrossReferences[index]
+				var references []string
+				for _, reference := range crossReference.References {
+					references = append(references, reference.String())
+				}
+
+				return symbolButtons[index].Layout(gtx, func(gtx C) D {
+					return crossReferenceLine(gtx, theme, []string{
+						crossReference.Symbol.Name,
+						fmt.Sprintf("%d", crossReference.DefinitionLine),
+						strings.Join(references, " "),
+					})
+				})
+			})
+		}),
+	)
+}
@@ -7,11 +7,12 @@ import (
 	"strings"
 
 	"sicsimgo/core"
-	"sicsimgo/core/base"
-	"sicsimgo/core/loader"
 	"sicsimgo/core/proc"
+	"sicsimgo/core/units"
 
 	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
@@ -23,56 +24,85 @@ func WidthSpacer(gtx layout.Context, width int) layout.FlexChild {
 		return layout.Spacer{Width: unit.Dp(width)}.Layout(gtx)
 	})
 }
-func InstructionLine(gtx layout.Context, theme *material.Theme, values []string, selected bool) D {
-	return layout.Flex{
-		Axis: layout.Horizontal,
-	}.Layout(gtx,
-		layout.Rigid(func(gtx C) D {
-			value := fmt.Sprintf("%-8s", values[0])
-			label := material.Body1(theme, value)
-			if selected {
-				label.Color = color.NRGBA(colornames.Red)
-			}
-			return label.Layout(gtx)
-		}),
-		WidthSpacer(gtx, 20),
 
-		layout.Rigid(func(gtx C) D {
-			value := fmt.Sprintf("%-8s", values[1])
-			label := material.Body1(theme, value)
-			if selected {
-				label.Color = color.NRGBA(colornames.Red)
-			}
-			return label.Layout(gtx)
-		}),
-		WidthSpacer(gtx, 20),
+// Executions are shaded by heat, their share of the most executed instruction's executions
+func heatColumn(gtx layout.Context, theme *material.Theme, value string, heat float32) D {
+	label := material.Body1(theme, fmt.Sprintf("%8s", value))
+	if heat == 0 {
+		return label.Layout(gtx)
+	}
+	return layout.Background{}.Layout(gtx,
+		func(gtx C) D {
+			defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+			paint.ColorOp{Color: color.NRGBA{R: 0xFF, G: 0x45, B: 0x00, A: uint8(0x20 + heat*0xA0)}}.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			return D{Size: gtx.Constraints.Min}
+		},
+		label.Layout,
+	)
+}
 
-		layout.Rigid(func(gtx C) D {
-			value := fmt.Sprintf("%-9s", values[2])
-			label := material.Body1(theme, value)
-			if selected {
-				label.Color = color.NRGBA(colornames.Red)
-			}
-			return label.Layout(gtx)
-		}),
-		WidthSpacer(gtx, 20),
+// Selected is the line at PC, its operand is highlighted separately when it addresses memory
+type InstructionLineState struct {
+	Selected        bool
+	SelectedOperand bool
+	Modified        bool
+	Cursor          bool
+	Breakpoint      bool
+	Heat            float32
+}
 
-		layout.Rigid(func(gtx C) D {
-			value := fmt.Sprintf("%s", values[3])
+func InstructionLine(gtx layout.Context, theme *material.Theme, values []string, state InstructionLineState) D {
+	column := func(value string, operand bool) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
 			label := material.Body1(theme, value)
-			if selected {
-				if core.CurrentProcState.Instruction.IsFormatSIC34() && core.CurrentProcState.Instruction.AbsoluteAddressingMode != proc.ImmediateAbsoluteAddressing {
+			if state.Modified {
+				label.Color = color.NRGBA(colornames.Darkorange)
+			}
+			if state.Cursor {
+				label.Color = color.NRGBA(colornames.Royalblue)
+			}
+			if state.Selected {
+				if operand && state.SelectedOperand {
 					label.Color = color.NRGBA(colornames.Darkorchid)
 				} else {
 					label.Color = color.NRGBA(colornames.Red)
 				}
 			}
 			return label.Layout(gtx)
+		})
+	}
+
+	return layout.Flex{
+		Axis: layout.Horizontal,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			return heatColumn(gtx, theme, values[5], state.Heat)
+		}),
+		WidthSpacer(gtx, 8),
+		layout.Rigid(func(gtx C) D {
+			marker := material.Body1(theme, " ")
+			if state.Breakpoint {
+				marker.Text = "*"
+				marker.Color = color.NRGBA(colornames.Crimson)
+			}
+			return marker.Layout(gtx)
 		}),
+		WidthSpacer(gtx, 8),
+		column(fmt.Sprintf("%-8s", values[0]), false),
+		WidthSpacer(gtx, 20),
+		column(fmt.Sprintf("%-8s", values[1]), false),
+		WidthSpacer(gtx, 20),
+		column(fmt.Sprintf("%-8s", values[2]), false),
+		WidthSpacer(gtx, 20),
+		column(fmt.Sprintf("%-9s", values[3]), false),
+		WidthSpacer(gtx, 20),
+		column(values[4], true),
 	)
 }
 
-func Disassembly(gtx *layout.Context, theme *material.Theme, instructionList *widget.List) layout.Dimensions {
+// Clicking a line selects it as the cursor for run to cursor and breakpoints
+func Disassembly(gtx *layout.Context, theme *material.Theme, instructionList *widget.List, instructionButtons []widget.Clickable, view *core.View, cursorAddress units.Int24, cursorSelected bool) layout.Dimensions {
 	return layout.Flex{
 		Axis:      layout.Vertical,
 		Alignment: layout.Middle,
@@ -84,14 +114,17 @@ func Disassembly(gtx *layout.Context, theme *material.Theme, instructionList *wi
 			return InstructionLine(gtx, theme, []string{
 				"ADDRESS",
 				"BYTES",
+				"LABEL",
 				"OPERATION",
-				"OPERAND (ADRRESS)",
-			}, false)
+				"OPERAND",
+				"COUNT",
+			}, InstructionLineState{})
 		}),
 
 		layout.Flexed(1, func(gtx C) D {
-			return material.List(theme, instructionList).Layout(gtx, len(loader.InstructionList), func(gtx C, index int) D {
-				instruction := loader.InstructionList[index]
+			count := min(len(view.InstructionList), len(instructionButtons))
+			return material.List(theme, instructionList).Layout(gtx, count, func(gtx C, index int) D {
+				instruction := view.InstructionList[index]
 				instructionAddress := instruction.InstructionAddress.StringHex()
 				instructionBytes := fmt.Sprintf("%-8s", strings.ToUpper(hex.EncodeToString(instruction.Bytes)))
 				var instructionOperation string
@@ -105,24 +138,42 @@ func Disassembly(gtx *layout.Context, theme *material.Theme, instructionList *wi
 					}
 					instructionOperation += fmt.Sprintf("%-4s", instruction.Opcode.String())
 				}
-				var instructionOperand string
-				if instruction.Directive == proc.DirectiveBYTE {
-					instructionOperand = ""
-				} else if instruction.Format == proc.InstructionFormat2 {
-					instructionOperand = fmt.Sprintf("%s,%s", instruction.R1.String(), instruction.R2.String())
-				} else if instruction.IsJumpInstruction() || instruction.IsStoreInstruction() {
-					instructionOperand = instruction.Address.StringHex()
-				} else {
-					instructionOperand = instruction.Operand.StringHex() + " (" + instruction.Address.StringHex() + ")"
+				// Operands are shown with labels when known without running the program
+				instructionOperand, symbolic := view.SymbolicOperands[instruction.InstructionAddress]
+				if !symbolic {
+					if instruction.Format == proc.InstructionFormat2 {
+						instructionOperand = fmt.Sprintf("%s,%s", instruction.R1.String(), instruction.R2.String())
+					} else if instruction.IsJumpInstruction() || instruction.IsStoreInstruction() {
+						instructionOperand = instruction.Address.StringHex()
+					} else {
+						instructionOperand = instruction.Operand.StringHex() + " (" + instruction.Address.StringHex() + ")"
+					}
 				}
 
-				instructionSelected := instruction.InstructionAddress.Compare(base.GetRegisterPC()) == 0
-				return InstructionLine(gtx, theme, []string{
-					instructionAddress,
-					instructionBytes,
-					instructionOperation,
-					instructionOperand,
-				}, instructionSelected)
+				instructionSelected := instruction.InstructionAddress.Compare(view.Registers.PC) == 0
+				_, instructionModified := view.ModifiedCode[instruction.InstructionAddress]
+				instructionExecutions := ""
+				if profile := view.InstructionProfiles[instruction.InstructionAddress]; profile.Executions > 0 {
+					instructionExecutions = fmt.Sprintf("%d", profile.Executions)
+				}
+				instructionCursor := cursorSelected && instruction.InstructionAddress.Compare(cursorAddress) == 0
+				return instructionButtons[index].Layout(gtx, func(gtx C) D {
+					return InstructionLine(gtx, theme, []string{
+						instructionAddress,
+						instructionBytes,
+						view.Labels[instruction.InstructionAddress],
+						instructionOperation,
+						instructionOperand,
+						instructionExecutions,
+					}, InstructionLineState{
+						Selected:        instructionSelected,
+						SelectedOperand: view.ProcState.Instruction.IsFormatSIC34() && view.ProcState.Instruction.AbsoluteAddressingMode != proc.ImmediateAbsoluteAddressing,
+						Modified:        instructionModified,
+						Cursor:          instructionCursor,
+						Breakpoint:      view.Breakpoints[instruction.InstructionAddress],
+						Heat:            view.GetInstructionHeat(instruction.InstructionAddress),
+					})
+				})
 			})
 		}),
 	)
@@ -0,0 +1,89 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"sicsimgo/core"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+func hotspotLine(gtx layout.Context, theme *material.Theme, values []string) D {
+	return layout.Flex{
+		Axis: layout.Horizontal,
+	}.Layout(gtx,
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%-12s", values[0])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%10s", values[1])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			value := fmt.Sprintf("%10s", values[2])
+			return material.Body1(theme, value).Layout(gtx)
+		}),
+		widthSpacer(20),
+
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(theme, values[3]).Layout(gtx)
+		}),
+	)
+}
+
+// Subroutines followed by instructions, both sorted by the selected order
+func Hotspots(gtx *layout.Context, theme *material.Theme, hotspotList *widget.List, orderButtons []widget.Clickable, order core.HotspotOrder, view *core.View) layout.Dimensions {
+	subroutines := append([]core.Hotspot{}, view.SubroutineHotspots...)
+	core.SortHotspots(subroutines, order)
+	instructions := append([]core.Hotspot{}, view.InstructionHotspots...)
+	core.SortHotspots(instructions, order)
+
+	orderLabels := make([]string, len(core.HotspotOrders))
+	for i, hotspotOrder := range core.HotspotOrders {
+		orderLabels[i] = strings.ToUpper(hotspotOrder.String())
+	}
+
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Start,
+	}.Layout(*gtx,
+		layout.Rigid(func(gtx C) D {
+			return Tabs(gtx, theme, orderButtons, orderLabels, int(order))
+		}),
+
+		layout.Flexed(1, func(gtx C) D {
+			count := 2 + len(subroutines) + len(instructions)
+			return material.List(theme, hotspotList).Layout(gtx, count, func(gtx C, index int) D {
+				switch {
+				case index == 0:
+					return hotspotLine(gtx, theme, []string{"SUBROUTINE", "CALLS", "CYCLES", "SHARE"})
+				case index <= len(subroutines):
+					hotspot := subroutines[index-1]
+					return hotspotLine(gtx, theme, []string{
+						hotspot.Name,
+						fmt.Sprintf("%d", hotspot.Executions),
+						fmt.Sprintf("%d", hotspot.Cycles),
+						hotspot.StringShare(view.Counters.Cycles),
+					})
+				case index == len(subroutines)+1:
+					return hotspotLine(gtx, theme, []string{"INSTRUCTION", "EXECUTIONS", "CYCLES", "SHARE"})
+				}
+				hotspot := instructions[index-len(subroutines)-2]
+				return hotspotLine(gtx, theme, []string{
+					hotspot.Name,
+					fmt.Sprintf("%d", hotspot.Executions),
+					fmt.Sprintf("%d", hotspot.Cycles),
+					hotspot.StringShare(view.Counters.Cycles),
+				})
+			})
+		}),
+	)
+}
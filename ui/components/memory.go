@@ -9,6 +9,8 @@ import (
 	"sicsimgo/core/units"
 
 	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"golang.org/x/image/colornames"
@@ -23,7 +25,43 @@ func MemoryByte(gtx layout.Context, theme *material.Theme, address units.Int24,
 	}
 	return label.Layout(gtx)
 }
-func MemoryLine(gtx layout.Context, theme *material.Theme, address units.Int24, values []byte, instructionAddressSelection []bool, operandAddressSelection []bool) D {
+
+// Blocks with a storage key other than 0 are shaded by their key
+var storageKeyColors = []color.NRGBA{
+	color.NRGBA(colornames.Lightblue),
+	color.NRGBA(colornames.Lightgreen),
+	color.NRGBA(colornames.Lightpink),
+	color.NRGBA(colornames.Lightyellow),
+	color.NRGBA(colornames.Lavender),
+	color.NRGBA(colornames.Peachpuff),
+	color.NRGBA(colornames.Lightcyan),
+	color.NRGBA(colornames.Mistyrose),
+	color.NRGBA(colornames.Honeydew),
+	color.NRGBA(colornames.Thistle),
+	color.NRGBA(colornames.Wheat),
+	color.NRGBA(colornames.Palegreen),
+	color.NRGBA(colornames.Powderblue),
+	color.NRGBA(colornames.Lightsalmon),
+	color.NRGBA(colornames.Khaki),
+}
+
+func MemoryLine(gtx layout.Context, theme *material.Theme, address units.Int24, values []byte, storageKey uint8, instructionAddressSelection []bool, operandAddressSelection []bool) D {
+	if storageKey == 0 {
+		return memoryLineValues(gtx, theme, address, values, instructionAddressSelection, operandAddressSelection)
+	}
+	return layout.Background{}.Layout(gtx,
+		func(gtx C) D {
+			defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+			paint.ColorOp{Color: storageKeyColors[(storageKey-1)%uint8(len(storageKeyColors))]}.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			return D{Size: gtx.Constraints.Min}
+		},
+		func(gtx C) D {
+			return memoryLineValues(gtx, theme, address, values, instructionAddressSelection, operandAddressSelection)
+		},
+	)
+}
+func memoryLineValues(gtx layout.Context, theme *material.Theme, address units.Int24, values []byte, instructionAddressSelection []bool, operandAddressSelection []bool) D {
 	return layout.Flex{
 		Axis: layout.Horizontal,
 	}.Layout(gtx,
@@ -115,7 +153,7 @@ func MemoryLine(gtx layout.Context, theme *material.Theme, address units.Int24,
 	)
 }
 
-func Memory(gtx *layout.Context, theme *material.Theme, memoryList *widget.List) layout.Dimensions {
+func Memory(gtx *layout.Context, theme *material.Theme, memoryList *widget.List, view *core.View) layout.Dimensions {
 	return layout.Flex{
 		Axis:      layout.Vertical,
 		Alignment: layout.Middle,
@@ -127,21 +165,21 @@ func Memory(gtx *layout.Context, theme *material.Theme, memoryList *widget.List)
 
 			// PC-instruction selection adresses
 			instructionAddresses := []units.Int24{}
-			pcAddress := core.CurrentProcState.Instruction.InstructionAddress
+			pcAddress := view.ProcState.Instruction.InstructionAddress
 			j := units.Int24{}
-			for i := 0; i < len(core.CurrentProcState.Instruction.Bytes); i++ {
+			for i := 0; i < len(view.ProcState.Instruction.Bytes); i++ {
 				instructionAddresses = append(instructionAddresses, pcAddress.Add(j))
-				j = j.Add(units.Int24{0x00, 0x00, 0x01})
+				j = j.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 			}
 
 			// Operand address selection adresses
 			operandAddresses := []units.Int24{}
-			if core.CurrentProcState.Instruction.IsFormatSIC34() && core.CurrentProcState.Instruction.AbsoluteAddressingMode != proc.ImmediateAbsoluteAddressing {
-				operandAddress := core.CurrentProcState.Instruction.Address
+			if view.ProcState.Instruction.IsFormatSIC34() && view.ProcState.Instruction.AbsoluteAddressingMode != proc.ImmediateAbsoluteAddressing {
+				operandAddress := view.ProcState.Instruction.Address
 				j = units.Int24{}
 				for i := 0; i < 3; i++ {
 					operandAddresses = append(operandAddresses, operandAddress.Add(j))
-					j = j.Add(units.Int24{0x00, 0x00, 0x01})
+					j = j.Add(units.BytesToInt24(0x00, 0x00, 0x01))
 				}
 			}
 
@@ -167,7 +205,7 @@ func Memory(gtx *layout.Context, theme *material.Theme, memoryList *widget.List)
 					}
 				}
 
-				return MemoryLine(gtx, theme, address, base.GetSlice16(address), instructionAddressSelection, operandAddressSelection)
+				return MemoryLine(gtx, theme, address, view.Memory.GetSlice16(address), view.Memory.GetStorageKey(address), instructionAddressSelection, operandAddressSelection)
 			})
 		}),
 	)
@@ -2,63 +2,65 @@ package components
 
 import (
 	"fmt"
+	"image/color"
 	"sicsimgo/core"
 	"sicsimgo/core/proc"
 
 	"gioui.org/layout"
 	"gioui.org/widget/material"
+	"golang.org/x/image/colornames"
 )
 
 func ProcInfo(
-	gtx *C, theme *material.Theme,
+	gtx *C, theme *material.Theme, view *core.View,
 ) D {
 
-	var currentInstructionSize int = len(core.CurrentProcState.Instruction.Bytes)
+	var currentInstructionSize int = len(view.ProcState.Instruction.Bytes)
 	if currentInstructionSize == 0 {
 		return layout.Dimensions{}
 	}
 
 	var currentInstructionHex string
 	for i := 0; i < currentInstructionSize; i++ {
-		currentInstructionHex += fmt.Sprintf("%02X ", core.CurrentProcState.Instruction.Bytes[i])
+		currentInstructionHex += fmt.Sprintf("%02X ", view.ProcState.Instruction.Bytes[i])
 	}
 	var currentInstructionBin string
 	for i := 0; i < currentInstructionSize; i++ {
-		currentInstructionBin += fmt.Sprintf("%08b ", core.CurrentProcState.Instruction.Bytes[i])
+		currentInstructionBin += fmt.Sprintf("%08b ", view.ProcState.Instruction.Bytes[i])
 	}
 
-	var currentInstructionOpcode string = fmt.Sprintf("%02X", core.CurrentProcState.Instruction.Bytes[0])
+	var currentInstructionOpcode string = fmt.Sprintf("%02X", view.ProcState.Instruction.Bytes[0])
 
-	instructionFormat34 := core.CurrentProcState.Instruction.Format == proc.InstructionFormat3 || core.CurrentProcState.Instruction.Format == proc.InstructionFormat4
+	instructionFormat34 := view.ProcState.Instruction.Format == proc.InstructionFormat3 || view.ProcState.Instruction.Format == proc.InstructionFormat4
 	var currentBitsNixbpe string
 
 	if instructionFormat34 {
-		if core.CurrentProcState.N {
+		if view.ProcState.N {
 			currentBitsNixbpe += "n"
 		} else {
 			currentBitsNixbpe += "-"
 		}
-		if core.CurrentProcState.I {
+		if view.ProcState.I {
 			currentBitsNixbpe += "i"
 		} else {
 			currentBitsNixbpe += "-"
 		}
-		if core.CurrentProcState.X {
+		if view.ProcState.X {
 			currentBitsNixbpe += "x"
 		} else {
 			currentBitsNixbpe += "-"
 		}
-		if core.CurrentProcState.B {
+		if view.ProcState.B {
 			currentBitsNixbpe += "b"
 		} else {
 			currentBitsNixbpe += "-"
 		}
-		if core.CurrentProcState.P {
+		if view.ProcState.P {
 			currentBitsNixbpe += "p"
 		} else {
 			currentBitsNixbpe += "-"
 		}
-		if core.CurrentProcState.E {
+		if view.ProcState.E {
 			currentBitsNixbpe += "e"
 		} else {
 			currentBitsNixbpe += "-"
@@ -76,10 +78,10 @@ func ProcInfo(
 		}),
 
 		layout.Rigid(func(gtx C) D {
-			return material.Body1(theme, fmt.Sprintf("Opcode (Operation): %s (%s)", currentInstructionOpcode, core.CurrentProcState.Instruction.Opcode.String())).Layout(gtx)
+			return material.Body1(theme, fmt.Sprintf("Opcode (Operation): %s (%s)", currentInstructionOpcode, view.ProcState.Instruction.Opcode.String())).Layout(gtx)
 		}),
 		layout.Rigid(func(gtx C) D {
-			return material.Body1(theme, "Format: "+core.CurrentProcState.Instruction.Format.String()).Layout(gtx)
+			return material.Body1(theme, "Format: "+view.ProcState.Instruction.Format.String()).Layout(gtx)
 		}),
 		layout.Rigid(func(gtx C) D {
 			if instructionFormat34 {
@@ -94,5 +96,35 @@ func ProcInfo(
 		layout.Rigid(func(gtx C) D {
 			return material.Body1(theme, "Bin: "+currentInstructionBin).Layout(gtx)
 		}),
+		layout.Rigid(func(gtx C) D {
+			storeAddress, modified := view.ModifiedCode[view.ProcState.Instruction.InstructionAddress]
+			if !modified {
+				return layout.Dimensions{}
+			}
+			label := material.Body1(theme, "Modified by store at "+storeAddress.StringHex())
+			label.Color = color.NRGBA(colornames.Darkorange)
+			return label.Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			if view.Fault == nil {
+				return layout.Dimensions{}
+			}
+			label := material.Body1(theme, view.Fault.Error())
+			label.Color = color.NRGBA(colornames.Red)
+			return label.Layout(gtx)
+		}),
+
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(func(gtx C) D {
+			return material.H6(theme, "Counters").Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			counters := view.Counters
+			return material.Body1(theme, fmt.Sprintf("Instructions: %d, Cycles: %d (CPI %s)", counters.Instructions, counters.Cycles, counters.StringCPI())).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			counters := view.Counters
+			return material.Body1(theme, fmt.Sprintf("Memory reads: %d, writes: %d, Device operations: %d", counters.MemoryReads, counters.MemoryWrites, counters.DeviceOperations)).Layout(gtx)
+		}),
 	)
 }
@@ -1,6 +1,7 @@
 package components
 
 import (
+	"sicsimgo/core"
 	"sicsimgo/core/base"
 
 	"gioui.org/layout"
@@ -31,7 +32,7 @@ func DrawRegister(gtx C, theme *material.Theme, name string, value string) D {
 	)
 }
 
-func Registers(gtx C, theme *material.Theme) D {
+func Registers(gtx C, theme *material.Theme, view *core.View) D {
 	return layout.Flex{
 		Axis:      layout.Vertical,
 		Alignment: layout.Middle,
@@ -46,13 +47,13 @@ func Registers(gtx C, theme *material.Theme) D {
 				Spacing: layout.SpaceAround,
 			}.Layout(gtx,
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "A", base.GetRegisterA().StringHex())
+					return DrawRegister(gtx, theme, "A", view.Registers.A.StringHex())
 				}),
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "X", base.GetRegisterX().StringHex())
+					return DrawRegister(gtx, theme, "X", view.Registers.X.StringHex())
 				}),
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "L", base.GetRegisterX().StringHex())
+					return DrawRegister(gtx, theme, "L", view.Registers.L.StringHex())
 				}),
 			)
 		}),
@@ -62,13 +63,13 @@ func Registers(gtx C, theme *material.Theme) D {
 				Spacing: layout.SpaceAround,
 			}.Layout(gtx,
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "B", base.GetRegisterB().StringHex())
+					return DrawRegister(gtx, theme, "B", view.Registers.B.StringHex())
 				}),
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "S", base.GetRegisterS().StringHex())
+					return DrawRegister(gtx, theme, "S", view.Registers.S.StringHex())
 				}),
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "T", base.GetRegisterT().StringHex())
+					return DrawRegister(gtx, theme, "T", view.Registers.T.StringHex())
 				}),
 			)
 		}),
@@ -78,7 +79,7 @@ func Registers(gtx C, theme *material.Theme) D {
 				Spacing: layout.SpaceAround,
 			}.Layout(gtx,
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "F", base.GetRegisterF().StringHex())
+					return DrawRegister(gtx, theme, "F", view.Registers.F.StringHex())
 				}),
 			)
 		}),
@@ -91,12 +92,18 @@ func Registers(gtx C, theme *material.Theme) D {
 				Spacing: layout.SpaceAround,
 			}.Layout(gtx,
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "PC", base.GetRegisterPC().StringHex())
+					return DrawRegister(gtx, theme, "PC", view.Registers.PC.StringHex())
 				}),
 				layout.Rigid(func(gtx C) D {
-					return DrawRegister(gtx, theme, "SW", base.GetRegisterSW().StringHex())
+					return DrawRegister(gtx, theme, "SW", view.Registers.SW.StringHex())
+				}),
+				layout.Rigid(func(gtx C) D {
+					return DrawRegister(gtx, theme, "I", view.Registers.I.StringHex())
 				}),
 			)
 		}),
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(theme, base.StringStatus(view.Registers.SW)).Layout(gtx)
+		}),
 	)
 }
@@ -0,0 +1,36 @@
+package components
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// Row of tab buttons, the selected one is drawn in the theme's contrast color
+func Tabs(gtx C, theme *material.Theme, tabButtons []widget.Clickable, labels []string, selected int) D {
+	tabs := make([]layout.FlexChild, 0, len(labels))
+	for i := range labels {
+		tabs = append(tabs, layout.Rigid(func(gtx C) D {
+			return layout.Inset{
+				Top:    unit.Dp(0),
+				Bottom: unit.Dp(2),
+				Right:  unit.Dp(1),
+				Left:   unit.Dp(1),
+			}.Layout(gtx, func(gtx C) D {
+				button := material.Button(theme, &tabButtons[i], labels[i])
+				if i != selected {
+					button.Background = color.NRGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xFF}
+				}
+				return button.Layout(gtx)
+			})
+		}))
+	}
+
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(gtx, tabs...)
+}
@@ -1,8 +1,11 @@
 package components
 
 import (
+	"strings"
+
 	"sicsimgo/core"
 	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
 
 	"gioui.org/layout"
 	"gioui.org/unit"
@@ -23,10 +26,10 @@ func toolbarButton(theme *material.Theme, button *widget.Clickable, label string
 	})
 }
 
-func Toolbar(gtx C, theme *material.Theme, LoadProgramButton, ExecuteStepButton, ExecuteStartButton, ResetSimButton, OutputObjFileButton, OutputLstFileButton *widget.Clickable) D {
+func Toolbar(gtx C, theme *material.Theme, LoadProgramButton, ExecuteStepButton, ExecuteStepOverButton, ExecuteStepOutButton, ExecuteRunToCursorButton, ExecuteStartButton, ResetSimButton, OutputObjFileButton, OutputLstFileButton, OutputAsmFileButton, OutputSnapshotFileButton, DialectButton *widget.Clickable, AutoExtendCheckBox, SICCheckBox, DebugInfoCheckBox *widget.Bool, view *core.View, assemblerOptions assembly.Options) D {
 
 	ExecuteState := func() string {
-		if core.SimExecuteState == core.ExecuteStartState {
+		if view.ExecuteState == core.ExecuteStartState {
 			return "STOP"
 		} else {
 			return "START"
@@ -44,14 +47,24 @@ func Toolbar(gtx C, theme *material.Theme, LoadProgramButton, ExecuteStepButton,
 			Alignment: layout.Middle,
 		}.Layout(gtx,
 			toolbarButton(theme, LoadProgramButton, "LOAD"),
+			toolbarButton(theme, DialectButton, strings.ToUpper(assemblerOptions.Dialect.String())),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(theme, AutoExtendCheckBox, "Auto-extend").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(theme, SICCheckBox, "SIC").Layout(gtx)
+			}),
 			toolbarButton(theme, ResetSimButton, "RESET"),
 			toolbarButton(theme, ExecuteStepButton, "STEP"),
+			toolbarButton(theme, ExecuteStepOverButton, "OVER"),
+			toolbarButton(theme, ExecuteStepOutButton, "OUT"),
+			toolbarButton(theme, ExecuteRunToCursorButton, "TO CURSOR"),
 			toolbarButton(theme, ExecuteStartButton, ExecuteState),
 			layout.Flexed(1, func(gtx C) D {
 				return layout.Spacer{}.Layout(gtx)
 			}),
 			layout.Rigid(func(gtx C) D {
-				if core.LoadedProgramTypeState == loader.Assembly {
+				if view.LoadedProgramType == loader.Assembly {
 					return layout.Flex{}.Layout(gtx,
 						toolbarButton(theme, OutputLstFileButton, "LST"),
 					)
@@ -59,9 +72,28 @@ func Toolbar(gtx C, theme *material.Theme, LoadProgramButton, ExecuteStepButton,
 				return D{}
 			}),
 			layout.Rigid(func(gtx C) D {
-				if core.LoadedProgramTypeState == loader.Assembly {
-					return layout.Flex{}.Layout(gtx,
+				if view.LoadedProgramType == loader.Assembly {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
 						toolbarButton(theme, OutputObjFileButton, "OBJ"),
+						layout.Rigid(func(gtx C) D {
+							return material.CheckBox(theme, DebugInfoCheckBox, "Debug info").Layout(gtx)
+						}),
+					)
+				}
+				return D{}
+			}),
+			layout.Rigid(func(gtx C) D {
+				if view.LoadedProgramType == loader.Bytecode {
+					return layout.Flex{}.Layout(gtx,
+						toolbarButton(theme, OutputAsmFileButton, "ASM"),
+					)
+				}
+				return D{}
+			}),
+			layout.Rigid(func(gtx C) D {
+				if view.LoadedProgramType != loader.None {
+					return layout.Flex{}.Layout(gtx,
+						toolbarButton(theme, OutputSnapshotFileButton, "SNAPSHOT"),
 					)
 				}
 				return D{}
@@ -3,8 +3,7 @@ package components
 import (
 	"fmt"
 
-	"sicsimgo/core/base"
-	"sicsimgo/core/loader"
+	"sicsimgo/core"
 
 	"gioui.org/layout"
 	"gioui.org/unit"
@@ -50,14 +49,11 @@ func watchLine(gtx layout.Context, theme *material.Theme, values []string) D {
 	)
 }
 
-func Watch(gtx *layout.Context, theme *material.Theme, watchList *widget.List) layout.Dimensions {
+func Watch(gtx *layout.Context, theme *material.Theme, watchList *widget.List, view *core.View) layout.Dimensions {
 	return layout.Flex{
 		Axis:      layout.Vertical,
 		Alignment: layout.Middle,
 	}.Layout(*gtx,
-		layout.Rigid(func(gtx C) D {
-			return material.H6(theme, "Watch").Layout(gtx)
-		}),
 		layout.Rigid(func(gtx C) D {
 			return watchLine(gtx, theme, []string{
 				"NAME",
@@ -68,20 +64,24 @@ func Watch(gtx *layout.Context, theme *material.Theme, watchList *widget.List) l
 		}),
 
 		layout.Flexed(1, func(gtx C) D {
-			return material.List(theme, watchList).Layout(gtx, len(loader.SymbolTableList), func(gtx C, index int) D {
-				symbol := loader.SymbolTableList[index]
+			return material.List(theme, watchList).Layout(gtx, len(view.SymbolTableList), func(gtx C, index int) D {
+				symbol := view.SymbolTableList[index]
 				symbolName := symbol.Name
 				symbolAddress := symbol.Address.StringHex()
 				var symbolValueDec string
 				var symbolValueHex string
 				if symbol.DataLength == 1 {
-					symbolValue := base.GetByte(symbol.Address)
+					symbolValue := view.Memory.GetByte(symbol.Address)
 					symbolValueDec = fmt.Sprintf("%d", int8(symbolValue))
 					symbolValueHex = fmt.Sprintf("%02X", symbolValue)
 				} else if symbol.DataLength == 3 {
-					symbolValue := base.GetWord(symbol.Address)
+					symbolValue := view.Memory.GetWord(symbol.Address)
 					symbolValueDec = symbolValue.StringDecSigned()
 					symbolValueHex = symbolValue.StringHex()
+				} else if symbol.DataLength == 6 {
+					symbolValue := view.Memory.GetFloat(symbol.Address)
+					symbolValueDec = symbolValue.StringDec()
+					symbolValueHex = symbolValue.StringHex()
 				}
 
 				return watchLine(gtx, theme, []string{
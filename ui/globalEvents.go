@@ -29,12 +29,24 @@ func HandleGlobalEvents(gtx layout.Context, theme *material.Theme, w *app.Window
 			Name:     key.Name("R"),
 			Optional: key.ModCtrl,
 		},
+		key.Filter{
+			Name: key.Name(key.NameF2),
+		},
 		key.Filter{
 			Name: key.Name(key.NameF5),
 		},
 		key.Filter{
 			Name: key.Name(key.NameF6),
 		},
+		key.Filter{
+			Name: key.Name(key.NameF7),
+		},
+		key.Filter{
+			Name: key.Name(key.NameF8),
+		},
+		key.Filter{
+			Name: key.Name(key.NameF9),
+		},
 	)
 
 	switch event := event.(type) {
@@ -76,6 +88,11 @@ func HandleGlobalEvents(gtx layout.Context, theme *material.Theme, w *app.Window
 				}
 				Reset(w)
 			}
+		case key.NameF2:
+			if debugHandleGlobalEvents {
+				fmt.Println("Toggle breakpoint")
+			}
+			ToggleBreakpoint()
 		case key.NameF5:
 			if debugHandleGlobalEvents {
 				fmt.Println("Execute start/stop")
@@ -86,6 +103,21 @@ func HandleGlobalEvents(gtx layout.Context, theme *material.Theme, w *app.Window
 				fmt.Println("Execute step")
 			}
 			ExecuteStep()
+		case key.NameF7:
+			if debugHandleGlobalEvents {
+				fmt.Println("Execute step over")
+			}
+			ExecuteStepOver()
+		case key.NameF8:
+			if debugHandleGlobalEvents {
+				fmt.Println("Execute step out")
+			}
+			ExecuteStepOut()
+		case key.NameF9:
+			if debugHandleGlobalEvents {
+				fmt.Println("Execute run to cursor")
+			}
+			ExecuteRunToCursor()
 		}
 	}
 }
@@ -2,11 +2,15 @@ package ui
 
 import (
 	_ "embed"
+	"fmt"
+	"os"
 	"sicsimgo/core"
-	"sicsimgo/core/base"
 	"sicsimgo/core/loader"
+	"sicsimgo/core/loader/assembly"
+	"sicsimgo/core/units"
 	"sicsimgo/internal"
 	"sicsimgo/ui/components"
+	"strings"
 
 	"gioui.org/app"
 	"gioui.org/font/gofont"
@@ -17,6 +21,7 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"github.com/sqweek/dialog"
 )
 
 type (
@@ -27,6 +32,19 @@ type (
 //go:embed FiraMono-Regular.ttf
 var fontBytes []byte
 
+// Owns the machine, the window only reads its views
+var Controller *core.Controller
+
+// Applied to the next loaded source
+var AssemblerOptions assembly.Options = loader.AssemblerOptions
+
+// Object files are written with a debug file next to them, as asm -g does
+var OutputDebugInfo bool
+
+// Disassembly line selected for run to cursor
+var CursorAddress units.Int24
+var CursorSelected bool
+
 func loadFont(theme *material.Theme) error {
 
 	faces, err := opentype.ParseCollection(fontBytes)
@@ -42,48 +60,180 @@ func loadFont(theme *material.Theme) error {
 }
 
 func OpenProgramFile(w *app.Window) {
-	core.ResetSim()
+	Controller.Send(core.Command{Type: core.CommandReset})
 
+	// Options are copied now, the dialog doesn't block the window
+	options := AssemblerOptions
 	go func() {
-		programName, startPC, loadedProgramType := loader.OpenAsmObjFile()
-		if loadedProgramType == loader.None {
-			core.ResetSim()
+		fileName, err := dialog.File().Filter("Assembly / Object files / Snapshots", "asm", "obj", "snap").Filter("Assembly files", "asm").Filter("Object files", "obj").Filter("Snapshots", "snap").Title("Select object / assembly file or snapshot").Load()
+		if err != nil {
 			internal.ResetWindowTitle(w)
 			return
 		}
-		base.SetRegisterPC(startPC)
-		core.LoadedProgramTypeState = loadedProgramType
-		core.UpdateProcState(base.GetRegisterPC())
-		internal.SetWindowTitle(programName, w)
+
+		Controller.Send(core.Command{
+			Type:     core.CommandLoad,
+			FileName: fileName,
+			Options:  options,
+			Loaded: func(programName string, err error) {
+				if err != nil || core.LoadedProgramTypeState == loader.None {
+					internal.ResetWindowTitle(w)
+					return
+				}
+				internal.SetWindowTitle(programName, w)
+			},
+		})
 	}()
 }
 func ExecuteStep() {
-	go core.ExecuteNextInstruction()
+	Controller.Send(core.Command{Type: core.CommandStep})
+}
+func ExecuteStepOver() {
+	Controller.Send(core.Command{Type: core.CommandStepOver})
+}
+func ExecuteStepOut() {
+	Controller.Send(core.Command{Type: core.CommandStepOut})
+}
+func ExecuteRunToCursor() {
+	if !CursorSelected {
+		return
+	}
+	Controller.Send(core.Command{Type: core.CommandRunToAddress, Address: CursorAddress})
 }
 func ExecuteStartStop() {
-	core.SimExecuteState = !core.SimExecuteState
+	if Controller.View().ExecuteState == core.ExecuteStartState {
+		Controller.Send(core.Command{Type: core.CommandStop})
+	} else {
+		Controller.Send(core.Command{Type: core.CommandRun})
+	}
+}
+
+// Breakpoint is toggled at the run to cursor line
+func ToggleBreakpoint() {
+	if !CursorSelected {
+		return
+	}
+	address := CursorAddress
+	Controller.Send(core.Command{
+		Type: core.CommandCall,
+		Function: func() {
+			core.ToggleBreakpoint(address)
+		},
+	})
+}
+func Reset(w *app.Window) {
+	internal.ResetWindowTitle(w)
+	Controller.Send(core.Command{Type: core.CommandReset})
+}
+
+// Writes the file on the controller, so the program isn't changed while it is written
+func writeFileFromController(file *os.File, write func(file *os.File) error) {
+	Controller.Send(core.Command{
+		Type: core.CommandCall,
+		Function: func() {
+			err := write(file)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				reportError(err)
+			}
+		},
+	})
+}
+
+// Printed as the CLI does and shown, the dialog runs on its own goroutine so the controller isn't blocked
+func reportError(err error) {
+	fmt.Fprintf(os.Stderr, "sicsimgo: %v\n", err)
+	go dialog.Message("%v", err).Title("Error").Error()
+}
+func OutputLstFile() {
 	go func() {
-		for core.SimExecuteState == core.ExecuteStartState {
-			core.ExecuteNextInstruction()
+		file, err := createFileFromDialog("List file", "lst")
+		if err != nil {
+			return
 		}
+		writeFileFromController(file, func(file *os.File) error {
+			loader.WriteLstFile(file)
+			return nil
+		})
 	}()
 }
-func Reset(w *app.Window) {
-	internal.ResetWindowTitle(w)
+func OutputObjFile() {
+	// Option is copied now, the dialog doesn't block the window
+	debugInfo := OutputDebugInfo
 	go func() {
-		core.ResetSim()
+		file, err := createFileFromDialog("Object file", "obj")
+		if err != nil {
+			return
+		}
+		writeFileFromController(file, func(file *os.File) error {
+			loader.WriteObjFile(file)
+			if !debugInfo {
+				return nil
+			}
+
+			// Debug file is picked up when the object file is loaded again
+			dbgFile, err := os.Create(loader.GetDbgFileName(file.Name()))
+			if err != nil {
+				return err
+			}
+			loader.WriteDbgFile(dbgFile)
+			return dbgFile.Close()
+		})
 	}()
 }
-func OutputLstFile() {
+func OutputAsmFile() {
 	go func() {
-		loader.OutputLstFile()
+		file, err := createFileFromDialog("Assembly file", "asm")
+		if err != nil {
+			return
+		}
+		writeFileFromController(file, func(file *os.File) error {
+			loader.WriteAsmFile(file)
+			return nil
+		})
 	}()
 }
-func OutputObjFile() {
+func OutputSnapshotFile() {
 	go func() {
-		loader.OutputObjFile()
+		file, err := createFileFromDialog("Snapshot", "snap")
+		if err != nil {
+			return
+		}
+		writeFileFromController(file, func(file *os.File) error {
+			return core.WriteSnapshot(file)
+		})
 	}()
 }
+func createFileFromDialog(description string, extension string) (*os.File, error) {
+	fileName, err := dialog.File().Filter(description, extension).Title("Save " + strings.ToLower(description)).Save()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(fileName, "."+extension) {
+		fileName += "." + extension
+	}
+
+	return os.Create(fileName)
+}
+
+// Scrolls disassembly to the symbol's next use, cycling through all of them
+func ScrollToNextReference(instructionList *widget.List, view *core.View, crossReference assembly.CrossReference, referenceCursors map[string]int) {
+	address := crossReference.Symbol.Address
+	if len(crossReference.References) > 0 {
+		cursor := referenceCursors[crossReference.Symbol.Name] % len(crossReference.References)
+		referenceCursors[crossReference.Symbol.Name] = cursor + 1
+		address = crossReference.References[cursor].Address
+	}
+
+	for index, instruction := range view.InstructionList {
+		if instruction.InstructionAddress.ToUint32() >= address.ToUint32() {
+			instructionList.List.Position = layout.Position{First: index}
+			return
+		}
+	}
+}
 
 func DrawWindow(w *app.Window) error {
 	var ops op.Ops
@@ -93,10 +243,19 @@ func DrawWindow(w *app.Window) error {
 
 	var LoadProgramButton widget.Clickable
 	var ExecuteStepButton widget.Clickable
+	var ExecuteStepOverButton widget.Clickable
+	var ExecuteStepOutButton widget.Clickable
+	var ExecuteRunToCursorButton widget.Clickable
 	var ExecuteStartStopButton widget.Clickable
 	var ResetSimButton widget.Clickable
 	var OutputObjFileButton widget.Clickable
 	var OutputLstFileButton widget.Clickable
+	var OutputAsmFileButton widget.Clickable
+	var OutputSnapshotFileButton widget.Clickable
+	var DialectButton widget.Clickable
+	var AutoExtendCheckBox widget.Bool
+	var SICCheckBox widget.Bool
+	var DebugInfoCheckBox widget.Bool
 
 	memoryList := widget.List{
 		List: layout.List{Axis: layout.Vertical},
@@ -107,6 +266,26 @@ func DrawWindow(w *app.Window) error {
 	watchList := widget.List{
 		List: layout.List{Axis: layout.Vertical},
 	}
+	crossReferenceList := widget.List{
+		List: layout.List{Axis: layout.Vertical},
+	}
+	hotspotList := widget.List{
+		List: layout.List{Axis: layout.Vertical},
+	}
+	callStackList := widget.List{
+		List: layout.List{Axis: layout.Vertical},
+	}
+
+	rightTabLabels := []string{"WATCH", "XREF", "HOTSPOTS", "CALLS"}
+	rightTabButtons := make([]widget.Clickable, len(rightTabLabels))
+	selectedRightTab := 0
+
+	var instructionButtons []widget.Clickable
+	var crossReferenceButtons []widget.Clickable
+	crossReferenceCursors := make(map[string]int)
+
+	hotspotOrderButtons := make([]widget.Clickable, len(core.HotspotOrders))
+	hotspotOrder := core.HotspotsByCycles
 
 	mainSplit := Split{
 		Ratio: -0.2,
@@ -118,12 +297,16 @@ func DrawWindow(w *app.Window) error {
 		Ratio: -0.7,
 	}
 
+	// Running programs are redrawn as new views are published
+	Controller = core.NewController(w.Invalidate)
+
 	for {
 		switch e := w.Event().(type) {
 
 		// Application rerender
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, e)
+			view := Controller.View()
 
 			HandleGlobalEvents(gtx, theme, w)
 
@@ -133,6 +316,15 @@ func DrawWindow(w *app.Window) error {
 			if ExecuteStepButton.Clicked(gtx) {
 				ExecuteStep()
 			}
+			if ExecuteStepOverButton.Clicked(gtx) {
+				ExecuteStepOver()
+			}
+			if ExecuteStepOutButton.Clicked(gtx) {
+				ExecuteStepOut()
+			}
+			if ExecuteRunToCursorButton.Clicked(gtx) {
+				ExecuteRunToCursor()
+			}
 			if ExecuteStartStopButton.Clicked(gtx) {
 				ExecuteStartStop()
 			}
@@ -145,13 +337,63 @@ func DrawWindow(w *app.Window) error {
 			if OutputObjFileButton.Clicked(gtx) {
 				OutputObjFile()
 			}
+			if OutputAsmFileButton.Clicked(gtx) {
+				OutputAsmFile()
+			}
+			if OutputSnapshotFileButton.Clicked(gtx) {
+				OutputSnapshotFile()
+			}
+			if DialectButton.Clicked(gtx) {
+				// Applies to the next loaded source
+				AssemblerOptions.Dialect = (AssemblerOptions.Dialect + 1) % assembly.Dialect(len(assembly.Dialects))
+			}
+			if AutoExtendCheckBox.Update(gtx) {
+				AssemblerOptions.AutoExtend = AutoExtendCheckBox.Value
+			}
+			if SICCheckBox.Update(gtx) {
+				AssemblerOptions.SIC = SICCheckBox.Value
+			}
+			if DebugInfoCheckBox.Update(gtx) {
+				OutputDebugInfo = DebugInfoCheckBox.Value
+			}
+			for i := range rightTabButtons {
+				if rightTabButtons[i].Clicked(gtx) {
+					selectedRightTab = i
+				}
+			}
+			if len(instructionButtons) != len(view.InstructionList) {
+				instructionButtons = make([]widget.Clickable, len(view.InstructionList))
+			}
+			for i := range instructionButtons {
+				if instructionButtons[i].Clicked(gtx) {
+					// Clicking the selected line again clears the cursor
+					address := view.InstructionList[i].InstructionAddress
+					CursorSelected = !CursorSelected || CursorAddress.Compare(address) != 0
+					CursorAddress = address
+				}
+			}
+			if len(crossReferenceButtons) != len(view.CrossReferences) {
+				crossReferenceButtons = make([]widget.Clickable, len(view.CrossReferences))
+				crossReferenceCursors = make(map[string]int)
+			}
+			for i := range crossReferenceButtons {
+				if crossReferenceButtons[i].Clicked(gtx) {
+					ScrollToNextReference(&instructionList, view, view.CrossReferences[i], crossReferenceCursors)
+				}
+			}
+
+			for i := range hotspotOrderButtons {
+				if hotspotOrderButtons[i].Clicked(gtx) {
+					hotspotOrder = core.HotspotOrders[i]
+				}
+			}
 
 			layout.Flex{
 				Axis:      layout.Vertical,
 				Alignment: layout.Middle,
 			}.Layout(gtx,
 				layout.Rigid(func(gtx C) D {
-					return components.Toolbar(gtx, theme, &LoadProgramButton, &ExecuteStepButton, &ExecuteStartStopButton, &ResetSimButton, &OutputObjFileButton, &OutputLstFileButton)
+					return components.Toolbar(gtx, theme, &LoadProgramButton, &ExecuteStepButton, &ExecuteStepOverButton, &ExecuteStepOutButton, &ExecuteRunToCursorButton, &ExecuteStartStopButton, &ResetSimButton, &OutputObjFileButton, &OutputLstFileButton, &OutputAsmFileButton, &OutputSnapshotFileButton, &DialectButton, &AutoExtendCheckBox, &SICCheckBox, &DebugInfoCheckBox, view, AssemblerOptions)
 				}),
 
 				layout.Flexed(1, func(gtx C) D {
@@ -170,7 +412,7 @@ func DrawWindow(w *app.Window) error {
 												Right:  unit.Dp(0),
 												Left:   unit.Dp(5),
 											}.Layout(gtx, func(gtx C) D {
-												return components.Registers(gtx, theme)
+												return components.Registers(gtx, theme, view)
 											})
 										}),
 										layout.Rigid(func(gtx C) D {
@@ -181,7 +423,7 @@ func DrawWindow(w *app.Window) error {
 												Left:   unit.Dp(5),
 											}.Layout(gtx, func(gtx C) D {
 												return components.ProcInfo(
-													&gtx, theme,
+													&gtx, theme, view,
 												)
 											})
 										}),
@@ -194,7 +436,7 @@ func DrawWindow(w *app.Window) error {
 										Right:  unit.Dp(5),
 										Left:   unit.Dp(5),
 									}.Layout(gtx, func(gtx C) D {
-										return components.Disassembly(&gtx, theme, &instructionList)
+										return components.Disassembly(&gtx, theme, &instructionList, instructionButtons, view, CursorAddress, CursorSelected)
 									})
 								},
 							)
@@ -208,7 +450,26 @@ func DrawWindow(w *app.Window) error {
 										Right:  unit.Dp(5),
 										Left:   unit.Dp(5),
 									}.Layout(gtx, func(gtx C) D {
-										return components.Watch(&gtx, theme, &watchList)
+										return layout.Flex{
+											Axis:      layout.Vertical,
+											Alignment: layout.Start,
+										}.Layout(gtx,
+											layout.Rigid(func(gtx C) D {
+												return components.Tabs(gtx, theme, rightTabButtons, rightTabLabels, selectedRightTab)
+											}),
+											layout.Flexed(1, func(gtx C) D {
+												switch selectedRightTab {
+												case 1:
+													return components.CrossReference(&gtx, theme, &crossReferenceList, crossReferenceButtons, view)
+												case 2:
+													return components.Hotspots(&gtx, theme, &hotspotList, hotspotOrderButtons, hotspotOrder, view)
+												case 3:
+													return components.CallStack(&gtx, theme, &callStackList, view)
+												default:
+													return components.Watch(&gtx, theme, &watchList, view)
+												}
+											}),
+										)
 									})
 								},
 								func(gtx C) D {
@@ -218,7 +479,7 @@ func DrawWindow(w *app.Window) error {
 										Right:  unit.Dp(5),
 										Left:   unit.Dp(5),
 									}.Layout(gtx, func(gtx C) D {
-										return components.Memory(&gtx, theme, &memoryList)
+										return components.Memory(&gtx, theme, &memoryList, view)
 									})
 								},
 							)